@@ -0,0 +1,316 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/control"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/debugserver"
+	"github.com/context-demo/pkg/escalate"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/report"
+	"github.com/context-demo/pkg/rng"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/watchdog"
+)
+
+// command is the subcommand for a single scenario: the flags shared by every
+// scenario plus one flag per scenario parameter.
+type command struct {
+	s    scenario.Scenario
+	fs   *flag.FlagSet
+	step bool
+	runFlags
+	output
+}
+
+// runFlags holds the flags that configure a run of any scenario.
+type runFlags struct {
+	workers      int
+	runFor       time.Duration
+	timeout      time.Duration
+	cancelAfter  time.Duration
+	tickInterval time.Duration
+	jitter       rng.Jitter
+	warnAt       []float64
+	gracePeriod  time.Duration
+	cause        string
+	requestID    string
+	watch        time.Duration
+	seed         uint64
+	leakCheck    bool
+	verifyLeaks  bool
+	maxLeaked    int
+	hardKill     time.Duration
+}
+
+// register defines the run flags on fs.
+func (r *runFlags) register(fs *flag.FlagSet) {
+	fs.DurationVar(&r.runFor, "run-for", scenario.DefaultRunFor, "total duration of the demonstration")
+	fs.DurationVar(&r.cancelAfter, "cancel-after", scenario.DefaultCancelAfter, "how long workers run before they are cancelled")
+	fs.DurationVar(&r.timeout, "timeout", 0, "deadline for the whole run, propagated to every worker (default: none)")
+	fs.DurationVar(&r.tickInterval, "tick-interval", 0, "how often workers do a unit of work (default: each worker's own)")
+	fs.Func("jitter", "vary tick intervals: fixed, uniform or exponential (default fixed)", func(s string) (err error) {
+		r.jitter.Kind, err = rng.ParseJitterKind(s)
+		return err
+	})
+	fs.Float64Var(&r.jitter.Spread, "jitter-spread", rng.DefaultSpread, "largest fraction of a tick interval that -jitter uniform moves it by")
+	fs.Func("warn-at", "warn as each worker uses up these `percentages` of the time before its deadline, such as 50,80,95 (default: no warnings)", func(s string) (err error) {
+		r.warnAt, err = escalate.ParseThresholds(s)
+		return err
+	})
+	fs.DurationVar(&r.gracePeriod, "grace-period", 0, "how long to wait for cancelled workers (default: run-for minus cancel-after)")
+	fs.IntVar(&r.workers, "workers", 1, "number of instances of each worker")
+	fs.StringVar(&r.cause, "cause", scenario.DefaultCause.Error(), "cancellation cause")
+	fs.StringVar(&r.requestID, "request-id", "", "request ID to attach to every worker (default: a fresh one generated for each worker)")
+	fs.Uint64Var(&r.seed, "seed", 0, "seed for every random choice of the run, to reproduce it (default: random)")
+	fs.BoolVar(&r.leakCheck, "leakcheck", false, "diff goroutine stacks before and after the run and list the goroutines it left behind")
+	fs.BoolVar(&r.verifyLeaks, "verify-leaks", false, "like -leakcheck, but fail the run if goroutines other than those of deliberately leaky workers are left behind")
+	fs.IntVar(&r.maxLeaked, "max-leaked", -1, "fail the run if it leaves more than this many goroutines behind, measured like -leakcheck (default: no limit)")
+	fs.DurationVar(&r.hardKill, "hard-kill", 0, "if the run has not ended this long after cancel-after plus the grace period, dump the stuck workers' stacks and exit 6 (default: wait forever)")
+	fs.DurationVar(&r.watch, "watch", 0, "sample the goroutine count this often and show it alongside the events (default: off)")
+}
+
+// options returns the contextdemo options for the run flags.
+func (r *runFlags) options() []contextdemo.Option {
+	opts := []contextdemo.Option{
+		contextdemo.WithWorkers(r.workers),
+		contextdemo.WithRunFor(r.runFor),
+		contextdemo.WithCancelAfter(r.cancelAfter),
+		contextdemo.WithTickInterval(r.tickInterval),
+		contextdemo.WithJitter(r.jitter),
+		contextdemo.WithGracePeriod(r.gracePeriod),
+		contextdemo.WithTimeout(r.timeout),
+		contextdemo.WithWatch(r.watch),
+		contextdemo.WithCause(errors.New(r.cause)),
+	}
+	if len(r.warnAt) > 0 {
+		opts = append(opts, contextdemo.WithEscalation(r.warnAt...))
+	}
+	if r.requestID != "" {
+		opts = append(opts, contextdemo.WithRequestID(r.requestID))
+	} else {
+		opts = append(opts, contextdemo.WithMiddleware(ctxmw.NewRequestID()))
+	}
+	if r.leakCheck {
+		opts = append(opts, contextdemo.WithLeakCheck())
+	}
+	if r.maxLeaked >= 0 {
+		opts = append(opts, contextdemo.WithLeakBudget(r.maxLeaked))
+	}
+	if r.verifyLeaks {
+		opts = append(opts, contextdemo.WithVerifyNoLeaks(contextdemo.IntentionalLeaks...))
+	}
+	if r.hardKill > 0 {
+		opts = append(opts, contextdemo.WithHardKill(r.hardKill, watchdog.Kill(os.Stderr, exitKilled)))
+	}
+	if r.seed != 0 {
+		opts = append(opts, contextdemo.WithSeed(r.seed))
+	}
+	return opts
+}
+
+// output holds the flags that decide how a run is shown, shared by scenario
+// subcommands and -config.
+type output struct {
+	deterministic bool
+	json          bool
+	tui           bool
+	record        string
+	noColor       bool
+	quiet         bool
+	verbose       bool
+	debug         bool
+
+	report      string
+	debugAddr   string
+	controlAddr string
+	recording   *os.File       // open while -record is in effect
+	reporting   *report.Report // collects runs while -report is in effect
+	debugging   *debugserver.Server
+	controlling *control.Server
+	profile
+}
+
+// register defines the output flags on fs.
+func (o *output) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.deterministic, "deterministic", false, "run on a simulated clock")
+	fs.BoolVar(&o.json, "json", false, "write events to stdout as NDJSON instead of narrating")
+	fs.BoolVar(&o.tui, "tui", false, "show live worker states and cancel workers interactively")
+	fs.StringVar(&o.record, "record", "", "also write every event to `file`, for contextdemo replay")
+	fs.StringVar(&o.report, "report", "", "write a report of the run to `file` once it is over: HTML if the name ends in .html, the leak analysis as JSON if it ends in .json, Markdown otherwise")
+	fs.StringVar(&o.debugAddr, "debug-addr", "", "serve /debug/pprof and expvar counters at `address`, such as localhost:6060, while the run lasts")
+	fs.StringVar(&o.controlAddr, "control", "", "accept list and cancel <worker>|all [cause] commands at `address`, a unix socket path such as /tmp/contextdemo.sock or a loopback TCP address such as localhost:7070, while the run lasts; commands are not authenticated")
+	o.profile.register(fs)
+	o.registerHuman(fs)
+}
+
+// registerHuman defines the flags that shape human-readable output on fs.
+func (o *output) registerHuman(fs *flag.FlagSet) {
+	fs.BoolVar(&o.quiet, "q", false, "print only the final summary")
+	fs.BoolVar(&o.verbose, "v", false, "also print every tick")
+	fs.BoolVar(&o.debug, "vv", false, "also print events that carry no message")
+	fs.BoolVar(&o.noColor, "no-color", false, "never colour the output, even on a terminal")
+}
+
+// newCommand builds the subcommand for s, writing usage and errors to w.
+func newCommand(s scenario.Scenario, w io.Writer) *command {
+	c := &command{s: s, fs: flag.NewFlagSet(s.Name(), flag.ContinueOnError)}
+	fs := c.fs
+	fs.SetOutput(w)
+	c.runFlags.register(fs)
+	c.output.register(fs)
+	fs.BoolVar(&c.step, "step", false, "pause at each key moment of the run and explain it; press Enter to go on")
+
+	for _, p := range s.Metadata().Params {
+		switch p.Kind {
+		case scenario.ParamInt:
+			n, _ := strconv.Atoi(p.Default)
+			fs.Int(p.Name, n, p.Usage)
+		case scenario.ParamDuration:
+			d, _ := time.ParseDuration(p.Default)
+			fs.Duration(p.Name, d, p.Usage)
+		default:
+			fs.String(p.Name, p.Default, p.Usage)
+		}
+	}
+
+	fs.Usage = func() { c.usage(w) }
+	return c
+}
+
+// usage writes the scenario's help to w, all of it drawn from the
+// scenario's metadata and flags: what it shows, its own parameters, how a
+// run should end, and the flags every scenario shares.
+func (c *command) usage(w io.Writer) {
+	md := c.s.Metadata()
+	own := make(map[string]bool)
+	for _, p := range md.Params {
+		own[p.Name] = true
+	}
+
+	fmt.Fprintf(w, "Usage: contextdemo %s", c.s.Name())
+	for _, p := range md.Params {
+		fmt.Fprintf(w, " [-%s %s]", p.Name, p.Kind)
+	}
+	fmt.Fprint(w, " [flags]\n\n")
+	fmt.Fprintf(w, "%s.\n", md.Description)
+	if len(md.Tags) > 0 {
+		fmt.Fprintf(w, "Tags: %s\n", strings.Join(md.Tags, ", "))
+	}
+	if len(own) > 0 {
+		fmt.Fprintln(w, "\nParameters:")
+		printFlags(w, c.fs, func(name string) bool { return own[name] })
+	}
+
+	fmt.Fprintln(w, "\nExpected outcome:")
+	if md.Outcome != "" {
+		fmt.Fprintln(w, wrap(md.Outcome, "  ", 76))
+	}
+	switch {
+	case md.Leaks != nil:
+		fmt.Fprintf(w, "  Leaks %d worker(s) on purpose with the default parameters, and as many as the parameters call for otherwise; that alone still exits 0.\n", md.ExpectedLeaks)
+	case md.ExpectedLeaks > 0:
+		fmt.Fprintf(w, "  Leaks %d worker(s) per -workers instance on purpose; that alone still exits 0.\n", md.ExpectedLeaks)
+	default:
+		fmt.Fprintln(w, "  Every worker exits; a leak is a bug and exits 5.")
+	}
+	if md.ExpectedFailures > 0 {
+		fmt.Fprintf(w, "  Fails %d worker(s) on purpose; that alone still exits 0.\n", md.ExpectedFailures)
+	}
+	if md.Duration > 0 {
+		fmt.Fprintf(w, "  Takes about %v with default flags, or next to no time with -deterministic.\n", md.Duration)
+	}
+
+	fmt.Fprintln(w, "\nFlags:")
+	printFlags(w, c.fs, func(name string) bool { return !own[name] })
+}
+
+// printFlags writes the defaults of the flags in fs that keep accepts to w,
+// formatted like flag.PrintDefaults.
+func printFlags(w io.Writer, fs *flag.FlagSet, keep func(name string) bool) {
+	sub := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	sub.SetOutput(w)
+	fs.VisitAll(func(f *flag.Flag) {
+		if keep(f.Name) {
+			sub.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	sub.PrintDefaults()
+}
+
+// wrap breaks s into lines of at most width columns, each starting with
+// indent.
+func wrap(s, indent string, width int) string {
+	var b strings.Builder
+	line := indent
+	for _, word := range strings.Fields(s) {
+		if line != indent && len(line)+1+len(word) > width {
+			b.WriteString(line + "\n")
+			line = indent
+		}
+		if line != indent {
+			line += " "
+		}
+		line += word
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// parse parses args and returns the options to run the scenario with.
+// Parameters are only passed on when set, so the scenario's own defaults
+// apply otherwise.
+func (c *command) parse(args []string) ([]contextdemo.Option, error) {
+	if err := c.fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if c.fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected argument %q", c.fs.Arg(0))
+		fmt.Fprintf(c.fs.Output(), "%v\n", err)
+		c.fs.Usage()
+		return nil, err
+	}
+
+	opts := append(c.runFlags.options(), contextdemo.WithScenario(c.s.Name()))
+	for _, p := range c.s.Metadata().Params {
+		if f := c.set(p.Name); f != nil {
+			opts = append(opts, contextdemo.WithParam(p.Name, f.Value.String()))
+		}
+	}
+	return opts, nil
+}
+
+// level returns the verbosity selected by -q, -v and -vv; the most verbose
+// wins.
+func (o *output) level() event.Level {
+	switch {
+	case o.debug:
+		return event.LevelDebug
+	case o.verbose:
+		return event.LevelVerbose
+	case o.quiet:
+		return event.LevelQuiet
+	default:
+		return event.LevelNormal
+	}
+}
+
+// set returns the flag called name if it was given on the command line.
+func (c *command) set(name string) *flag.Flag {
+	var found *flag.Flag
+	c.fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = f
+		}
+	})
+	return found
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/context-demo/pkg/scenario"
+)
+
+// builtins describes the subcommands that are not scenarios.
+var builtins = []struct{ name, usage string }{
+	{"help", "show usage, or the flags of a scenario"},
+	{"list", "list scenarios with their parameters"},
+	{"run-all", "run several scenarios, each in isolation"},
+	{"replay", "play back a run recorded with -record"},
+	{"completion", "print a shell completion script"},
+}
+
+// shells are the shells completion can write scripts for.
+var shells = []string{"bash", "zsh", "fish"}
+
+// completion writes the completion script for the shell named in args to
+// stdout. Scenario names and flags are taken from the registry when the
+// script is generated, so regenerate it after adding scenarios.
+func completion(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintf(stderr, "Usage: contextdemo completion %s\n", strings.Join(shells, "|"))
+		return exitUsage
+	}
+	switch args[0] {
+	case "bash":
+		writeBash(stdout, false)
+	case "zsh":
+		writeBash(stdout, true)
+	case "fish":
+		writeFish(stdout)
+	default:
+		fmt.Fprintf(stderr, "contextdemo: no completion for shell %q; try %s\n", args[0], strings.Join(shells, ", "))
+		return exitUsage
+	}
+	return exitOK
+}
+
+// flagSets returns the flag set of every subcommand that has one, keyed by
+// subcommand name.
+func flagSets() map[string]*flag.FlagSet {
+	sets := map[string]*flag.FlagSet{
+		"run-all": newRunAll(io.Discard).fs,
+		"replay":  newReplay(io.Discard).fs,
+	}
+	for _, s := range scenario.All() {
+		sets[s.Name()] = newCommand(s, io.Discard).fs
+	}
+	return sets
+}
+
+// flagNames returns the flags of fs as they are typed, such as -workers.
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, "-"+f.Name) })
+	return names
+}
+
+// scenarioNames returns the name of every registered scenario.
+func scenarioNames() []string {
+	var names []string
+	for _, s := range scenario.All() {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// writeBash writes a bash completion function. zsh runs the same function
+// through bashcompinit.
+func writeBash(w io.Writer, zsh bool) {
+	if zsh {
+		fmt.Fprintln(w, "#compdef contextdemo")
+		fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	}
+	var first []string
+	for _, b := range builtins {
+		first = append(first, b.name)
+	}
+	first = append(first, scenarioNames()...)
+	first = append(first, "-config")
+
+	fmt.Fprintln(w, "_contextdemo() {")
+	fmt.Fprintln(w, `	local cur=${COMP_WORDS[COMP_CWORD]} words=""`)
+	fmt.Fprintln(w, "	if [[ $COMP_CWORD -eq 1 ]]; then")
+	fmt.Fprintf(w, "\t\twords=%q\n", strings.Join(first, " "))
+	fmt.Fprintln(w, "	else")
+	fmt.Fprintln(w, `		case ${COMP_WORDS[1]} in`)
+	fmt.Fprintf(w, "\t\thelp) words=%q ;;\n", strings.Join(scenarioNames(), " "))
+	fmt.Fprintf(w, "\t\tcompletion) words=%q ;;\n", strings.Join(shells, " "))
+	sets := flagSets()
+	for _, name := range append([]string{"run-all", "replay"}, scenarioNames()...) {
+		words := flagNames(sets[name])
+		if name == "run-all" {
+			words = append(words, scenarioNames()...)
+		}
+		fmt.Fprintf(w, "\t\t%s) words=%q ;;\n", name, strings.Join(words, " "))
+	}
+	fmt.Fprintln(w, "		esac")
+	fmt.Fprintln(w, "	fi")
+	fmt.Fprintln(w, `	COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _contextdemo contextdemo")
+}
+
+// writeFish writes fish completions, which also show what each subcommand
+// and flag is for.
+func writeFish(w io.Writer) {
+	const cmd = "complete -c contextdemo"
+	fmt.Fprintf(w, "%s -f\n", cmd)
+	for _, b := range builtins {
+		fmt.Fprintf(w, "%s -n __fish_use_subcommand -a %s -d %s\n", cmd, b.name, fishQuote(b.usage))
+	}
+	for _, s := range scenario.All() {
+		fmt.Fprintf(w, "%s -n __fish_use_subcommand -a %s -d %s\n", cmd, s.Name(), fishQuote(s.Metadata().Description))
+	}
+	fmt.Fprintf(w, "%s -n __fish_use_subcommand -o config -r -d %s\n", cmd, fishQuote("file declaring the scenarios to run"))
+	scenarios := strings.Join(scenarioNames(), " ")
+	fmt.Fprintf(w, "%s -n '__fish_seen_subcommand_from help run-all' -a %s\n", cmd, fishQuote(scenarios))
+	fmt.Fprintf(w, "%s -n '__fish_seen_subcommand_from completion' -a %s\n", cmd, fishQuote(strings.Join(shells, " ")))
+	fmt.Fprintf(w, "%s -n '__fish_seen_subcommand_from replay' -F\n", cmd)
+
+	sets := flagSets()
+	for _, name := range append([]string{"run-all", "replay"}, scenarioNames()...) {
+		sets[name].VisitAll(func(f *flag.Flag) {
+			_, usage := flag.UnquoteUsage(f)
+			fmt.Fprintf(w, "%s -n '__fish_seen_subcommand_from %s' -o %s -d %s\n", cmd, name, f.Name, fishQuote(usage))
+		})
+	}
+}
+
+// fishQuote quotes s as a single fish word.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
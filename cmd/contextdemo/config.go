@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/context-demo/pkg/config"
+	"github.com/context-demo/pkg/scenario"
+)
+
+// isConfigFlag reports whether arg is -config or --config, with or without
+// a value attached.
+func isConfigFlag(arg string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+	name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return name == "config"
+}
+
+// runConfig runs every scenario declared in the file named by -config, in
+// order, and returns the first nonzero exit code, if any.
+func runConfig(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var (
+		path string
+		out  output
+	)
+	fs := flag.NewFlagSet("contextdemo -config", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&path, "config", "", "file declaring the scenarios to run")
+	out.register(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitUsage
+	}
+	if err := out.open(stderr); err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitError
+	}
+	defer out.close(stderr)
+	runs := cfg.Resolve()
+	for _, r := range runs {
+		if _, ok := scenario.Lookup(r.Scenario); !ok {
+			fmt.Fprintf(stderr, "contextdemo: %s: %v: %q\n", path, scenario.ErrUnknownScenario, r.Scenario)
+			return exitUsage
+		}
+	}
+
+	code := exitOK
+	for i, r := range runs {
+		s, _ := scenario.Lookup(r.Scenario)
+		if !out.json {
+			fmt.Fprintf(stdout, "\n=== [%d/%d] %s ===\n", i+1, len(runs), r.Scenario)
+		}
+		if c := out.execute(s, r.Options(), stdin, stdout, stderr); code == exitOK {
+			code = c
+		}
+	}
+	return code
+}
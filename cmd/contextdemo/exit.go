@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/scenario"
+)
+
+// Exit codes. Where a run fits more than one, the lowest of the run outcome
+// codes (3 and up) wins.
+const (
+	exitOK    = 0 // every worker that was meant to stop did so in time
+	exitError = 1 // the scenario could not be run
+	exitUsage = 2 // bad command line
+	// exitFailed means more workers returned an error than the scenario
+	// fails by design.
+	exitFailed = 3
+	// exitTimedOut means a worker saw cancellation but was still shutting
+	// down when the grace period ran out.
+	exitTimedOut = 4
+	// exitLeaked means more workers ignored cancellation than the scenario
+	// leaks by design, or the run broke its goroutine leak budget.
+	exitLeaked = 5
+	// exitKilled means the run was still shutting down at the -hard-kill
+	// deadline and the watchdog ended the process. It is set by the
+	// watchdog, never by exitCode.
+	exitKilled = 6
+)
+
+// exitCode classifies res, produced by s.
+func exitCode(s scenario.Scenario, res *contextdemo.Result) int {
+	md := s.Metadata()
+	switch {
+	case res.Failed() > md.ExpectedFailures:
+		return exitFailed
+	case res.TimedOut() > 0:
+		return exitTimedOut
+	case res.Leaked()-res.TimedOut() > res.ExpectedLeaks:
+		return exitLeaked
+	default:
+		return exitOK
+	}
+}
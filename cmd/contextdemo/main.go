@@ -0,0 +1,409 @@
+// Command contextdemo runs the context demonstrations from the command line.
+//
+// All of the demonstration logic lives under pkg/; this binary only picks a
+// scenario, runs it, and prints the result.
+//
+// Usage:
+//
+//	contextdemo [scenario] [flags]
+//
+// Each scenario is a subcommand with the common flags plus any parameters of
+// its own; run "contextdemo list" or "contextdemo help <scenario>" to see
+// them. With -json, the narration is replaced by one JSON object per event
+// on stdout, for jq or grading scripts, and the result by a leak_report
+// object with the run's leak analysis; -report file.json writes the same
+// analysis for every run as one document.
+//
+// A scripted sequence of runs can be kept in a file instead; see package
+// config for the format:
+//
+//	contextdemo -config demo.toml [-deterministic] [-q]
+//
+// With -control, workers can be cancelled by name from another terminal
+// while the run goes on; see package control for the commands:
+//
+//	echo 'cancel hogwarts rollback' | nc -U /tmp/contextdemo.sock
+//
+// With -warn-at 50,80,95, each worker under a deadline is warned as it uses
+// up half, four fifths and nineteen twentieths of its time, in the
+// narration, the JSON stream and the TUI alike; see package escalate.
+//
+// While a run is going, kill -USR1 <pid> writes the stack of every
+// goroutine to stderr, with those running a worker labelled by its name, so
+// a leaked worker can be inspected before the demonstration ends.
+//
+// The exit status is 0 only if every worker that was meant to stop did so
+// within the grace period: 3 means a worker returned an error the scenario
+// did not fail on purpose, 4 that one was still shutting down when the
+// grace period ran out or the whole run outlived -timeout, and 5 that more
+// workers leaked than the scenario does by design, or that the run left
+// more goroutines behind than -max-leaked or -verify-leaks allow. 6 means
+// the run hung on shutdown past -hard-kill and the watchdog killed it,
+// after dumping the stacks of the workers still running to stderr. 1 and 2
+// report a run that could not start and a bad command line.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/context-demo/pkg/ansi"
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/control"
+	"github.com/context-demo/pkg/debugserver"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/leakcheck"
+	"github.com/context-demo/pkg/report"
+	"github.com/context-demo/pkg/scenario"
+	_ "github.com/context-demo/pkg/scenario/builtin" // blank-import further scenario packages alongside this one
+	"github.com/context-demo/pkg/stackdump"
+	"github.com/context-demo/pkg/tui"
+	"github.com/context-demo/pkg/worker"
+)
+
+func main() {
+	stop := stackdump.OnSignal(os.Stderr)
+	code := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && isConfigFlag(args[0]) {
+		return runConfig(args, stdin, stdout, stderr)
+	}
+	name := contextdemo.DefaultScenario
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+	switch name {
+	case "help":
+		return help(args, stdout, stderr)
+	case "list":
+		list(stdout)
+		return exitOK
+	case "run-all":
+		return runAll(args, stdout, stderr)
+	case "replay":
+		return replay(args, stdout, stderr)
+	case "completion":
+		return completion(args, stdout, stderr)
+	}
+
+	s, ok := scenario.Lookup(name)
+	if !ok {
+		fmt.Fprintf(stderr, "contextdemo: %v: %q\n\n", scenario.ErrUnknownScenario, name)
+		listScenarios(stderr)
+		return exitUsage
+	}
+	cmd := newCommand(s, stderr)
+	opts, err := cmd.parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	if cmd.step {
+		if cmd.tui {
+			fmt.Fprintln(stderr, "contextdemo: -step cannot be combined with -tui")
+			return exitUsage
+		}
+		if cmd.hardKill > 0 {
+			fmt.Fprintln(stderr, "contextdemo: -step cannot be combined with -hard-kill")
+			return exitUsage
+		}
+		opts = append(opts, contextdemo.WithStep(stepper(stdin, stderr)))
+	}
+	if err := cmd.output.open(stderr); err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitError
+	}
+	defer cmd.output.close(stderr)
+	return cmd.output.execute(s, opts, stdin, stdout, stderr)
+}
+
+// execute runs s with opts, showing the run as o says, and returns the exit
+// code for the run.
+func (o *output) execute(s scenario.Scenario, opts []contextdemo.Option, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts = append(opts, o.options(stdout)...)
+	color := o.color(stdout)
+
+	var (
+		res *contextdemo.Result
+		err error
+	)
+	if o.tui {
+		res, err = runTUI(opts, stdin, stdout)
+	} else {
+		res, err = contextdemo.Run(context.Background(), opts...)
+	}
+	var leaks *leakcheck.Error
+	overBudget := errors.Is(err, contextdemo.ErrLeakBudget)
+	if err != nil && !errors.Is(err, contextdemo.ErrTimeout) && !errors.As(err, &leaks) && !overBudget {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitError
+	}
+	if o.reporting != nil {
+		o.reporting.Add(res, err)
+	}
+	if o.json {
+		writeLeaks(stdout, res, err)
+	} else {
+		printResult(stdout, res, color)
+	}
+	if leaks != nil || overBudget {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitLeaked
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitTimedOut
+	}
+	return exitCode(s, res)
+}
+
+// stepper returns a step function that explains each phase on w and waits
+// for a line from r.
+func stepper(r io.Reader, w io.Writer) func(scenario.Phase) {
+	br := bufio.NewReader(r)
+	return func(p scenario.Phase) {
+		fmt.Fprintf(w, "\n[step: %s] %s\nPress Enter to continue...", p, p.Explain())
+		br.ReadString('\n')
+	}
+}
+
+// color reports whether human output to w is coloured.
+func (o *output) color(w io.Writer) bool {
+	return !o.noColor && ansi.Enabled(w)
+}
+
+// open starts the debug server and the control listener, creates the
+// -record file and starts profiling, if asked to, reporting the servers'
+// addresses on stderr. Call close once every run is over.
+func (o *output) open(stderr io.Writer) error {
+	if o.debugAddr != "" {
+		s, err := debugserver.Start(o.debugAddr)
+		if err != nil {
+			return err
+		}
+		o.debugging = s
+		fmt.Fprintf(stderr, "Debug server at http://%s/debug/pprof/\n", s.Addr())
+	}
+	if o.controlAddr != "" {
+		s, err := control.Start(o.controlAddr)
+		if err != nil {
+			o.stopDebugging()
+			return err
+		}
+		o.controlling = s
+		fmt.Fprintf(stderr, "Control listener at %s\n", s.Addr())
+	}
+	if o.record != "" {
+		f, err := os.Create(o.record)
+		if err != nil {
+			o.stopDebugging()
+			return err
+		}
+		o.recording = f
+	}
+	if o.report != "" {
+		o.reporting = report.New()
+	}
+	if err := o.profile.start(); err != nil {
+		o.stopDebugging()
+		if o.recording != nil {
+			o.recording.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+// stopDebugging stops the debug server and the control listener, if any.
+func (o *output) stopDebugging() {
+	if o.debugging != nil {
+		o.debugging.Close()
+		o.debugging = nil
+	}
+	if o.controlling != nil {
+		o.controlling.Close()
+		o.controlling = nil
+	}
+}
+
+// close closes the -record file and writes out the report and the
+// profiles, if any, reporting failures on stderr.
+func (o *output) close(stderr io.Writer) {
+	o.stopDebugging()
+	if o.recording != nil {
+		o.recording.Close()
+	}
+	if err := o.writeReport(); err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+	}
+	if err := o.profile.stop(); err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+	}
+}
+
+// writeLeaks writes the leak analysis of res, which ended with err, to w as
+// a single JSON record, to follow the run's events in -json output.
+func writeLeaks(w io.Writer, res *contextdemo.Result, err error) {
+	json.NewEncoder(w).Encode(report.Analyze(res, err))
+}
+
+// writeReport writes the -report file, if any, in the format its name
+// calls for.
+func (o *output) writeReport() error {
+	if o.reporting == nil {
+		return nil
+	}
+	f, err := os.Create(o.report)
+	if err != nil {
+		return err
+	}
+	write := o.reporting.WriteMarkdown
+	switch filepath.Ext(o.report) {
+	case ".html", ".htm":
+		write = o.reporting.WriteHTML
+	case ".json":
+		write = o.reporting.WriteJSON
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("report: %w", err)
+	}
+	return f.Close()
+}
+
+// options returns the options that show a run on stdout as o says. With
+// -tui the display is left to runTUI.
+func (o *output) options(stdout io.Writer) []contextdemo.Option {
+	var opts []contextdemo.Option
+	if o.deterministic {
+		opts = append(opts, contextdemo.WithDeterministic())
+	}
+	if o.recording != nil {
+		opts = append(opts, contextdemo.WithSink(event.NewJSONSink(o.recording)))
+	}
+	if o.reporting != nil {
+		opts = append(opts, contextdemo.WithSink(o.reporting))
+	}
+	if o.debugging != nil {
+		opts = append(opts, contextdemo.WithSink(debugserver.Sink))
+	}
+	if o.controlling != nil {
+		opts = append(opts, contextdemo.WithMiddleware(o.controlling.Middleware()))
+	}
+	switch {
+	case o.json:
+		opts = append(opts,
+			contextdemo.WithLogger(worker.Discard),
+			contextdemo.WithSink(event.NewJSONSink(stdout)),
+		)
+	case !o.tui:
+		opts = append(opts,
+			contextdemo.WithLogger(worker.Discard),
+			contextdemo.WithSink(event.AtLevel(event.NewHumanSink(stdout, o.color(stdout)), o.level())),
+		)
+	}
+	return opts
+}
+
+// runTUI runs the scenario behind a live tui.Model reading commands from
+// stdin.
+func runTUI(opts []contextdemo.Option, stdin io.Reader, stdout io.Writer) (*contextdemo.Result, error) {
+	m := tui.New()
+	defer m.Release()
+	opts = append(opts,
+		contextdemo.WithLogger(worker.Discard),
+		contextdemo.WithSink(m),
+		contextdemo.WithMiddleware(m.Middleware()),
+	)
+
+	var (
+		res  *contextdemo.Result
+		err  error
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		res, err = contextdemo.Run(context.Background(), opts...)
+	}()
+	m.Run(done, stdin, stdout, 100*time.Millisecond)
+	<-done
+	return res, err
+}
+
+// help prints general usage, or the flags of the scenario named in args.
+func help(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stdout, "Usage: contextdemo [scenario] [flags]")
+		fmt.Fprintln(stdout, "       contextdemo run-all [flags] [scenario...]")
+		fmt.Fprintln(stdout, "       contextdemo -config file [flags]")
+		fmt.Fprintln(stdout, "       contextdemo replay [flags] file")
+		fmt.Fprintln(stdout, "       contextdemo completion bash|zsh|fish")
+		fmt.Fprintf(stdout, "\nWith no scenario, %s is run.\n", contextdemo.DefaultScenario)
+		fmt.Fprintln(stdout, "\nScenarios:")
+		listScenarios(stdout)
+		fmt.Fprintln(stdout, "\nRun \"contextdemo list\" for scenario parameters, or")
+		fmt.Fprintln(stdout, "\"contextdemo help <scenario>\" for a scenario's flags.")
+		return exitOK
+	}
+	s, ok := scenario.Lookup(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "contextdemo: %v: %q\n", scenario.ErrUnknownScenario, args[0])
+		return exitUsage
+	}
+	cmd := newCommand(s, stdout)
+	cmd.fs.Usage()
+	return exitOK
+}
+
+// listScenarios writes the name, description and tags of every registered
+// scenario to w.
+func listScenarios(w io.Writer) {
+	all := scenario.All()
+	width := 0
+	for _, s := range all {
+		width = max(width, len(s.Name()))
+	}
+	for _, s := range all {
+		md := s.Metadata()
+		fmt.Fprintf(w, "  %-*s %s [%s]\n", width, s.Name(), md.Description, strings.Join(md.Tags, ", "))
+	}
+}
+
+// list writes every registered scenario to w with its description, tags
+// and the defaults of its own parameters.
+func list(w io.Writer) {
+	for i, s := range scenario.All() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		md := s.Metadata()
+		fmt.Fprintf(w, "%s\n  %s\n", s.Name(), md.Description)
+		if len(md.Tags) > 0 {
+			fmt.Fprintf(w, "  tags: %s\n", strings.Join(md.Tags, ", "))
+		}
+		for _, p := range md.Params {
+			fmt.Fprintf(w, "  -%s %s", p.Name, p.Kind)
+			if p.Default != "" {
+				fmt.Fprintf(w, " (default %s)", p.Default)
+			}
+			fmt.Fprintf(w, "\n      %s\n", p.Usage)
+		}
+	}
+}
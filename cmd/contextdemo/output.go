@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/context-demo/pkg/ansi"
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/watch"
+	"github.com/context-demo/pkg/worker"
+)
+
+// exitColors colours the EXIT column of the result table. The sequences are
+// all the same length, which keeps tabwriter's column widths right.
+var exitColors = map[worker.ExitReason]string{
+	worker.ExitUnknown:   ansi.Magenta,
+	worker.ExitCancelled: ansi.Green,
+	worker.ExitCompleted: ansi.Cyan,
+	worker.ExitFailed:    ansi.Yellow,
+	worker.ExitLeaked:    ansi.Red,
+}
+
+// printTrend writes the goroutine counts sampled with -watch to w as a
+// sparkline, with the growth over the run picked out if there was any.
+func printTrend(w io.Writer, trend []watch.Point, paint func(c, s string) string) {
+	if len(trend) < 2 {
+		return
+	}
+	first, last := trend[0].Goroutines, trend[len(trend)-1].Goroutines
+	peak := first
+	for _, p := range trend {
+		peak = max(peak, p.Goroutines)
+	}
+	growth := fmt.Sprintf("%+d", last-first)
+	if last > first {
+		growth = paint(ansi.Red, growth)
+	}
+	fmt.Fprintf(w, "Goroutines over %d samples: %s  start %d, peak %d, end %d (%s)\n",
+		len(trend), watch.Sparkline(trend), first, peak, last, growth)
+}
+
+// doneState says whether an uncancelled context has ended all the same.
+func doneState(done bool) string {
+	if done {
+		return "ended by its parent or deadline"
+	}
+	return "still live"
+}
+
+// exitReasons are the exit reasons in the order the summary lists them.
+var exitReasons = []string{
+	event.ReasonCompleted,
+	event.ReasonCanceled,
+	event.ReasonDeadlineExceeded,
+	event.ReasonFailed,
+	event.ReasonLeaked,
+}
+
+// counts formats the non-zero counts in n for keys, in order, as
+// "count × key, ...".
+func counts(n map[string]int, keys []string) string {
+	var parts []string
+	for _, k := range keys {
+		if n[k] > 0 {
+			parts = append(parts, fmt.Sprintf("%d × %s", n[k], k))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printTimings writes each worker's stopwatch: when it started, saw
+// cancellation and exited, measured from the start of the run, and how long
+// it took to react to cancellation. It ends with the run's wall time.
+func printTimings(w io.Writer, res *contextdemo.Result) {
+	since := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return "+" + t.Sub(res.StartedAt).String()
+	}
+	fmt.Fprintln(w, "\nTimings, from the start of the run:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  WORKER\tSTARTED\tSAW CANCEL\tEXITED\tTIME TO REACT")
+	for _, r := range res.Workers {
+		react := "-"
+		if r.Observed {
+			react = r.Propagation.String()
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", r.Worker, since(r.StartedAt), since(r.ObservedAt), since(r.ExitedAt), react)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "Wall time: %v.\n", res.Wall())
+}
+
+// printResult writes a human-readable summary of res to w, in colour if
+// color is set.
+func printResult(w io.Writer, res *contextdemo.Result, color bool) {
+	paint := func(c, s string) string {
+		if !color {
+			return s
+		}
+		return ansi.Paint(c, s)
+	}
+	fmt.Fprintf(w, "\nResults for %s:\n", res.Scenario)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  WORKER\tEXIT\tPROCESSED\tPROPAGATION\tLATENCY\tCAUSE")
+	for _, r := range res.Workers {
+		propagation, latency, cause := "-", "-", "-"
+		if r.Observed {
+			propagation = r.Propagation.String()
+		}
+		if r.Exit == worker.ExitCancelled {
+			latency = r.Latency.String()
+		}
+		if r.Cause != nil {
+			cause = r.Cause.Error()
+		}
+		if r.Err != nil {
+			cause = r.Err.Error()
+		}
+		cause = strings.ReplaceAll(cause, "\n", "; ") // as from errors.Join
+		if cause != "-" {
+			cause = paint(ansi.Magenta, cause)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%d\t%s\t%s\t%s\n", r.Worker, paint(exitColors[r.Exit], r.Exit.String()), r.Processed, propagation, latency, cause)
+	}
+	tw.Flush()
+	printTimings(w, res)
+	fmt.Fprintf(w, "\n%d of %d worker(s) exited. Seed: %d.\n", res.Exited(), len(res.Workers), res.Seed)
+	if n := res.Observed(); n > 0 {
+		d, slowest := res.MaxPropagation()
+		fmt.Fprintf(w, "Cancellation reached %d worker(s); the slowest, %s, after %v.\n", n, slowest, d)
+	}
+	if n := res.Leaked(); n > 0 {
+		msg := fmt.Sprintf("%d worker(s) leaked: %d blocked, %d still spinning.", n, res.Blocked(), n-res.Blocked())
+		fmt.Fprintln(w, paint(ansi.Bold+ansi.Red, msg))
+	}
+	fmt.Fprintf(w, "Exit reasons: %s.\n", counts(res.Reasons(), exitReasons))
+	if causes := res.Causes(); len(causes) > 0 {
+		fmt.Fprintf(w, "Causes: %s.\n", counts(causes, slices.Sorted(maps.Keys(causes))))
+	}
+	if unacked := res.Unacked(); len(unacked) > 0 {
+		fmt.Fprintf(w, "Exit confirmed by %d worker(s); no acknowledgement from %s.\n", len(res.Workers)-len(unacked), strings.Join(unacked, ", "))
+	}
+	printTrend(w, res.Trend, paint)
+	if !res.LeakChecked {
+		return
+	}
+	if len(res.Timers) > 0 {
+		fmt.Fprintln(w, paint(ansi.Red, fmt.Sprintf("Timer check: the run left %d ticker(s) and timer(s) unstopped:", len(res.Timers))))
+		for _, t := range res.Timers {
+			fmt.Fprintf(w, "  %v\n", t)
+		}
+	}
+	if len(res.Uncancelled) > 0 {
+		fmt.Fprintln(w, paint(ansi.Red, fmt.Sprintf("Cancel check: the run never cancelled %d derived context(s):", len(res.Uncancelled))))
+		for _, u := range res.Uncancelled {
+			fmt.Fprintf(w, "  %s context, %s, derived at:\n", u.Label, doneState(u.Done))
+			for _, f := range u.Stack {
+				fmt.Fprintf(w, "      %v\n", f)
+			}
+		}
+	}
+	if len(res.Goroutines) == 0 {
+		fmt.Fprintln(w, "Leak check: the run left no goroutines behind.")
+		return
+	}
+	fmt.Fprintln(w, paint(ansi.Red, fmt.Sprintf("Leak check: the run left %d goroutine(s) behind:", len(res.Goroutines))))
+	for _, s := range res.LeakSites {
+		fmt.Fprintf(w, "  %v\n", s)
+		g := s.Goroutines[0]
+		fmt.Fprintf(w, "    e.g. goroutine %d [%s]:\n", g.ID, g.State)
+		for _, f := range g.Stack {
+			fmt.Fprintf(w, "      %v\n", f)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profile holds the flags that capture pprof profiles and an execution
+// trace around a run, for comparing a leaky scenario with a well-behaved one
+// in go tool pprof and go tool trace.
+type profile struct {
+	cpu        string
+	mem        string
+	goroutines string
+	trace      string
+
+	cpuFile   *os.File // open while the CPU profile is being written
+	traceFile *os.File // open while the trace is being written
+}
+
+// register defines the profiling flags on fs.
+func (p *profile) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.cpu, "cpuprofile", "", "write a CPU profile of the run to `file`")
+	fs.StringVar(&p.mem, "memprofile", "", "write a heap profile to `file` once the run is over")
+	fs.StringVar(&p.goroutines, "goroutineprofile", "", "write a goroutine profile to `file` once the run is over, with each worker's goroutines labelled")
+	fs.StringVar(&p.trace, "trace", "", "write a runtime execution trace of the run to `file`; each worker is a task")
+}
+
+// start begins the CPU profile and the trace, if any.
+func (p *profile) start() error {
+	if p.cpu != "" {
+		f, err := os.Create(p.cpu)
+		if err != nil {
+			return err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("cpuprofile: %w", err)
+		}
+		p.cpuFile = f
+	}
+	if p.trace != "" {
+		f, err := os.Create(p.trace)
+		if err != nil {
+			p.stopCPU()
+			return err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			p.stopCPU()
+			return fmt.Errorf("trace: %w", err)
+		}
+		p.traceFile = f
+	}
+	return nil
+}
+
+// stop ends the trace and the CPU profile and writes the goroutine and
+// heap profiles, if any. Leaked workers are still running at this point, so
+// their goroutines and whatever they hold on to show up in the profiles,
+// and their tasks never end in the trace.
+func (p *profile) stop() error {
+	if p.traceFile != nil {
+		trace.Stop()
+		if err := p.traceFile.Close(); err != nil {
+			return err
+		}
+		p.traceFile = nil
+	}
+	if err := p.stopCPU(); err != nil {
+		return err
+	}
+	if p.goroutines != "" {
+		if err := writeProfile(p.goroutines, "goroutine"); err != nil {
+			return fmt.Errorf("goroutineprofile: %w", err)
+		}
+	}
+	if p.mem != "" {
+		runtime.GC() // report live objects as of the end of the run
+		if err := writeProfile(p.mem, "heap"); err != nil {
+			return fmt.Errorf("memprofile: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeProfile writes the named runtime/pprof profile to the file called
+// path.
+func writeProfile(path, name string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// stopCPU ends the CPU profile, if any.
+func (p *profile) stopCPU() error {
+	if p.cpuFile == nil {
+		return nil
+	}
+	pprof.StopCPUProfile()
+	err := p.cpuFile.Close()
+	p.cpuFile = nil
+	return err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/context-demo/pkg/event"
+)
+
+// replayCommand is the replay subcommand.
+type replayCommand struct {
+	fs      *flag.FlagSet
+	speed   float64
+	instant bool
+	output
+}
+
+// newReplay builds the replay subcommand, writing usage and errors to w.
+func newReplay(w io.Writer) *replayCommand {
+	c := &replayCommand{fs: flag.NewFlagSet("replay", flag.ContinueOnError)}
+	fs := c.fs
+	fs.SetOutput(w)
+	fs.Float64Var(&c.speed, "speed", 1, "playback speed relative to the recording, such as 0.5, 2 or 10")
+	fs.BoolVar(&c.instant, "instant", false, "play every event at once, without the recorded pauses")
+	c.output.registerHuman(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(w, "Usage: contextdemo replay [flags] file")
+		fmt.Fprintln(w, "\nPlays back a run recorded with -record.")
+		fmt.Fprintln(w, "\nFlags:")
+		fs.PrintDefaults()
+	}
+	return c
+}
+
+// replay plays back a file written with -record through the human output,
+// at the original pace, slower, faster or at once.
+func replay(args []string, stdout, stderr io.Writer) int {
+	c := newReplay(stderr)
+	fs, out := c.fs, &c.output
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	speed := c.speed
+	switch {
+	case c.instant:
+		speed = 0
+	case speed <= 0:
+		fmt.Fprintf(stderr, "contextdemo: -speed must be positive, not %v; use -instant to play without pauses\n", speed)
+		return exitUsage
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitError
+	}
+	defer f.Close()
+	sink := event.AtLevel(event.NewHumanSink(stdout, out.color(stdout)), out.level())
+	if err := event.Replay(context.Background(), f, sink, speed); err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %s: %v\n", fs.Arg(0), err)
+		return exitError
+	}
+	return exitOK
+}
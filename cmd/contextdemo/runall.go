@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/leakcheck"
+	"github.com/context-demo/pkg/scenario"
+)
+
+// exitStatus names the run outcome exit codes in the run-all summary.
+var exitStatus = map[int]string{
+	exitOK:       "ok",
+	exitError:    "error",
+	exitFailed:   "failed",
+	exitTimedOut: "timed out",
+	exitLeaked:   "leaked",
+}
+
+// runAllCommand is the run-all subcommand.
+type runAllCommand struct {
+	fs       *flag.FlagSet
+	parallel bool
+	tag      string
+	runFlags
+	output
+}
+
+// newRunAll builds the run-all subcommand, writing usage and errors to w.
+func newRunAll(w io.Writer) *runAllCommand {
+	c := &runAllCommand{fs: flag.NewFlagSet("run-all", flag.ContinueOnError)}
+	fs := c.fs
+	fs.SetOutput(w)
+	fs.BoolVar(&c.parallel, "parallel", false, "run the scenarios at the same time instead of one after another")
+	fs.StringVar(&c.tag, "tag", "", "run only the scenarios with this tag")
+	c.runFlags.register(fs)
+	c.output.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(w, "Usage: contextdemo run-all [flags] [scenario...]")
+		fmt.Fprintln(w, "\nRuns the named scenarios, or every registered one, each in a fresh run of its own.")
+		fmt.Fprintln(w, "\nFlags:")
+		fs.PrintDefaults()
+	}
+	return c
+}
+
+// runAll runs several scenarios, each in isolation, and prints a combined
+// summary. It returns the first nonzero exit code among the runs, if any.
+//
+// With -parallel the narration of different scenarios interleaves; use -q
+// for the summaries alone, or -json, whose records carry the scenario name.
+func runAll(args []string, stdout, stderr io.Writer) int {
+	c := newRunAll(stderr)
+	fs, rf, out := c.fs, &c.runFlags, &c.output
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if out.tui {
+		fmt.Fprintln(stderr, "contextdemo: run-all does not support -tui")
+		return exitUsage
+	}
+	if err := out.open(stderr); err != nil {
+		fmt.Fprintf(stderr, "contextdemo: %v\n", err)
+		return exitError
+	}
+	defer out.close(stderr)
+
+	var ss []scenario.Scenario
+	for _, name := range fs.Args() {
+		s, ok := scenario.Lookup(name)
+		if !ok {
+			fmt.Fprintf(stderr, "contextdemo: %v: %q\n", scenario.ErrUnknownScenario, name)
+			return exitUsage
+		}
+		ss = append(ss, s)
+	}
+	if len(ss) == 0 {
+		ss = scenario.All()
+	}
+	if c.tag != "" {
+		ss = scenario.WithTag(ss, c.tag)
+	}
+	names := make([]string, len(ss))
+	for i, s := range ss {
+		names[i] = s.Name()
+	}
+
+	opts := append(rf.options(), out.options(stdout)...)
+	outcomes := contextdemo.RunAll(context.Background(), names, c.parallel, opts...)
+
+	color := out.color(stdout)
+	codes := make([]int, len(outcomes))
+	code := exitOK
+	for i, o := range outcomes {
+		switch {
+		case o.Result == nil:
+			codes[i] = exitError
+		case errors.Is(o.Err, contextdemo.ErrTimeout):
+			codes[i] = exitTimedOut
+		case errors.As(o.Err, new(*leakcheck.Error)), errors.Is(o.Err, contextdemo.ErrLeakBudget):
+			codes[i] = exitLeaked
+		default:
+			codes[i] = exitCode(ss[i], o.Result)
+		}
+		if o.Err != nil {
+			fmt.Fprintf(stderr, "contextdemo: %s: %v\n", o.Scenario, o.Err)
+		}
+		if o.Result != nil && out.reporting != nil {
+			out.reporting.Add(o.Result, o.Err)
+		}
+		switch {
+		case o.Result == nil:
+		case out.json:
+			writeLeaks(stdout, o.Result, o.Err)
+		default:
+			printResult(stdout, o.Result, color)
+		}
+		if code == exitOK {
+			code = codes[i]
+		}
+	}
+	if !out.json {
+		printSummary(stdout, outcomes, codes)
+	}
+	return code
+}
+
+// printSummary writes one line per run of run-all to w.
+func printSummary(w io.Writer, outcomes []contextdemo.Outcome, codes []int) {
+	fmt.Fprintf(w, "\nSummary of %d scenario(s):\n", len(outcomes))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  SCENARIO\tWORKERS\tEXITED\tLEAKED\tSTATUS")
+	for i, o := range outcomes {
+		if o.Result == nil {
+			fmt.Fprintf(tw, "  %s\t-\t-\t-\t%s\n", o.Scenario, exitStatus[codes[i]])
+			continue
+		}
+		r := o.Result
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%d\t%s\n", o.Scenario, len(r.Workers), r.Exited(), r.Leaked(), exitStatus[codes[i]])
+	}
+	tw.Flush()
+}
@@ -0,0 +1,131 @@
+// Package adaptive sets timeouts from the latencies actually observed
+// rather than from a constant picked in advance.
+//
+// A fixed timeout is either too tight for a slow day or too loose to catch
+// a hung call on a fast one. A Timeout keeps a rolling window of recent
+// latencies and offers a high percentile of them times a safety factor,
+// p99×2 by default, so the deadline follows the workload:
+//
+//	at := &adaptive.Timeout{Initial: 100 * time.Millisecond}
+//	...
+//	ctx, cancel, _ := at.WithTimeout(ctx)
+//	start := clk.Now()
+//	err := call(ctx)
+//	cancel()
+//	at.Observe(clock.Since(clk, start))
+//
+// An operation that times out should be observed too, with the time it was
+// given: it took at least that long, and leaving it out would keep the
+// timeout where it failed.
+package adaptive
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// Defaults used for the zero fields of a Timeout.
+const (
+	DefaultPercentile = 0.99
+	DefaultFactor     = 2.0
+	DefaultWindow     = 100
+	DefaultWarmup     = 10
+	DefaultInitial    = time.Second
+)
+
+// Timeout is a timeout derived from a rolling window of observed
+// latencies. Its zero value is usable; it is safe for concurrent use.
+type Timeout struct {
+	// Percentile is the quantile of the window the timeout is based on, in
+	// (0, 1].
+	Percentile float64
+	// Factor is the safety margin the percentile is multiplied by.
+	Factor float64
+	// Window is how many of the most recent latencies are kept.
+	Window int
+	// Warmup is how many latencies must be observed before they are
+	// trusted; until then the timeout is Initial.
+	Warmup int
+	// Initial is the timeout used during warmup.
+	Initial time.Duration
+	// Min and Max, if positive, bound the timeout.
+	Min, Max time.Duration
+
+	mu      sync.Mutex
+	samples []time.Duration // ring of the last Window latencies
+	next    int             // where the next sample goes once full
+}
+
+// Observe records the latency of one operation.
+func (t *Timeout) Observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	window := t.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	if len(t.samples) < window {
+		t.samples = append(t.samples, d)
+		return
+	}
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % len(t.samples)
+}
+
+// Latency returns the configured percentile of the observed latencies, and
+// false during warmup.
+func (t *Timeout) Latency() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	warmup := t.Warmup
+	if warmup <= 0 {
+		warmup = DefaultWarmup
+	}
+	if len(t.samples) == 0 || len(t.samples) < warmup {
+		return 0, false
+	}
+	q := t.Percentile
+	if q <= 0 || q > 1 {
+		q = DefaultPercentile
+	}
+	sorted := slices.Clone(t.samples)
+	slices.Sort(sorted)
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)], true
+}
+
+// Current returns the timeout to give the next operation: the percentile
+// times the factor, within Min and Max, or Initial during warmup.
+func (t *Timeout) Current() time.Duration {
+	d := t.Initial
+	if d <= 0 {
+		d = DefaultInitial
+	}
+	if p, ok := t.Latency(); ok {
+		factor := t.Factor
+		if factor <= 0 {
+			factor = DefaultFactor
+		}
+		d = time.Duration(float64(p) * factor)
+	}
+	if t.Min > 0 && d < t.Min {
+		d = t.Min
+	}
+	if t.Max > 0 && d > t.Max {
+		d = t.Max
+	}
+	return d
+}
+
+// WithTimeout returns a copy of ctx that times out after Current, on the
+// clock carried by ctx, and the timeout it was given.
+func (t *Timeout) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc, time.Duration) {
+	d := t.Current()
+	ctx, cancel := clock.WithTimeout(ctx, d)
+	return ctx, cancel, d
+}
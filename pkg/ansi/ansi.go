@@ -0,0 +1,48 @@
+// Package ansi holds the handful of terminal escape sequences the human
+// output formats use, and how to decide whether to use them at all.
+package ansi
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// SGR sequences for the colours in use.
+const (
+	Reset   = "\x1b[0m"
+	Bold    = "\x1b[1m"
+	Dim     = "\x1b[2m"
+	Red     = "\x1b[31m"
+	Green   = "\x1b[32m"
+	Yellow  = "\x1b[33m"
+	Magenta = "\x1b[35m"
+	Cyan    = "\x1b[36m"
+)
+
+// Paint returns s wrapped in color and Reset, line by line so that each
+// line stands on its own in a pager. Empty lines and an empty color are
+// left alone.
+func Paint(color, s string) string {
+	if color == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = color + l + Reset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Enabled reports whether w is a terminal that should get colour. It honours
+// the NO_COLOR convention and TERM=dumb.
+func Enabled(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
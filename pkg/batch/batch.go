@@ -0,0 +1,163 @@
+// Package batch collects items into batches that are flushed when full,
+// when they have waited long enough, or when the context's deadline draws
+// near.
+//
+// A Batcher never drops what it has collected. When its context is
+// cancelled it flushes the partial batch one last time, under a context
+// that keeps the original's values but not its cancellation, bounded by
+// FinalTimeout, and only then returns:
+//
+//	b := &batch.Batcher[string]{Size: 50, Flush: write}
+//	err := b.Run(ctx, items)
+package batch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// Reason is why a batch was flushed.
+type Reason int
+
+// The reasons for a flush.
+const (
+	// Full means the batch reached Size.
+	Full Reason = iota
+	// Waited means the batch's first item had waited MaxWait.
+	Waited
+	// Deadline means the context's deadline was within Margin.
+	Deadline
+	// Cancelled means the context was done and the partial batch was
+	// flushed rather than dropped.
+	Cancelled
+	// Drained means the input was closed.
+	Drained
+)
+
+func (r Reason) String() string {
+	switch r {
+	case Full:
+		return "full"
+	case Waited:
+		return "waited"
+	case Deadline:
+		return "deadline"
+	case Cancelled:
+		return "cancelled"
+	case Drained:
+		return "drained"
+	default:
+		return fmt.Sprintf("Reason(%d)", int(r))
+	}
+}
+
+// Defaults used for the zero fields of a Batcher.
+const (
+	DefaultSize         = 100
+	DefaultMargin       = 50 * time.Millisecond
+	DefaultFinalTimeout = time.Second
+)
+
+// Batcher collects items into batches and hands each to Flush.
+type Batcher[T any] struct {
+	// Size is the most items a batch holds; a batch that reaches it is
+	// flushed at once.
+	Size int
+	// MaxWait, if positive, is the longest the first item of a batch
+	// waits before the batch is flushed, full or not.
+	MaxWait time.Duration
+	// Margin is how long before the context's deadline the partial batch
+	// is flushed, so the flush itself still has time to finish.
+	Margin time.Duration
+	// FinalTimeout bounds the flush made after the context is done.
+	FinalTimeout time.Duration
+	// Flush writes a batch. It is never called with an empty one, and
+	// never two at once.
+	Flush func(ctx context.Context, items []T, why Reason) error
+}
+
+// Run collects items from in until in is closed or ctx is done, flushing
+// as it goes, and flushes what is left before it returns. It returns the
+// first error from Flush, which stops it, or nil.
+func (b *Batcher[T]) Run(ctx context.Context, in <-chan T) error {
+	size := b.Size
+	if size <= 0 {
+		size = DefaultSize
+	}
+	clk := clock.From(ctx)
+
+	// near fires Margin before the deadline, once.
+	var near <-chan time.Time
+	if deadline, ok := ctx.Deadline(); ok {
+		margin := b.Margin
+		if margin <= 0 {
+			margin = DefaultMargin
+		}
+		t := clk.NewTimer(deadline.Add(-margin).Sub(clk.Now()))
+		defer t.Stop()
+		near = t.C()
+	}
+
+	var items []T
+	var wait clock.Timer // running while a batch waits, if MaxWait is set
+	stopWait := func() {
+		if wait != nil {
+			wait.Stop()
+			wait = nil
+		}
+	}
+	defer stopWait()
+	waited := func() <-chan time.Time {
+		if wait == nil {
+			return nil
+		}
+		return wait.C()
+	}
+	flush := func(ctx context.Context, why Reason) error {
+		stopWait()
+		if len(items) == 0 {
+			return nil
+		}
+		err := b.Flush(ctx, items, why)
+		items = nil
+		return err
+	}
+
+	for {
+		select {
+		case item, ok := <-in:
+			if !ok {
+				return flush(ctx, Drained)
+			}
+			items = append(items, item)
+			if len(items) == 1 && b.MaxWait > 0 {
+				wait = clk.NewTimer(b.MaxWait)
+			}
+			if len(items) >= size {
+				if err := flush(ctx, Full); err != nil {
+					return err
+				}
+			}
+		case <-waited():
+			if err := flush(ctx, Waited); err != nil {
+				return err
+			}
+		case <-near:
+			near = nil
+			if err := flush(ctx, Deadline); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			timeout := b.FinalTimeout
+			if timeout <= 0 {
+				timeout = DefaultFinalTimeout
+			}
+			final, cancel := clock.WithTimeout(context.WithoutCancel(ctx), timeout)
+			defer cancel()
+			return flush(final, Cancelled)
+		}
+	}
+}
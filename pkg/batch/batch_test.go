@@ -0,0 +1,137 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// flushed is one call of Flush.
+type flushed struct {
+	items []int
+	why   Reason
+}
+
+// start runs b over a new input channel in a new goroutine, recording each
+// flush, and returns the input, the flushes and Run's result.
+func start(ctx context.Context, b *Batcher[int]) (chan<- int, <-chan flushed, <-chan error) {
+	in := make(chan int)
+	flushes := make(chan flushed, 16)
+	if b.Flush == nil {
+		b.Flush = func(_ context.Context, items []int, why Reason) error {
+			flushes <- flushed{append([]int(nil), items...), why}
+			return nil
+		}
+	}
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, in) }()
+	return in, flushes, done
+}
+
+func fakeContext() (context.Context, *clock.Fake) {
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	return clock.With(context.Background(), f), f
+}
+
+func TestFlushWhenFullAndWhenDrained(t *testing.T) {
+	ctx, _ := fakeContext()
+	in, flushes, done := start(ctx, &Batcher[int]{Size: 2})
+	for i := 1; i <= 5; i++ {
+		in <- i
+	}
+	close(in)
+	if err := <-done; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	want := []flushed{{[]int{1, 2}, Full}, {[]int{3, 4}, Full}, {[]int{5}, Drained}}
+	for i, w := range want {
+		if got := <-flushes; !reflect.DeepEqual(got, w) {
+			t.Errorf("flush %d = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestFlushAfterMaxWait(t *testing.T) {
+	ctx, f := fakeContext()
+	in, flushes, done := start(ctx, &Batcher[int]{Size: 10, MaxWait: time.Second})
+	in <- 1
+	in <- 2
+	f.BlockUntil(1)
+	f.Advance(999 * time.Millisecond)
+	select {
+	case got := <-flushes:
+		t.Fatalf("flushed %v before MaxWait", got)
+	default:
+	}
+	f.Advance(time.Millisecond)
+	if got := <-flushes; !reflect.DeepEqual(got, flushed{[]int{1, 2}, Waited}) {
+		t.Errorf("flush = %v, want [1 2] waited", got)
+	}
+	close(in)
+	if err := <-done; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if f.Pending() != 0 {
+		t.Errorf("Run left %d timer(s) pending", f.Pending())
+	}
+}
+
+func TestFlushBeforeDeadline(t *testing.T) {
+	ctx, f := fakeContext()
+	ctx, cancel := clock.WithTimeout(ctx, time.Second)
+	defer cancel()
+	in, flushes, done := start(ctx, &Batcher[int]{Size: 10, Margin: 100 * time.Millisecond})
+	in <- 1
+	f.Advance(900 * time.Millisecond)
+	if got := <-flushes; !reflect.DeepEqual(got, flushed{[]int{1}, Deadline}) {
+		t.Errorf("flush = %v, want [1] deadline", got)
+	}
+	close(in)
+	if err := <-done; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+}
+
+func TestFlushOnCancelOutlivesTheContext(t *testing.T) {
+	ctx, _ := fakeContext()
+	ctx, cancel := context.WithCancelCause(ctx)
+	var flushCtxErr error
+	var final flushed
+	b := &Batcher[int]{Size: 10, FinalTimeout: time.Second}
+	b.Flush = func(fctx context.Context, items []int, why Reason) error {
+		flushCtxErr = fctx.Err()
+		final = flushed{append([]int(nil), items...), why}
+		if _, ok := fctx.Deadline(); !ok {
+			t.Error("the final flush has no deadline; FinalTimeout should bound it")
+		}
+		return nil
+	}
+	in, _, done := start(ctx, b)
+	in <- 1
+	in <- 2
+	cancel(errors.New("shutting down"))
+	if err := <-done; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if !reflect.DeepEqual(final, flushed{[]int{1, 2}, Cancelled}) {
+		t.Errorf("final flush = %v, want [1 2] cancelled", final)
+	}
+	if flushCtxErr != nil {
+		t.Errorf("the final flush was handed a context that was already done: %v", flushCtxErr)
+	}
+}
+
+func TestFlushErrorStopsRun(t *testing.T) {
+	errDisk := errors.New("disk full")
+	ctx, _ := fakeContext()
+	b := &Batcher[int]{Size: 1, Flush: func(context.Context, []int, Reason) error { return errDisk }}
+	in, _, done := start(ctx, b)
+	in <- 1
+	if err := <-done; !errors.Is(err, errDisk) {
+		t.Errorf("Run = %v, want %v", err, errDisk)
+	}
+}
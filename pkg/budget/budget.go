@@ -0,0 +1,120 @@
+// Package budget splits the time left before a deadline across stages of
+// work that run one after another.
+//
+// A request with a second to live that must fetch, process and write
+// cannot give each stage the whole second. A Plan gives each a share of
+// it instead, 60%, 30% and 10% say, as consecutive slices of the time
+// between now and the deadline. A stage that finishes early leaves its
+// slack to those after it; one that overruns, by not checking its context
+// often enough, eats into theirs. A stage left with less than its Min when
+// it is entered fails fast rather than start work it cannot finish:
+//
+//	plan, err := budget.Split(ctx,
+//		budget.Stage{Name: "fetch", Share: 0.6},
+//		budget.Stage{Name: "process", Share: 0.3},
+//		budget.Stage{Name: "write", Share: 0.1, Min: 50 * time.Millisecond})
+//	...
+//	ctx, cancel, err := plan.Enter(ctx, "fetch")
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/clock"
+)
+
+// ErrNoDeadline is returned by Split for a context without a deadline.
+var ErrNoDeadline = errors.New("budget: context has no deadline to split")
+
+// Stage is one step of work in a Plan.
+type Stage struct {
+	// Name identifies the stage in Enter and in causes.
+	Name string
+	// Share is the stage's part of the total, relative to the others' Share.
+	Share float64
+	// Min is the least time worth starting the stage with.
+	Min time.Duration
+}
+
+// Plan is a deadline split across stages; see Split.
+type Plan struct {
+	start  time.Time
+	total  time.Duration
+	stages []Stage
+	ends   []time.Time // when each stage's slice ends
+}
+
+// Split plans stages across the time from now, on the clock carried by ctx,
+// until ctx's deadline.
+func Split(ctx context.Context, stages ...Stage) (*Plan, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, ErrNoDeadline
+	}
+	var sum float64
+	for _, s := range stages {
+		if s.Share < 0 {
+			return nil, fmt.Errorf("budget: stage %s has negative share %v", s.Name, s.Share)
+		}
+		sum += s.Share
+	}
+	if sum == 0 {
+		return nil, errors.New("budget: stages have no share to split")
+	}
+	p := &Plan{start: clock.From(ctx).Now(), stages: stages}
+	p.total = deadline.Sub(p.start)
+	var cum float64
+	for _, s := range stages {
+		cum += s.Share
+		p.ends = append(p.ends, p.start.Add(time.Duration(float64(p.total)*cum/sum)))
+	}
+	p.ends[len(p.ends)-1] = deadline // no rounding short of the real thing
+	return p, nil
+}
+
+// Budget returns the planned length of stage name's slice, and whether the
+// plan has such a stage.
+func (p *Plan) Budget(name string) (time.Duration, bool) {
+	for i, s := range p.stages {
+		if s.Name == name {
+			from := p.start
+			if i > 0 {
+				from = p.ends[i-1]
+			}
+			return p.ends[i].Sub(from), true
+		}
+	}
+	return 0, false
+}
+
+// Enter derives the context for stage name from ctx, with a deadline at the
+// end of the stage's slice and a *cause.DeadlineBudgetExhausted as the
+// cause should it pass. If less than the stage's Min is left by then, Enter
+// returns that cause as its error instead, without deriving a context.
+func (p *Plan) Enter(ctx context.Context, name string) (context.Context, context.CancelFunc, error) {
+	i := -1
+	for j, s := range p.stages {
+		if s.Name == name {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil, nil, fmt.Errorf("budget: no stage %q", name)
+	}
+	s := p.stages[i]
+	planned, _ := p.Budget(name)
+	now := clock.From(ctx).Now()
+	left := p.ends[i].Sub(now)
+	exhausted := &cause.DeadlineBudgetExhausted{Who: "stage " + name, At: now, Budget: planned}
+	if left <= 0 || left < s.Min {
+		exhausted.Why = fmt.Sprintf("only %v left when it started, needs %v", max(left, 0), s.Min)
+		return nil, nil, exhausted
+	}
+	exhausted.Why, exhausted.At = "its slice of the deadline passed", p.ends[i]
+	ctx, cancel := clock.WithDeadlineCause(ctx, p.ends[i], exhausted)
+	return ctx, cancel, nil
+}
@@ -0,0 +1,123 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// plan splits a second on a fake clock across fetch, process and write,
+// 60/30/10, with write needing at least 60ms.
+func plan(t *testing.T) (context.Context, *clock.Fake, *Plan) {
+	t.Helper()
+	f := clock.NewFake(epoch)
+	ctx, cancel := clock.WithTimeout(clock.With(context.Background(), f), time.Second)
+	t.Cleanup(cancel)
+	p, err := Split(ctx,
+		Stage{Name: "fetch", Share: 0.6},
+		Stage{Name: "process", Share: 0.3},
+		Stage{Name: "write", Share: 0.1, Min: 60 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	return ctx, f, p
+}
+
+func TestSplitShares(t *testing.T) {
+	_, _, p := plan(t)
+	for _, tt := range []struct {
+		name string
+		want time.Duration
+	}{{"fetch", 600 * time.Millisecond}, {"process", 300 * time.Millisecond}, {"write", 100 * time.Millisecond}} {
+		if got, ok := p.Budget(tt.name); !ok || got != tt.want {
+			t.Errorf("Budget(%q) = %v, %v, want %v", tt.name, got, ok, tt.want)
+		}
+	}
+	if _, ok := p.Budget("deploy"); ok {
+		t.Error("Budget of a stage not in the plan reported ok")
+	}
+}
+
+func TestStageExpiresWithBudgetCause(t *testing.T) {
+	ctx, f, p := plan(t)
+	sctx, cancel, err := p.Enter(ctx, "fetch")
+	if err != nil {
+		t.Fatalf("Enter(fetch): %v", err)
+	}
+	defer cancel()
+	if d, _ := sctx.Deadline(); !d.Equal(epoch.Add(600 * time.Millisecond)) {
+		t.Errorf("fetch deadline at %v, want +600ms", d.Sub(epoch))
+	}
+
+	f.Advance(600 * time.Millisecond)
+	<-sctx.Done()
+	if !errors.Is(sctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("fetch Err() = %v, want %v", sctx.Err(), context.DeadlineExceeded)
+	}
+	var exhausted *cause.DeadlineBudgetExhausted
+	if !errors.As(context.Cause(sctx), &exhausted) || exhausted.Who != "stage fetch" || exhausted.Budget != 600*time.Millisecond {
+		t.Errorf("fetch cause = %v, want stage fetch's 600ms budget exhausted", context.Cause(sctx))
+	}
+	if ctx.Err() != nil {
+		t.Error("the request's context ended with the stage's slice")
+	}
+}
+
+func TestEarlyStageLeavesSlackToTheNext(t *testing.T) {
+	ctx, f, p := plan(t)
+	_, cancel, _ := p.Enter(ctx, "fetch")
+	f.Advance(200 * time.Millisecond)
+	cancel() // fetch is done early
+
+	sctx, cancel, err := p.Enter(ctx, "process")
+	if err != nil {
+		t.Fatalf("Enter(process): %v", err)
+	}
+	defer cancel()
+	if d, _ := sctx.Deadline(); !d.Equal(epoch.Add(900 * time.Millisecond)) {
+		t.Errorf("process deadline at %v, want +900ms: its own slice's end, with fetch's slack", d.Sub(epoch))
+	}
+}
+
+func TestExhaustedStageFailsFast(t *testing.T) {
+	ctx, f, p := plan(t)
+	f.Advance(950 * time.Millisecond) // fetch overran into process's slice and write's
+
+	if _, _, err := p.Enter(ctx, "process"); err == nil {
+		t.Error("Enter(process) after its slice ended succeeded")
+	}
+	_, _, err := p.Enter(ctx, "write")
+	var exhausted *cause.DeadlineBudgetExhausted
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("Enter(write) with 50ms left of a 60ms minimum = %v, want a budget exhausted", err)
+	}
+	if exhausted.Budget != 100*time.Millisecond {
+		t.Errorf("write's budget reported as %v, want its planned 100ms", exhausted.Budget)
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	f := clock.NewFake(epoch)
+	ctx := clock.With(context.Background(), f)
+	if _, err := Split(ctx, Stage{Name: "a", Share: 1}); !errors.Is(err, ErrNoDeadline) {
+		t.Errorf("Split without a deadline = %v, want %v", err, ErrNoDeadline)
+	}
+	ctx, cancel := clock.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := Split(ctx, Stage{Name: "a", Share: -1}, Stage{Name: "b", Share: 2}); err == nil {
+		t.Error("Split with a negative share succeeded")
+	}
+	if _, err := Split(ctx, Stage{Name: "a"}); err == nil {
+		t.Error("Split with no share at all succeeded")
+	}
+	p, _ := Split(ctx, Stage{Name: "a", Share: 1})
+	if _, _, err := p.Enter(ctx, "b"); err == nil {
+		t.Error("Enter of a stage not in the plan succeeded")
+	}
+}
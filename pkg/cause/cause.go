@@ -0,0 +1,149 @@
+// Package cause defines typed cancellation causes, for workers that do more
+// with context.Cause than print it.
+//
+// A cause made with errors.New only says what happened. The types here also
+// say who cancelled, why and when, and a worker can tell them apart with
+// errors.As and react to each in its own way:
+//
+//	var up *cause.UpstreamFailure
+//	if errors.As(context.Cause(ctx), &up) {
+//		return up // nothing to drain into; stop at once
+//	}
+//
+// Explain turns any cause, typed or not, into the chain of errors it wraps.
+package cause
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// stamp formats the when of a cause.
+func stamp(at time.Time) string {
+	return at.Format("15:04:05.000")
+}
+
+// ShutdownRequested is the cause for an orderly shutdown, such as on a
+// signal or a deploy. Workers should finish what they have accepted.
+type ShutdownRequested struct {
+	Who string
+	Why string
+	At  time.Time
+}
+
+func (e *ShutdownRequested) Error() string {
+	return fmt.Sprintf("shutdown requested by %s at %s: %s", e.Who, stamp(e.At), e.Why)
+}
+
+// UpstreamFailure is the cause when something the work depends on has
+// failed, so that carrying on is pointless. Err, if set, is that failure.
+type UpstreamFailure struct {
+	Who string
+	Why string
+	At  time.Time
+	Err error
+}
+
+func (e *UpstreamFailure) Error() string {
+	msg := fmt.Sprintf("upstream %s failed at %s: %s", e.Who, stamp(e.At), e.Why)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *UpstreamFailure) Unwrap() error { return e.Err }
+
+// DeadlineBudgetExhausted is the cause when the time allowed for a piece of
+// work, Budget, has run out. It suits context.WithDeadlineCause.
+type DeadlineBudgetExhausted struct {
+	Who    string
+	Why    string
+	At     time.Time
+	Budget time.Duration
+}
+
+func (e *DeadlineBudgetExhausted) Error() string {
+	return fmt.Sprintf("%s exhausted its %v budget at %s: %s", e.Who, e.Budget, stamp(e.At), e.Why)
+}
+
+// OperatorAbort is the cause when a person stopped the work by hand.
+type OperatorAbort struct {
+	Who string
+	Why string
+	At  time.Time
+}
+
+func (e *OperatorAbort) Error() string {
+	return fmt.Sprintf("aborted by %s at %s: %s", e.Who, stamp(e.At), e.Why)
+}
+
+// Preempted is the cause when the work is to be stopped now and resumed
+// later, or elsewhere, as when a scheduler takes back its slot. Workers
+// should save how far they got before they return.
+type Preempted struct {
+	Who string
+	Why string
+	At  time.Time
+}
+
+func (e *Preempted) Error() string {
+	return fmt.Sprintf("preempted by %s at %s: %s", e.Who, stamp(e.At), e.Why)
+}
+
+// Chain returns err followed by every error it wraps, depth first: through
+// Unwrap() error, and through each branch of Unwrap() []error as made by
+// errors.Join or fmt.Errorf with several %w verbs.
+func Chain(err error) []error {
+	if err == nil {
+		return nil
+	}
+	out := []error{err}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		out = append(out, Chain(u.Unwrap())...)
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			out = append(out, Chain(e)...)
+		}
+	}
+	return out
+}
+
+// Explain describes why ctx was cancelled by walking the chain of its
+// cause, one link per wrapped error:
+//
+//	cancelled because Voldemort is here → because parent deadline passed → because context deadline exceeded
+//
+// Each link shows only what that error adds to the ones it wraps, and
+// errors that add nothing, such as those made by errors.Join, are skipped.
+func Explain(ctx context.Context) string {
+	c := context.Cause(ctx)
+	if c == nil {
+		return "not cancelled"
+	}
+	var links []string
+	for _, e := range Chain(c) {
+		if s := own(e); s != "" {
+			links = append(links, "because "+s)
+		}
+	}
+	return "cancelled " + strings.Join(links, " → ")
+}
+
+// own returns the part of err's message that is not the message of the
+// error it wraps.
+func own(err error) string {
+	msg := err.Error()
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := u.Unwrap(); inner != nil {
+			msg = strings.TrimRight(strings.TrimSuffix(msg, inner.Error()), ": ")
+		}
+	case interface{ Unwrap() []error }:
+		return ""
+	}
+	return msg
+}
@@ -0,0 +1,117 @@
+// Package cleanup runs cleanup callbacks as soon as a context is cancelled,
+// rather than when the function that registered them returns.
+//
+// Deferred cleanup waits for the worker to notice the cancellation and
+// return, which a worker in the middle of something slow may not do for a
+// while. A Registry is built on context.AfterFunc, so its callbacks run the
+// moment the context is done, whatever the worker is doing:
+//
+//	reg := cleanup.OnCancel(ctx)
+//	defer reg.Close() // runs them on a normal return, too
+//	f := openLedger()
+//	reg.Add("close ledger", f.Close)
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// PanicError is the error a callback that panicked is reported with.
+type PanicError struct {
+	Name  string
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("cleanup %q panicked: %v", e.Name, e.Value)
+}
+
+type callback struct {
+	name string
+	fn   func() error
+}
+
+// Registry holds cleanup callbacks to run once, when its context is done or
+// it is closed, whichever comes first.
+type Registry struct {
+	stop func() bool
+	done chan struct{} // closed once the callbacks have run
+
+	mu  sync.Mutex
+	fns []callback
+	ran bool
+	err error
+}
+
+// OnCancel returns a Registry whose callbacks run when ctx is done.
+func OnCancel(ctx context.Context) *Registry {
+	r := &Registry{done: make(chan struct{})}
+	r.stop = context.AfterFunc(ctx, r.run)
+	return r
+}
+
+// Add registers fn to run under name. Callbacks run in the reverse order
+// of registration, like deferred calls, and each runs even if an earlier
+// one failed or panicked. If the callbacks have already run, Add runs fn
+// straight away.
+func (r *Registry) Add(name string, fn func() error) {
+	r.mu.Lock()
+	if !r.ran {
+		r.fns = append(r.fns, callback{name, fn})
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+	if err := call(callback{name, fn}); err != nil {
+		r.mu.Lock()
+		r.err = errors.Join(r.err, err)
+		r.mu.Unlock()
+	}
+}
+
+// Close runs the callbacks now if the context has not run them already,
+// and otherwise waits for them to finish. It returns their errors, joined.
+func (r *Registry) Close() error {
+	if r.stop() {
+		r.run()
+	}
+	<-r.done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Registry) run() {
+	r.mu.Lock()
+	fns := r.fns
+	r.fns, r.ran = nil, true
+	r.mu.Unlock()
+
+	var errs []error
+	for _, cb := range slices.Backward(fns) {
+		if err := call(cb); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	r.mu.Lock()
+	r.err = errors.Join(append([]error{r.err}, errs...)...)
+	r.mu.Unlock()
+	close(r.done)
+}
+
+// call runs cb, turning a panic into a *PanicError.
+func call(cb callback) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Name: cb.name, Value: v}
+		}
+	}()
+	if err := cb.fn(); err != nil {
+		return fmt.Errorf("cleanup %q: %w", cb.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Package clock abstracts the passage of time so that demonstrations can run
+// against the wall clock or against simulated time.
+//
+// A Clock travels in the context, like worker hooks, so every worker,
+// supervisor and scenario beneath the point where it was installed keeps
+// time the same way.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and schedules wake-ups.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	NewTimer(d time.Duration) Timer
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer delivers a single tick after a delay, like time.Timer. Use one
+// instead of After where the wait may be cut short, so it can be stopped.
+type Timer interface {
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. It reports whether it did so,
+	// like time.Timer.Stop.
+	Stop() bool
+}
+
+// Real is the wall clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+func (realClock) NewTimer(d time.Duration) Timer   { return realTimer{time.NewTimer(d)} }
+
+func (realClock) afterFunc(d time.Duration, f func(now time.Time)) Timer {
+	return realTimer{time.AfterFunc(d, func() { f(time.Now()) })}
+}
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+// Since returns the time elapsed on c since t.
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+type clockKey struct{}
+
+// With returns a copy of ctx carrying c.
+func With(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// From returns the clock carried by ctx, or Real if there is none.
+func From(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return c
+	}
+	return Real
+}
@@ -0,0 +1,208 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeAdvanceFiresTimersInOrder(t *testing.T) {
+	f := NewFake(epoch)
+	late := f.NewTimer(2 * time.Second)
+	early := f.NewTimer(time.Second)
+	ticker := f.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	f.Advance(500 * time.Millisecond)
+	select {
+	case <-early.C():
+		t.Fatal("timer for 1s fired after 500ms")
+	default:
+	}
+
+	f.Advance(1500 * time.Millisecond)
+	if got := <-early.C(); !got.Equal(epoch.Add(time.Second)) {
+		t.Errorf("early timer fired at %v, want %v", got, epoch.Add(time.Second))
+	}
+	if got := <-late.C(); !got.Equal(epoch.Add(2 * time.Second)) {
+		t.Errorf("late timer fired at %v, want %v", got, epoch.Add(2*time.Second))
+	}
+	// The ticker's first tick was not received, so its second was dropped.
+	if got := <-ticker.C(); !got.Equal(epoch.Add(time.Second)) {
+		t.Errorf("ticker ticked at %v, want %v", got, epoch.Add(time.Second))
+	}
+	if got := f.Now(); !got.Equal(epoch.Add(2 * time.Second)) {
+		t.Errorf("Now() = %v, want %v", got, epoch.Add(2*time.Second))
+	}
+	if n := f.Pending(); n != 1 {
+		t.Errorf("Pending() = %d, want 1 (the ticker)", n)
+	}
+}
+
+func TestFakeTimerStop(t *testing.T) {
+	f := NewFake(epoch)
+	timer := f.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Error("Stop() of a pending timer = false, want true")
+	}
+	if timer.Stop() {
+		t.Error("second Stop() = true, want false")
+	}
+	f.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(epoch)
+	woke := make(chan time.Time)
+	for range 2 {
+		go func() {
+			f.Sleep(time.Second)
+			woke <- f.Now()
+		}()
+	}
+
+	f.BlockUntil(2)
+	f.Advance(time.Second)
+	for range 2 {
+		if got := <-woke; !got.Equal(epoch.Add(time.Second)) {
+			t.Errorf("sleeper woke at %v, want %v", got, epoch.Add(time.Second))
+		}
+	}
+}
+
+func TestSimulatedRunsAhead(t *testing.T) {
+	f := NewSimulated(epoch)
+	defer f.Stop()
+	start := time.Now()
+	f.Sleep(time.Hour)
+	if real := time.Since(start); real > time.Second {
+		t.Errorf("an hour on the simulated clock took %v of real time", real)
+	}
+	if got := f.Now(); got.Before(epoch.Add(time.Hour)) {
+		t.Errorf("Now() = %v after sleeping an hour from %v", got, epoch)
+	}
+}
+
+func TestWithTimeoutExpires(t *testing.T) {
+	f := NewFake(epoch)
+	ctx, cancel := WithTimeout(With(context.Background(), f), time.Second)
+	defer cancel()
+
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(epoch.Add(time.Second)) {
+		t.Errorf("Deadline() = %v, %v, want %v, true", deadline, ok, epoch.Add(time.Second))
+	}
+	f.Advance(999 * time.Millisecond)
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("Err() = %v before the deadline", err)
+	}
+	f.Advance(time.Millisecond)
+	<-ctx.Done()
+	if err := ctx.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Err() = %v, want context.DeadlineExceeded", err)
+	}
+	if err := context.Cause(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Cause() = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestWithTimeoutCauseExpires(t *testing.T) {
+	f := NewFake(epoch)
+	errSlow := errors.New("too slow")
+	ctx, cancel := WithTimeoutCause(With(context.Background(), f), time.Second, errSlow)
+	defer cancel()
+
+	f.Advance(time.Second)
+	<-ctx.Done()
+	if err := ctx.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Err() = %v, want context.DeadlineExceeded", err)
+	}
+	if err := context.Cause(ctx); !errors.Is(err, errSlow) {
+		t.Errorf("Cause() = %v, want %v", err, errSlow)
+	}
+}
+
+func TestWithTimeoutCancelledEarlyLeavesNothingPending(t *testing.T) {
+	f := NewFake(epoch)
+	ctx := With(context.Background(), f)
+	for range 3 {
+		tctx, cancel := WithTimeout(ctx, time.Hour)
+		cancel()
+		<-tctx.Done()
+		if err := tctx.Err(); !errors.Is(err, context.Canceled) {
+			t.Errorf("Err() = %v, want context.Canceled", err)
+		}
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after cancelling every timeout, want 0", n)
+	}
+}
+
+func TestWithTimeoutParentCancelled(t *testing.T) {
+	f := NewFake(epoch)
+	errParent := errors.New("parent gave up")
+	parent, cancelParent := context.WithCancelCause(With(context.Background(), f))
+	ctx, cancel := WithTimeout(parent, time.Hour)
+	defer cancel()
+
+	cancelParent(errParent)
+	<-ctx.Done()
+	if err := context.Cause(ctx); !errors.Is(err, errParent) {
+		t.Errorf("Cause() = %v, want %v", err, errParent)
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after the parent was cancelled, want 0", n)
+	}
+}
+
+func TestWithTimeoutKeepsSoonerParentDeadline(t *testing.T) {
+	f := NewFake(epoch)
+	parent, cancelParent := WithTimeout(With(context.Background(), f), time.Second)
+	defer cancelParent()
+	ctx, cancel := WithTimeout(parent, time.Hour)
+	defer cancel()
+
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(epoch.Add(time.Second)) {
+		t.Errorf("Deadline() = %v, want the parent's %v", deadline, epoch.Add(time.Second))
+	}
+	f.Advance(time.Second)
+	<-ctx.Done()
+	if err := ctx.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Err() = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestTrackerForgetsStoppedAndFiredTimers(t *testing.T) {
+	f := NewFake(epoch)
+	tr := Track(f)
+	ticker := tr.NewTicker(time.Second)
+	stopped := tr.NewTimer(time.Second)
+	fired := tr.NewTimer(time.Second)
+	pending := tr.NewTimer(time.Hour)
+	defer pending.Stop()
+
+	if n := len(tr.Outstanding()); n != 4 {
+		t.Fatalf("Outstanding() has %d entries, want 4", n)
+	}
+	stopped.Stop()
+	f.Advance(time.Second)
+	if got := <-fired.C(); !got.Equal(epoch.Add(time.Second)) {
+		t.Errorf("tracked timer fired at %v, want %v", got, epoch.Add(time.Second))
+	}
+	ticker.Stop()
+
+	out := tr.Outstanding()
+	if len(out) != 1 || out[0].Kind != "timer" || out[0].Interval != time.Hour {
+		t.Fatalf("Outstanding() = %v, want only the hour timer", out)
+	}
+	if got, want := out[0].Func, "github.com/context-demo/pkg/clock.TestTrackerForgetsStoppedAndFiredTimers"; got != want {
+		t.Errorf("timer attributed to %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithTimeout is context.WithTimeout measured on the clock carried by ctx.
+func WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return WithDeadlineCause(ctx, From(ctx).Now().Add(d), nil)
+}
+
+// WithTimeoutCause is context.WithTimeoutCause measured on the clock carried
+// by ctx.
+func WithTimeoutCause(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	return WithDeadlineCause(ctx, From(ctx).Now().Add(d), cause)
+}
+
+// WithDeadline is context.WithDeadline measured on the clock carried by ctx.
+func WithDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	return WithDeadlineCause(ctx, deadline, nil)
+}
+
+// WithDeadlineCause is context.WithDeadlineCause measured on the clock
+// carried by ctx. With the Real clock it is exactly the standard library
+// function. With any other clock the deadline fires when that clock reaches
+// it, and the returned context still reports context.DeadlineExceeded from
+// Err and cause (or DeadlineExceeded) from context.Cause.
+func WithDeadlineCause(ctx context.Context, deadline time.Time, cause error) (context.Context, context.CancelFunc) {
+	c := From(ctx)
+	if c == Real {
+		return context.WithDeadlineCause(ctx, deadline, cause)
+	}
+	if cur, ok := ctx.Deadline(); ok && cur.Before(deadline) {
+		// The parent's deadline is sooner; it will fire first.
+		return context.WithCancel(ctx)
+	}
+	if cause == nil {
+		cause = context.DeadlineExceeded
+	}
+
+	inner, cancel := context.WithCancelCause(ctx)
+	dc := &deadlineCtx{Context: inner, deadline: deadline, done: make(chan struct{})}
+	// A timer rather than After, so that a context cancelled early leaves
+	// nothing pending on the clock.
+	t := c.NewTimer(deadline.Sub(c.Now()))
+	go func() {
+		select {
+		case <-t.C():
+			dc.expired.Store(true)
+			cancel(cause)
+		case <-inner.Done():
+			t.Stop()
+		}
+		dc.finish()
+	}()
+	// Hand out a standard child rather than dc itself: the standard library
+	// registers it through dc.AfterFunc, so it and everything derived from
+	// it are cancelled before dc's Done channel closes.
+	out, outCancel := context.WithCancel(dc)
+	return out, func() {
+		t.Stop()
+		cancel(context.Canceled)
+		outCancel()
+	}
+}
+
+// deadlineCtx is a context whose deadline is kept by a non-wall clock.
+//
+// It has its own done channel rather than inner's, so the standard library
+// treats it as a foreign context when deriving children and copies Err and
+// Cause from it instead of from inner. It implements AfterFunc, which the
+// standard library uses to cancel children synchronously instead of from a
+// goroutine watching Done.
+type deadlineCtx struct {
+	context.Context // inner: carries values and the cancellation cause
+	deadline        time.Time
+	done            chan struct{}
+	expired         atomic.Bool
+
+	mu       sync.Mutex
+	finished bool
+	funcs    map[*func()]struct{}
+}
+
+func (c *deadlineCtx) Deadline() (time.Time, bool) { return c.deadline, true }
+func (c *deadlineCtx) Done() <-chan struct{}       { return c.done }
+
+// Err is valid as soon as inner is cancelled, so the AfterFunc callbacks
+// run by finish see it before Done is closed.
+func (c *deadlineCtx) Err() error {
+	if c.expired.Load() {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
+
+// AfterFunc arranges to call f once c is done, and returns a function that
+// stops the call if it has not happened yet.
+func (c *deadlineCtx) AfterFunc(f func()) (stop func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.finished {
+		go f()
+		return func() bool { return false }
+	}
+	if c.funcs == nil {
+		c.funcs = make(map[*func()]struct{})
+	}
+	key := &f
+	c.funcs[key] = struct{}{}
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, ok := c.funcs[key]
+		delete(c.funcs, key)
+		return ok
+	}
+}
+
+// finish runs the AfterFunc callbacks and then closes Done.
+func (c *deadlineCtx) finish() {
+	c.mu.Lock()
+	c.finished = true
+	funcs := c.funcs
+	c.funcs = nil
+	c.mu.Unlock()
+	for f := range funcs {
+		(*f)()
+	}
+	close(c.done)
+}
@@ -0,0 +1,261 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// simulatedYield is the real time a simulated clock gives goroutines to
+// react to one wake-up before it jumps to the next. It jumps only once the
+// clock has gone unused for simulatedQuiet yields in a row, so that a
+// goroutine the scheduler is slow to run is not left behind, but no later
+// than simulatedPatience yields after the last jump.
+const (
+	simulatedYield    = time.Millisecond
+	simulatedQuiet    = 2
+	simulatedPatience = 10
+)
+
+// Fake is a Clock whose time only moves when told to.
+//
+// A Fake made by NewFake moves when Advance is called, which suits tests
+// that step time explicitly. A Fake made by NewSimulated moves on its own:
+// it repeatedly jumps straight to the next pending wake-up, so a scenario
+// that would take seconds of wall-clock time plays out in milliseconds, in
+// the same order every time.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // broadcast when a timer is added or removed
+	now     time.Time
+	seq     int
+	timers  []*fakeTimer
+	stop    chan struct{}
+	once    sync.Once
+	used    uint64 // counts calls that read or schedule on the clock
+}
+
+type fakeTimer struct {
+	f      *Fake
+	at     time.Time
+	period time.Duration // zero for one-shot timers
+	seq    int           // breaks ties between timers due at the same time
+	ch     chan time.Time
+	fn     func(now time.Time) // called instead of sending on ch, if set
+}
+
+// fakeOneShot is a fakeTimer made by NewTimer, whose Stop reports whether
+// it was still pending.
+type fakeOneShot struct{ *fakeTimer }
+
+// NewFake returns a Fake set to start that moves only when Advance is called.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// NewSimulated returns a Fake set to start that advances itself from one
+// wake-up to the next, once the goroutines it woke stop using it, until
+// Stop is called.
+func NewSimulated(start time.Time) *Fake {
+	f := &Fake{now: start, stop: make(chan struct{})}
+	f.changed = sync.NewCond(&f.mu)
+	go f.drive()
+	return f
+}
+
+// Stop halts a simulated clock. Timers that are still pending never fire.
+// It is a no-op for a Fake made by NewFake.
+func (f *Fake) Stop() {
+	if f.stop != nil {
+		f.once.Do(func() { close(f.stop) })
+	}
+}
+
+func (f *Fake) drive() {
+	yield := time.NewTicker(simulatedYield)
+	defer yield.Stop()
+	seen, quiet, waited := f.usage(), 0, 0
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-yield.C:
+		}
+		waited++
+		if n := f.usage(); n != seen {
+			seen, quiet = n, 0
+		} else {
+			quiet++
+		}
+		if quiet < simulatedQuiet && waited < simulatedPatience {
+			continue
+		}
+		f.fireNext(time.Time{})
+		seen, quiet, waited = f.usage(), 0, 0
+	}
+}
+
+// usage returns f.used.
+func (f *Fake) usage() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.used
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.used++
+	return f.now
+}
+
+// After returns a channel that receives the fake time once d has elapsed.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.add(d, 0, nil).ch
+}
+
+// Sleep blocks until d has elapsed on the fake clock. With a Fake made by
+// NewFake, some other goroutine must call Advance.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// NewTicker returns a Ticker driven by the fake clock. It panics if d <= 0.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return f.add(d, d, nil)
+}
+
+// NewTimer returns a Timer driven by the fake clock.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return fakeOneShot{f.add(d, 0, nil)}
+}
+
+func (f *Fake) afterFunc(d time.Duration, fn func(now time.Time)) Timer {
+	return fakeOneShot{f.add(d, 0, fn)}
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	t.f.remove(t)
+}
+
+func (t fakeOneShot) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	return t.f.remove(t.fakeTimer)
+}
+
+// fire delivers now, dropping it if the previous tick was not received,
+// like time.Ticker does. f.mu must be held.
+func (t *fakeTimer) fire(now time.Time) {
+	if t.fn != nil {
+		t.fn(now)
+		return
+	}
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due
+// on the way, in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	target := f.now.Add(d)
+	f.mu.Unlock()
+
+	for f.fireNext(target) {
+	}
+
+	f.mu.Lock()
+	if target.After(f.now) {
+		f.now = target
+	}
+	f.mu.Unlock()
+}
+
+// BlockUntil blocks until at least n timers and tickers are waiting to
+// fire, so a test can be sure the goroutines it started are asleep before
+// it calls Advance.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.changed.Wait()
+	}
+}
+
+// Pending returns the number of timers and tickers waiting to fire.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+func (f *Fake) add(d, period time.Duration, fn func(now time.Time)) *fakeTimer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.used++
+	f.seq++
+	t := &fakeTimer{f: f, at: f.now.Add(d), period: period, seq: f.seq, ch: make(chan time.Time, 1), fn: fn}
+	if d <= 0 && period == 0 {
+		t.fire(f.now)
+		return t
+	}
+	f.timers = append(f.timers, t)
+	f.changed.Broadcast()
+	return t
+}
+
+// fireNext fires the earliest pending timer, moving the clock to its due
+// time. If limit is non-zero, timers due after limit are left alone.
+// It reports whether a timer fired.
+func (f *Fake) fireNext(limit time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var next *fakeTimer
+	for _, t := range f.timers {
+		if next == nil || t.at.Before(next.at) || (t.at.Equal(next.at) && t.seq < next.seq) {
+			next = t
+		}
+	}
+	if next == nil || (!limit.IsZero() && next.at.After(limit)) {
+		return false
+	}
+
+	if next.at.After(f.now) {
+		f.now = next.at
+	}
+	next.fire(f.now)
+	if next.period > 0 {
+		f.seq++
+		next.at = next.at.Add(next.period)
+		next.seq = f.seq
+	} else {
+		f.remove(next)
+	}
+	return true
+}
+
+// remove drops t from the pending timers and reports whether it was there.
+func (f *Fake) remove(t *fakeTimer) bool {
+	f.used++
+	for i, ft := range f.timers {
+		if ft == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			f.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package clock
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Unstopped describes a ticker, or a timer that has not fired, made by a
+// Tracker and not stopped. A ticker that is never stopped keeps firing, and
+// keeps whatever its channel's reader holds alive, for the life of the
+// process.
+type Unstopped struct {
+	// ID orders tickers and timers by creation and tells them apart.
+	ID uint64
+	// Kind is "ticker" or "timer".
+	Kind string
+	// Interval is the ticker's period or the timer's duration.
+	Interval time.Duration
+	// Func, File and Line locate the call to NewTicker or NewTimer.
+	Func string
+	File string
+	Line int
+}
+
+// String summarises u on one line.
+func (u Unstopped) String() string {
+	what := "ticker every"
+	if u.Kind == "timer" {
+		what = "timer for"
+	}
+	return fmt.Sprintf("%s %v created by %s at %s:%d", what, u.Interval, u.Func, filepath.Base(u.File), u.Line)
+}
+
+// Tracker is a Clock that keeps a record of the tickers and timers made
+// through it, so that a run can report those it left unstopped. Clocks
+// keep no such record themselves, since finding where each ticker or timer
+// was made costs a runtime.Caller: wrap a clock with Track only where the
+// report is wanted.
+type Tracker struct {
+	Clock
+
+	mu     sync.Mutex
+	lastID uint64
+	live   map[uint64]*tracked
+}
+
+// tracked is a ticker, or a timer that has not fired, that has not been
+// stopped.
+type tracked struct {
+	Unstopped
+	due time.Time // when a timer that cannot report firing falls due; zero otherwise
+}
+
+// afterFuncer is implemented by the clocks of this package, whose timers
+// can call a function when they fire instead of sending on a channel.
+type afterFuncer interface {
+	afterFunc(d time.Duration, f func(now time.Time)) Timer
+}
+
+// Track returns a Tracker that keeps time by c.
+func Track(c Clock) *Tracker {
+	return &Tracker{Clock: c, live: make(map[uint64]*tracked)}
+}
+
+// NewTicker is c.NewTicker, recording the ticker until it is stopped.
+func (c *Tracker) NewTicker(d time.Duration) Ticker {
+	t := c.Clock.NewTicker(d)
+	return trackedTicker{t, c.add("ticker", d, time.Time{})}
+}
+
+// NewTimer is c.NewTimer, recording the timer until it is stopped or
+// fires.
+func (c *Tracker) NewTimer(d time.Duration) Timer {
+	af, ok := c.Clock.(afterFuncer)
+	if !ok {
+		return trackedTimer{c.Clock.NewTimer(d), nil, c.add("timer", d, c.Now().Add(d))}
+	}
+	forget := c.add("timer", d, time.Time{})
+	ch := make(chan time.Time, 1) // fires once, so the send never blocks
+	t := af.afterFunc(d, func(now time.Time) {
+		forget()
+		ch <- now
+	})
+	return trackedTimer{t, ch, forget}
+}
+
+// add records a ticker or timer made by the caller of NewTicker or
+// NewTimer, which must call it directly, and returns the function that
+// forgets it.
+func (c *Tracker) add(kind string, d time.Duration, due time.Time) (forget func()) {
+	t := &tracked{Unstopped: Unstopped{Kind: kind, Interval: d}, due: due}
+	if pc, file, line, ok := runtime.Caller(2); ok {
+		t.File, t.Line = file, line
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			t.Func = fn.Name()
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastID++
+	t.ID = c.lastID
+	c.live[t.ID] = t
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.live, t.ID)
+	}
+}
+
+// Outstanding returns every ticker made through c that has not been
+// stopped and every timer that has been neither stopped nor fired, oldest
+// first. Channels from After cannot be stopped and are not tracked.
+func (c *Tracker) Outstanding() []Unstopped {
+	c.mu.Lock()
+	live := make([]tracked, 0, len(c.live))
+	for _, t := range c.live {
+		live = append(live, *t)
+	}
+	c.mu.Unlock()
+
+	now := c.Now() // outside c.mu, which a timer of c.Clock may take as it fires
+	var out []Unstopped
+	for _, t := range live {
+		if t.due.IsZero() || now.Before(t.due) {
+			out = append(out, t.Unstopped)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+type trackedTicker struct {
+	Ticker
+	forget func()
+}
+
+func (t trackedTicker) Stop() {
+	t.Ticker.Stop()
+	t.forget()
+}
+
+type trackedTimer struct {
+	Timer
+	ch     chan time.Time // nil if Timer's own channel is used
+	forget func()
+}
+
+func (t trackedTimer) C() <-chan time.Time {
+	if t.ch != nil {
+		return t.ch
+	}
+	return t.Timer.C()
+}
+
+func (t trackedTimer) Stop() bool {
+	t.forget()
+	return t.Timer.Stop()
+}
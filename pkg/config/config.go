@@ -0,0 +1,271 @@
+// Package config reads scripted demonstrations from a file, so a sequence of
+// scenarios with their own parameters, worker counts and cancellation
+// schedules can be replayed without recompiling.
+//
+// Files are written in a small subset of TOML: comments, key = value pairs
+// with string, integer or boolean values, an array of [[run]] tables, and a
+// [run.params] table for the run declared last. Keys before the first table
+// are defaults for every run. Durations are strings in time.ParseDuration
+// syntax:
+//
+//	# Shared by every run.
+//	cancel-after = "1s"
+//
+//	[[run]]
+//	scenario = "cancel-cause"
+//	workers = 3
+//
+//	[[run]]
+//	scenario = "cancel-one"
+//	cause = "the Ministry has fallen"
+//
+//	[run.params]
+//	victim = 2
+package config
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/escalate"
+	"github.com/context-demo/pkg/rng"
+)
+
+// Run is one scenario run. Zero fields are left to the defaults of the file
+// and then to those of the scenario package.
+type Run struct {
+	Scenario     string
+	Workers      int
+	CancelAfter  time.Duration
+	RunFor       time.Duration
+	GracePeriod  time.Duration
+	TickInterval time.Duration
+	Jitter       rng.Jitter
+	WarnAt       []float64
+	Timeout      time.Duration
+	Cause        string
+	RequestID    string
+	Seed         uint64
+	Params       map[string]string
+}
+
+// File is a parsed configuration file.
+type File struct {
+	// Defaults holds the keys given before the first table.
+	Defaults Run
+	// Runs holds the [[run]] tables, in order.
+	Runs []Run
+}
+
+// Load reads and parses the file at path.
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads a configuration from r.
+func Parse(r io.Reader) (*File, error) {
+	var (
+		f      File
+		run    = &f.Defaults
+		params bool // inside [run.params]
+	)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(sc.Text()))
+		var err error
+		switch {
+		case line == "":
+		case line == "[[run]]":
+			f.Runs = append(f.Runs, Run{})
+			run, params = &f.Runs[len(f.Runs)-1], false
+		case line == "[run.params]":
+			if len(f.Runs) == 0 {
+				err = errors.New("[run.params] before any [[run]]")
+			}
+			params = true
+		case strings.HasPrefix(line, "["):
+			err = fmt.Errorf("unsupported table %s", line)
+		default:
+			key, val, ok := strings.Cut(line, "=")
+			if !ok {
+				err = fmt.Errorf("expected key = value, got %q", line)
+				break
+			}
+			key = strings.TrimSpace(key)
+			val, err = scalar(strings.TrimSpace(val))
+			if err != nil {
+				break
+			}
+			if params {
+				if run.Params == nil {
+					run.Params = make(map[string]string)
+				}
+				run.Params[key] = val
+			} else {
+				err = run.set(key, val)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for i, r := range f.Runs {
+		if r.Scenario == "" && f.Defaults.Scenario == "" {
+			return nil, fmt.Errorf("run %d: no scenario", i+1)
+		}
+	}
+	return &f, nil
+}
+
+// stripComment removes a # comment that is not inside a string.
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// scalar returns the text of a string, integer or boolean value.
+func scalar(v string) (string, error) {
+	if strings.HasPrefix(v, `"`) {
+		s, err := strconv.Unquote(v)
+		if err != nil {
+			return "", fmt.Errorf("bad string %s", v)
+		}
+		return s, nil
+	}
+	if v == "true" || v == "false" {
+		return v, nil
+	}
+	if _, err := strconv.Atoi(v); err != nil {
+		return "", fmt.Errorf("bad value %s", v)
+	}
+	return v, nil
+}
+
+// set assigns the value of key.
+func (r *Run) set(key, val string) error {
+	var err error
+	duration := func(d *time.Duration) { *d, err = time.ParseDuration(val) }
+	switch key {
+	case "scenario":
+		r.Scenario = val
+	case "workers":
+		r.Workers, err = strconv.Atoi(val)
+	case "cancel-after":
+		duration(&r.CancelAfter)
+	case "run-for":
+		duration(&r.RunFor)
+	case "grace-period":
+		duration(&r.GracePeriod)
+	case "tick-interval":
+		duration(&r.TickInterval)
+	case "jitter":
+		r.Jitter.Kind, err = rng.ParseJitterKind(val)
+	case "jitter-spread":
+		r.Jitter.Spread, err = strconv.ParseFloat(val, 64)
+	case "warn-at":
+		r.WarnAt, err = escalate.ParseThresholds(val)
+	case "timeout":
+		duration(&r.Timeout)
+	case "cause":
+		r.Cause = val
+	case "request-id":
+		r.RequestID = val
+	case "seed":
+		r.Seed, err = strconv.ParseUint(val, 10, 64)
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// Resolve returns the runs with the file's defaults filled in.
+func (f *File) Resolve() []Run {
+	d := f.Defaults
+	runs := make([]Run, len(f.Runs))
+	for i, r := range f.Runs {
+		r.Scenario = cmp.Or(r.Scenario, d.Scenario)
+		r.Workers = cmp.Or(r.Workers, d.Workers)
+		r.CancelAfter = cmp.Or(r.CancelAfter, d.CancelAfter)
+		r.RunFor = cmp.Or(r.RunFor, d.RunFor)
+		r.GracePeriod = cmp.Or(r.GracePeriod, d.GracePeriod)
+		r.TickInterval = cmp.Or(r.TickInterval, d.TickInterval)
+		r.Jitter.Kind = cmp.Or(r.Jitter.Kind, d.Jitter.Kind)
+		r.Jitter.Spread = cmp.Or(r.Jitter.Spread, d.Jitter.Spread)
+		if r.WarnAt == nil {
+			r.WarnAt = d.WarnAt
+		}
+		r.Timeout = cmp.Or(r.Timeout, d.Timeout)
+		r.Cause = cmp.Or(r.Cause, d.Cause)
+		r.RequestID = cmp.Or(r.RequestID, d.RequestID)
+		r.Seed = cmp.Or(r.Seed, d.Seed)
+		runs[i] = r
+	}
+	return runs
+}
+
+// Options returns the contextdemo options for r.
+func (r Run) Options() []contextdemo.Option {
+	opts := []contextdemo.Option{
+		contextdemo.WithScenario(r.Scenario),
+		contextdemo.WithWorkers(r.Workers),
+		contextdemo.WithCancelAfter(r.CancelAfter),
+		contextdemo.WithRunFor(r.RunFor),
+		contextdemo.WithGracePeriod(r.GracePeriod),
+		contextdemo.WithTickInterval(r.TickInterval),
+		contextdemo.WithJitter(r.Jitter),
+		contextdemo.WithTimeout(r.Timeout),
+	}
+	if len(r.WarnAt) > 0 {
+		opts = append(opts, contextdemo.WithEscalation(r.WarnAt...))
+	}
+	if r.Cause != "" {
+		opts = append(opts, contextdemo.WithCause(errors.New(r.Cause)))
+	}
+	if r.RequestID != "" {
+		opts = append(opts, contextdemo.WithRequestID(r.RequestID))
+	}
+	if r.Seed != 0 {
+		opts = append(opts, contextdemo.WithSeed(r.Seed))
+	}
+	for k, v := range r.Params {
+		opts = append(opts, contextdemo.WithParam(k, v))
+	}
+	return opts
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Run // resolved
+	}{
+		{
+			name: "comments",
+			in: `# a whole-line comment
+[[run]]   # a table with a comment
+scenario = "timeout" # trailing comment
+workers = 2#no space needed
+`,
+			want: []Run{{Scenario: "timeout", Workers: 2}},
+		},
+		{
+			name: "hash inside strings",
+			in: `[[run]]
+scenario = "cancel-cause"
+cause = "room #7 is on fire" # but this is a comment
+request-id = "say \"#1\"" # escaped quotes stay in the string
+`,
+			want: []Run{{Scenario: "cancel-cause", Cause: "room #7 is on fire", RequestID: `say "#1"`}},
+		},
+		{
+			name: "defaults inherited",
+			in: `scenario = "cancel-cause"
+cancel-after = "1s"
+workers = 3
+cause = "default cause"
+
+[[run]]
+
+[[run]]
+scenario = "timeout"
+workers = 1
+cancel-after = "250ms"
+`,
+			want: []Run{
+				{Scenario: "cancel-cause", Workers: 3, CancelAfter: time.Second, Cause: "default cause"},
+				{Scenario: "timeout", Workers: 1, CancelAfter: 250 * time.Millisecond, Cause: "default cause"},
+			},
+		},
+		{
+			name: "run params",
+			in: `[[run]]
+scenario = "cancel-one"
+
+[run.params]
+victim = 2
+label = "the # stays"
+quiet = true
+
+[[run]]
+scenario = "timeout"
+`,
+			want: []Run{
+				{Scenario: "cancel-one", Params: map[string]string{"victim": "2", "label": "the # stays", "quiet": "true"}},
+				{Scenario: "timeout"},
+			},
+		},
+		{
+			name: "durations and numbers",
+			in: `[[run]]
+scenario = "supervisor"
+run-for = "2s"
+grace-period = "1.5s"
+tick-interval = "100ms"
+timeout = "1m"
+seed = 42
+jitter-spread = 0
+`,
+			want: []Run{{Scenario: "supervisor", RunFor: 2 * time.Second, GracePeriod: 1500 * time.Millisecond,
+				TickInterval: 100 * time.Millisecond, Timeout: time.Minute, Seed: 42}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := f.Resolve(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no equals", "[[run]]\nscenario \"timeout\"\n", `line 2: expected key = value, got "scenario \"timeout\""`},
+		{"unknown key", "# defaults\nworker = 3\n", `line 2: unknown key "worker"`},
+		{"bad duration", "[[run]]\nscenario = \"timeout\"\ncancel-after = \"soon\"\n", `line 3: cancel-after: time: invalid duration "soon"`},
+		{"unquoted duration", "cancel-after = 1s\n", "line 1: bad value 1s"},
+		{"bad string", "cause = \"unterminated\n", `line 1: bad string "unterminated`},
+		{"bad integer", "workers = \"three\"\n", `line 1: workers: strconv.Atoi: parsing "three": invalid syntax`},
+		{"unsupported table", "[[run]]\nscenario = \"timeout\"\n[run.hooks]\n", "line 3: unsupported table [run.hooks]"},
+		{"params before run", "[run.params]\nvictim = 2\n", "line 1: [run.params] before any [[run]]"},
+		{"run without scenario", "workers = 2\n[[run]]\n", "run 1: no scenario"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.in))
+			if err == nil {
+				t.Fatalf("Parse succeeded, want error %q", tt.want)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("Parse error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
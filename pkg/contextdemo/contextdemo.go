@@ -0,0 +1,349 @@
+// Package contextdemo is the public entry point to the context demonstrations.
+//
+// Teaching code can embed a demonstration with a single call:
+//
+//	res, err := contextdemo.Run(ctx,
+//		contextdemo.WithCancelAfter(time.Second),
+//		contextdemo.WithCause(errors.New("the Ministry has fallen")),
+//		contextdemo.WithOutput(&buf),
+//	)
+package contextdemo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxaudit"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/escalate"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/leakcheck"
+	"github.com/context-demo/pkg/rng"
+	"github.com/context-demo/pkg/scenario"
+	_ "github.com/context-demo/pkg/scenario/builtin" // DefaultScenario lives here
+	"github.com/context-demo/pkg/worker"
+)
+
+// Result is the outcome of a demonstration run.
+type Result = scenario.Result
+
+// ErrTimeout is reported by Run when the run outlives WithTimeout.
+var ErrTimeout = errors.New("run timed out")
+
+// ErrLeakBudget is reported by Run when the run leaves more goroutines
+// behind than WithLeakBudget allows.
+var ErrLeakBudget = errors.New("leak budget exceeded")
+
+// DefaultScenario is the scenario Run executes unless WithScenario is given.
+const DefaultScenario = "cancel-cause"
+
+// Option configures Run.
+type Option func(*config)
+
+type config struct {
+	scenario    string
+	registry    *scenario.Registry
+	requestID   string
+	timeout     time.Duration
+	simulated   bool
+	hooks       *worker.Hooks
+	leakCheck   bool
+	timers      *clock.Tracker  // the run's clock, when leakCheck is set
+	contexts    *ctxaudit.Audit // carried by the run's context, when leakCheck is set
+	verifyLeaks bool
+	allowLeaks  []leakcheck.Filter
+	budgeted    bool
+	leakBudget  int
+	env         scenario.Env
+}
+
+// WithScenario selects the registered scenario to run.
+func WithScenario(name string) Option {
+	return func(c *config) { c.scenario = name }
+}
+
+// WithRegistry looks scenarios up in r instead of scenario.Default.
+func WithRegistry(r *scenario.Registry) Option {
+	return func(c *config) { c.registry = r }
+}
+
+// WithRunFor sets the total duration of the demonstration.
+func WithRunFor(d time.Duration) Option {
+	return func(c *config) { c.env.RunFor = d }
+}
+
+// WithCancelAfter sets how long workers run before they are cancelled.
+func WithCancelAfter(d time.Duration) Option {
+	return func(c *config) { c.env.CancelAfter = d }
+}
+
+// WithGracePeriod sets how long scenarios wait for cancelled workers to
+// signal completion, instead of whatever is left of the run duration.
+func WithGracePeriod(d time.Duration) Option {
+	return func(c *config) { c.env.GracePeriod = d }
+}
+
+// WithTickInterval sets how often workers do a unit of work.
+func WithTickInterval(d time.Duration) Option {
+	return func(c *config) { c.env.TickInterval = d }
+}
+
+// WithJitter varies the tick intervals of workers as j says, drawing from
+// the run's seeded source of randomness.
+func WithJitter(j rng.Jitter) Option {
+	return func(c *config) { c.env.Jitter = j }
+}
+
+// WithCause sets the error passed to cancel functions that accept a cause.
+func WithCause(cause error) Option {
+	return func(c *config) { c.env.Cause = cause }
+}
+
+// WithWorkers starts n instances of each worker type, named with a numeric
+// suffix such as hogwarts-3, each under its own derived context.
+func WithWorkers(n int) Option {
+	return func(c *config) { c.env.Workers = n }
+}
+
+// WithParam sets the scenario-specific parameter name to value. Values are
+// checked against the scenario's declared parameters when Run starts.
+func WithParam(name, value string) Option {
+	return func(c *config) {
+		if c.env.Params == nil {
+			c.env.Params = make(map[string]string)
+		}
+		c.env.Params[name] = value
+	}
+}
+
+// WithOutput sends the demonstration output to w instead of os.Stdout.
+func WithOutput(w io.Writer) Option {
+	return func(c *config) { c.env.Logger = worker.NewLogger(w) }
+}
+
+// WithLogger sends the demonstration output to l. Use worker.Discard to
+// silence it.
+func WithLogger(l worker.Logger) Option {
+	return func(c *config) { c.env.Logger = l }
+}
+
+// WithSink delivers every event of the run to s, alongside the narration.
+// It may be given more than once.
+func WithSink(s event.Sink) Option {
+	return func(c *config) { c.env.Sinks = append(c.env.Sinks, s) }
+}
+
+// WithMiddleware decorates the context of every worker the scenario
+// launches with mws, in order. It may be given more than once.
+func WithMiddleware(mws ...ctxmw.Middleware) Option {
+	return func(c *config) { c.env.Middleware = append(c.env.Middleware, mws...) }
+}
+
+// WithEscalation warns, through event.DeadlineApproaching, as each worker
+// uses up the given fractions of the time before its deadline, such as 0.5,
+// 0.8 and 0.95, or escalate.DefaultThresholds if none are given.
+func WithEscalation(thresholds ...float64) Option {
+	return WithMiddleware(escalate.Middleware(thresholds...))
+}
+
+// WithSlog sends the demonstration output to l as structured records. Each
+// record carries the scenario name, worker name and request ID found in the
+// context of the code that logged it.
+func WithSlog(l *slog.Logger) Option {
+	return func(c *config) { c.env.Logger = worker.NewSlogLogger(l) }
+}
+
+// WithRequestID stores id in the context handed to the scenario, so it is
+// attached to every slog record and visible to workers through
+// worker.RequestID.
+func WithRequestID(id string) Option {
+	return func(c *config) { c.requestID = id }
+}
+
+// WithHooks installs lifecycle hooks on every worker the scenario launches
+// with the scenario's context.
+func WithHooks(h *worker.Hooks) Option {
+	return func(c *config) { c.hooks = h }
+}
+
+// WithClock keeps time for the scenario and its workers with c.
+func WithClock(clk clock.Clock) Option {
+	return func(c *config) { c.env.Clock = clk }
+}
+
+// WithTimeout bounds the whole run to d, measured on the run's clock. The
+// scenario's context, and so every worker's, expires with
+// context.DeadlineExceeded; scenarios cut short their waits, and Run returns
+// the result along with an error wrapping ErrTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// WithSeed seeds every random choice of the run, so running again with the
+// same seed reproduces it. Without it each run picks a fresh seed and
+// reports it in Result.Seed.
+func WithSeed(seed uint64) Option {
+	return func(c *config) { c.env.Rand = rng.New(seed) }
+}
+
+// WithWatch publishes the number of goroutines in the process every d while
+// the scenario runs, as event.GoroutineSample events.
+func WithWatch(d time.Duration) Option {
+	return func(c *config) { c.env.Watch = d }
+}
+
+// WithHardKill calls kill if the run is still going d after its workers
+// should all have stopped, that is d past the cancellation delay plus the
+// grace period, measured on the wall clock. kill is meant not to return;
+// pass watchdog.Kill to dump the stuck workers and exit.
+func WithHardKill(d time.Duration, kill func()) Option {
+	return func(c *config) {
+		c.env.HardKill = d
+		c.env.Kill = kill
+	}
+}
+
+// WithLeakCheck measures the goroutines the run leaves behind by diffing
+// stack snapshots taken before and after it, and reports them in
+// Result.Goroutines, along with the tickers and timers it left unstopped in
+// Result.Timers and the contexts it derived through ctxtree or
+// scenario.Group and never cancelled in Result.Uncancelled. Runs that
+// overlap it are measured too; see package leakcheck.
+func WithLeakCheck() Option {
+	return func(c *config) { c.leakCheck = true }
+}
+
+// WithVerifyNoLeaks checks the run for leaked goroutines like
+// WithLeakCheck and fails it if any are left that allow does not accept:
+// Run then returns the result along with a *leakcheck.Error. Pass
+// IntentionalLeaks to allow the workers that leak on purpose.
+func WithVerifyNoLeaks(allow ...leakcheck.Filter) Option {
+	return func(c *config) {
+		c.leakCheck = true
+		c.verifyLeaks = true
+		c.allowLeaks = allow
+	}
+}
+
+// WithLeakBudget checks the run for leaked goroutines like WithLeakCheck
+// and fails it if it leaves more than n behind, counting those of workers
+// that leak on purpose: Run then returns the result along with an error
+// wrapping ErrLeakBudget.
+func WithLeakBudget(n int) Option {
+	return func(c *config) {
+		c.leakCheck = true
+		c.budgeted = true
+		c.leakBudget = n
+	}
+}
+
+// IntentionalLeaks allows the goroutines of the workers in this module that
+// leak by design, such as worker.LeakyCauldron.
+var IntentionalLeaks = []leakcheck.Filter{
+	leakcheck.IgnoreAnyFunction(funcName((*worker.LeakyCauldron).Run)),
+	leakcheck.IgnoreAnyFunction(funcName((*worker.StrandedOwl).Run)),
+	leakcheck.IgnoreAnyFunction(funcName((*worker.WaitingPortrait).Run)),
+	leakcheck.IgnoreAnyFunction(funcName((*worker.LockedVault).Run)),
+}
+
+// funcName returns the name fn has in stack traces.
+func funcName(fn any) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+// WithStep calls step each time the scenario reaches one of the key
+// moments of a run, and holds the scenario there until step returns.
+func WithStep(step func(p scenario.Phase)) Option {
+	return func(c *config) { c.env.Step = step }
+}
+
+// WithDeterministic runs the scenario in simulated time: every sleep, tick
+// and deadline is driven by a clock.NewSimulated clock that jumps from one
+// wake-up to the next, so the run finishes in milliseconds and unfolds in
+// the same order every time.
+func WithDeterministic() Option {
+	return func(c *config) { c.simulated = true }
+}
+
+// Run executes a context demonstration with ctx as its parent context and
+// reports how each of its workers ended.
+func Run(ctx context.Context, opts ...Option) (*Result, error) {
+	c := config{scenario: DefaultScenario}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if !c.leakCheck {
+		return run(ctx, &c)
+	}
+	before := leakcheck.Take()
+	res, err := run(ctx, &c)
+	if res == nil {
+		return res, err
+	}
+	res.LeakChecked = true
+	res.Goroutines = leakcheck.Find(before, leakcheck.DefaultSettle)
+	res.Timers = c.timers.Outstanding()
+	res.Uncancelled = c.contexts.Outstanding()
+	workers := worker.Goroutines()
+	res.LeakSites = leakcheck.Attribute(res.Goroutines, func(g leakcheck.Goroutine) string {
+		return workers[g.ID].Type
+	})
+	if n := len(res.Goroutines); c.budgeted && err == nil && n > c.leakBudget {
+		err = fmt.Errorf("%w: %d goroutine(s) left behind, %d allowed", ErrLeakBudget, n, c.leakBudget)
+	}
+	if c.verifyLeaks && err == nil {
+		if bad := leakcheck.Without(res.Goroutines, c.allowLeaks...); len(bad) > 0 {
+			err = &leakcheck.Error{Goroutines: bad}
+		}
+	}
+	return res, err
+}
+
+// run is Run once the options are applied. Everything it sets up is torn
+// down by the time it returns, so leak checks only see what the scenario
+// left behind.
+func run(ctx context.Context, c *config) (*Result, error) {
+	if c.requestID != "" {
+		ctx = worker.WithRequestID(ctx, c.requestID)
+	}
+	if c.simulated {
+		sim := clock.NewSimulated(time.Unix(0, 0))
+		defer sim.Stop()
+		c.env.Clock = sim
+	}
+	if c.leakCheck {
+		clk := c.env.Clock
+		if clk == nil {
+			clk = clock.Real
+		}
+		c.timers = clock.Track(clk)
+		c.env.Clock = c.timers
+		c.contexts = ctxaudit.New()
+		ctx = ctxaudit.With(ctx, c.contexts)
+	}
+	if c.hooks != nil {
+		ctx = worker.WithHooks(ctx, c.hooks)
+	}
+	if c.timeout > 0 {
+		clk := c.env.Clock
+		if clk == nil {
+			clk = clock.Real
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = clock.WithTimeout(clock.With(ctx, clk), c.timeout)
+		defer cancel()
+	}
+	runner := &scenario.Runner{Registry: c.registry, Env: &c.env}
+	res, err := runner.Run(ctx, c.scenario)
+	if err == nil && c.timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w after %v", ErrTimeout, c.timeout)
+	}
+	return res, err
+}
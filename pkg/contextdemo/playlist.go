@@ -0,0 +1,47 @@
+package contextdemo
+
+import (
+	"context"
+	"sync"
+)
+
+// Outcome is how one run of RunAll ended.
+type Outcome struct {
+	Scenario string
+	// Result is nil if the run could not take place. Err may be set
+	// alongside it, as when the run times out.
+	Result *Result
+	Err    error
+}
+
+// RunAll runs each named scenario with opts and reports how each run ended,
+// in the order given. Every scenario gets a run of its own, with fresh
+// contexts and its own result, so leaks are accounted to the scenario that
+// caused them. With parallel set the runs overlap; otherwise each starts
+// once the previous one has returned. A run that fails does not stop the
+// others.
+func RunAll(ctx context.Context, names []string, parallel bool, opts ...Option) []Outcome {
+	out := make([]Outcome, len(names))
+	runOne := func(i int) {
+		o := append(append([]Option(nil), opts...), WithScenario(names[i]))
+		res, err := Run(ctx, o...)
+		out[i] = Outcome{Scenario: names[i], Result: res, Err: err}
+	}
+
+	if !parallel {
+		for i := range names {
+			runOne(i)
+		}
+		return out
+	}
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runOne(i)
+		}()
+	}
+	wg.Wait()
+	return out
+}
@@ -0,0 +1,265 @@
+// Package control lets an operator cancel workers from another terminal
+// while a demonstration runs.
+//
+// A Server listens on a unix socket or a localhost TCP port and reads one
+// command per line:
+//
+//	list                    the workers still running
+//	cancel <worker> [cause] cancel one worker
+//	cancel all [cause]      cancel every worker, and any started later
+//
+// Each command gets one line back, starting "ok" or "error". Any nc will
+// do as a client:
+//
+//	echo 'cancel hogwarts rollback' | nc -U /tmp/contextdemo.sock
+//
+// Commands are not authenticated: anyone who can reach the socket can
+// cancel workers. Start therefore refuses TCP addresses other than
+// loopback ones.
+//
+// The cause a worker sees is a *cause.OperatorAbort, so workers that tell
+// causes apart treat it like any other abort by hand.
+package control
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/worker"
+)
+
+// DefaultWhy is the reason given for a cancel command that names none.
+const DefaultWhy = "cancelled over the control socket"
+
+// ErrNotLoopback is returned by Start for a TCP address whose host is not
+// localhost or a loopback IP.
+var ErrNotLoopback = errors.New("control: refusing to listen beyond loopback: commands are not authenticated")
+
+// handle is what the server needs to cancel one running worker.
+type handle struct {
+	cancel context.CancelCauseFunc
+	clk    clock.Clock
+}
+
+// Server is a running control listener.
+type Server struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	workers map[string]*handle   // by name, while running
+	release map[*handle]struct{} // for Close: started and not yet exited
+	all     string               // the reason given to cancel all, once it has been
+}
+
+// Start listens on addr and serves commands from a goroutine of its own
+// until Close. An addr containing a slash, such as /tmp/contextdemo.sock or
+// ./ctl.sock, is a unix socket; anything else, such as localhost:7070 or
+// localhost:0, a TCP address, which must be on loopback.
+func Start(addr string) (*Server, error) {
+	network := "tcp"
+	if strings.Contains(addr, "/") {
+		network = "unix"
+	} else if err := loopback(addr); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{ln: ln, workers: make(map[string]*handle), release: make(map[*handle]struct{})}
+	go s.serve()
+	return s, nil
+}
+
+// loopback returns ErrNotLoopback unless addr's host is localhost or a
+// loopback IP. An empty host, which listens on every interface, is not.
+func loopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrNotLoopback, addr)
+}
+
+// Addr returns the address the server listens on, with the port filled in
+// if Start was given port 0.
+func (s *Server) Addr() string { return s.ln.Addr().String() }
+
+// Close stops listening and cancels the contexts Middleware created, for
+// those that are still live. A unix socket file is removed.
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for h := range s.release {
+		h.cancel(nil)
+	}
+	clear(s.release)
+	clear(s.workers)
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+// session answers the commands read from conn until the client hangs up.
+func (s *Server) session(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		if err := s.Exec(conn, sc.Text()); err != nil {
+			fmt.Fprintf(conn, "error: %v\n", err)
+		}
+	}
+}
+
+// Exec runs one command line and writes its answer to w. A command that
+// fails writes nothing and returns the error.
+func (s *Server) Exec(w io.Writer, line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return errors.New("empty command")
+	}
+	switch fields[0] {
+	case "list":
+		names := s.running()
+		if len(names) == 0 {
+			fmt.Fprintln(w, "ok: no workers running")
+			return nil
+		}
+		fmt.Fprintf(w, "ok: %s\n", strings.Join(names, " "))
+		return nil
+	case "cancel":
+		if len(fields) < 2 {
+			return errors.New("usage: cancel <worker>|all [cause]")
+		}
+		why := strings.Join(fields[2:], " ")
+		if why == "" {
+			why = DefaultWhy
+		}
+		if fields[1] == "all" {
+			n := s.CancelAll(why)
+			fmt.Fprintf(w, "ok: cancelled %d worker(s)\n", n)
+			return nil
+		}
+		if err := s.Cancel(fields[1], why); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "ok: cancelled %s\n", fields[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q; want list or cancel", fields[0])
+	}
+}
+
+// running returns the names of the workers still running, sorted.
+func (s *Server) running() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.workers))
+	for name := range s.workers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Cancel cancels the running worker called name, giving why as the reason.
+func (s *Server) Cancel(name, why string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.workers[name]
+	if !ok {
+		return fmt.Errorf("no running worker %q", name)
+	}
+	h.cancel(abort(h.clk, why))
+	return nil
+}
+
+// CancelAll cancels every running worker, and every worker that starts
+// from now on, giving why as the reason. It returns how many workers were
+// running.
+func (s *Server) CancelAll(why string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.all = why
+	for _, h := range s.workers {
+		h.cancel(abort(h.clk, why))
+	}
+	return len(s.workers)
+}
+
+// abort returns the cause for a cancel command.
+func abort(clk clock.Clock, why string) error {
+	return &cause.OperatorAbort{Who: "operator", Why: why, At: clk.Now()}
+}
+
+type cancelKey struct{}
+
+// Middleware gives every worker a context the server can cancel on its
+// own. The worker is registered under its name when it starts, since only
+// then is the name in the context, and forgotten when it exits. Close
+// cancels the contexts of those that are still registered.
+func (s *Server) Middleware() ctxmw.Middleware {
+	hooks := &worker.Hooks{
+		OnStart: func(ctx context.Context) {
+			name, _ := worker.WorkerName(ctx)
+			h, ok := ctx.Value(cancelKey{}).(*handle)
+			if !ok {
+				return
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			h.clk = clock.From(ctx)
+			if s.all != "" {
+				h.cancel(abort(h.clk, s.all))
+			}
+			s.workers[name] = h
+			s.release[h] = struct{}{}
+		},
+		OnExit: func(ctx context.Context, _ worker.Result) {
+			name, _ := worker.WorkerName(ctx)
+			h, ok := ctx.Value(cancelKey{}).(*handle)
+			if !ok {
+				return
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.workers[name] == h {
+				delete(s.workers, name)
+			}
+			delete(s.release, h)
+		},
+	}
+	return func(ctx context.Context) context.Context {
+		ctx, cancel := context.WithCancelCause(ctx)
+		ctx = context.WithValue(ctx, cancelKey{}, &handle{cancel: cancel})
+		return worker.WithHooks(ctx, hooks)
+	}
+}
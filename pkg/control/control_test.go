@@ -0,0 +1,70 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/worker"
+)
+
+func TestStartRefusesBeyondLoopback(t *testing.T) {
+	for _, addr := range []string{":0", "0.0.0.0:0", "[::]:0", "192.0.2.1:0", "example.com:0"} {
+		if s, err := Start(addr); !errors.Is(err, ErrNotLoopback) {
+			if err == nil {
+				s.Close()
+			}
+			t.Errorf("Start(%q) = %v, want %v", addr, err, ErrNotLoopback)
+		}
+	}
+	for _, addr := range []string{"localhost:0", "127.0.0.1:0"} {
+		s, err := Start(addr)
+		if err != nil {
+			t.Errorf("Start(%q) = %v", addr, err)
+			continue
+		}
+		s.Close()
+	}
+}
+
+func TestCancelAndForget(t *testing.T) {
+	s, err := Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := s.Middleware()(context.Background())
+
+	for range 3 {
+		worker.Execute(ctx, "quick", worker.Func(func(context.Context) error { return nil }))
+	}
+	if n := len(s.release); n != 0 {
+		t.Errorf("%d context(s) held after their workers exited, want 0", n)
+	}
+
+	started, done := make(chan struct{}), make(chan worker.Result)
+	go func() {
+		done <- worker.Execute(ctx, "hogwarts", worker.Func(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return nil
+		}))
+	}()
+	<-started
+	var out strings.Builder
+	if err := s.Exec(&out, "list"); err != nil || out.String() != "ok: hogwarts\n" {
+		t.Errorf("list = %q, %v, want hogwarts running", out.String(), err)
+	}
+	if err := s.Exec(&out, "cancel hogwarts rollback"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	var abort *cause.OperatorAbort
+	if r := <-done; !errors.As(r.Cause, &abort) || abort.Why != "rollback" {
+		t.Errorf("hogwarts exited with cause %v, want an operator abort for rollback", r.Cause)
+	}
+	if names := s.running(); len(names) != 0 || len(s.release) != 0 {
+		t.Errorf("after the exit %v still running and %d context(s) held", names, len(s.release))
+	}
+}
@@ -0,0 +1,148 @@
+// Package ctxaudit finds derived contexts whose cancel function is never
+// called: the classic missing defer cancel().
+//
+// A context derived with WithCancel, WithTimeout or the like holds on to
+// its parent, and to a timer if it has a deadline, until it is cancelled.
+// If nobody calls its cancel function, that only happens when the parent
+// ends, which for a long-lived parent may be never. go vet catches the
+// omission within a function; Outstanding catches it at run time, across
+// functions, with the stack that derived each context.
+//
+// ctxtree and scenario.Group register the contexts they derive with Track.
+// Recording a context costs a runtime.Callers and a lock, so Track only
+// records it under a context carrying an Audit, installed with With:
+// elsewhere it hands back the cancel function it was given.
+package ctxaudit
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/context-demo/pkg/leakcheck"
+)
+
+// Uncancelled describes a derived context whose cancel function has not
+// been called.
+type Uncancelled struct {
+	// ID orders contexts by when they were derived and tells them apart.
+	ID uint64
+	// Label says what kind of context it is, such as "cancel-cause".
+	Label string
+	// Done reports that the context has ended all the same, because its
+	// parent was cancelled or its deadline passed.
+	Done bool
+	// Stack is where the context was derived, innermost call first.
+	Stack []leakcheck.Frame
+}
+
+// String summarises u on one line, naming the call that derived it.
+func (u Uncancelled) String() string {
+	state := "still live"
+	if u.Done {
+		state = "ended by its parent or deadline"
+	}
+	site := "an unknown caller"
+	if len(u.Stack) > 0 {
+		site = u.Stack[0].String()
+	}
+	return fmt.Sprintf("%s context derived by %s never cancelled (%s)", u.Label, site, state)
+}
+
+// entry is a tracked context whose cancel function has not been called.
+type entry struct {
+	Uncancelled
+	ctx context.Context
+}
+
+// Audit records the contexts derived under a context that carries it, for
+// as long as their cancel functions go uncalled.
+type Audit struct {
+	mu     sync.Mutex
+	lastID uint64
+	live   map[uint64]*entry
+}
+
+// New returns an empty Audit.
+func New() *Audit {
+	return &Audit{live: make(map[uint64]*entry)}
+}
+
+type auditKey struct{}
+
+// With returns a copy of ctx carrying a, so that Track records the contexts
+// derived from it in a.
+func With(ctx context.Context, a *Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, a)
+}
+
+// From returns the Audit carried by ctx, or nil if there is none.
+func From(ctx context.Context) *Audit {
+	a, _ := ctx.Value(auditKey{}).(*Audit)
+	return a
+}
+
+// Track records that ctx was derived with cancel, in the Audit ctx
+// carries, and returns a cancel function to use in its place, which forgets
+// ctx once called. The stack recorded starts skip frames above the caller
+// of Track, so a helper that derives contexts for others can point at its
+// own caller. If ctx carries no Audit, Track returns cancel as it is.
+func Track(ctx context.Context, label string, cancel func(cause error), skip int) func(cause error) {
+	a := From(ctx)
+	if a == nil {
+		return cancel
+	}
+	e := &entry{Uncancelled: Uncancelled{Label: label, Stack: callers(skip + 3)}, ctx: ctx}
+	a.mu.Lock()
+	a.lastID++
+	e.ID = a.lastID
+	a.live[e.ID] = e
+	a.mu.Unlock()
+
+	var once sync.Once
+	return func(cause error) {
+		once.Do(func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			delete(a.live, e.ID)
+		})
+		cancel(cause)
+	}
+}
+
+// Outstanding returns every context recorded in a whose cancel function
+// has not been called, in the order they were derived.
+func (a *Audit) Outstanding() []Uncancelled {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]Uncancelled, 0, len(a.live))
+	for _, e := range a.live {
+		u := e.Uncancelled
+		u.Done = e.ctx.Err() != nil
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// callers returns the stack of the calling goroutine, skipping skip frames
+// as runtime.Callers does, and stopping short of the runtime's own frames.
+func callers(skip int) []leakcheck.Frame {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip, pcs)])
+	var out []leakcheck.Frame
+	for {
+		f, more := frames.Next()
+		if strings.HasPrefix(f.Function, "runtime.") {
+			break
+		}
+		out = append(out, leakcheck.Frame{Func: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			break
+		}
+	}
+	return out
+}
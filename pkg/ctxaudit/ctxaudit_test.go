@@ -0,0 +1,47 @@
+package ctxaudit
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTrackWithoutAuditRecordsNothing(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	var called bool
+	tracked := Track(ctx, "cancel-cause", func(cause error) { called = true; cancel(cause) }, 0)
+	tracked(nil)
+	if !called {
+		t.Fatal("the cancel function Track returned did not call the one it was given")
+	}
+}
+
+func TestOutstandingListsUncancelledContexts(t *testing.T) {
+	a := New()
+	parent, stop := context.WithCancel(With(context.Background(), a))
+
+	first, cancelFirst := context.WithCancelCause(parent)
+	trackedFirst := Track(first, "first", cancelFirst, 0)
+	second, cancelSecond := context.WithCancelCause(parent)
+	Track(second, "second", cancelSecond, 0)
+
+	out := a.Outstanding()
+	if len(out) != 2 || out[0].Label != "first" || out[1].Label != "second" {
+		t.Fatalf("Outstanding() = %v, want first and second in order", out)
+	}
+	if site := out[0].Stack[0].Func; !strings.HasSuffix(site, "TestOutstandingListsUncancelledContexts") {
+		t.Errorf("first was derived by %s, want the test", site)
+	}
+
+	trackedFirst(nil)
+	trackedFirst(nil) // a second call must not disturb the others
+	stop()
+	out = a.Outstanding()
+	if len(out) != 1 || out[0].Label != "second" {
+		t.Fatalf("after cancelling first, Outstanding() = %v, want only second", out)
+	}
+	if !out[0].Done {
+		t.Error("second is not reported done after its parent was cancelled")
+	}
+}
@@ -0,0 +1,113 @@
+// Package ctxmw decorates contexts with cross-cutting concerns before
+// workers are launched.
+//
+// A Middleware takes a context and returns a derived one. Middleware
+// installed in a context with Install is applied by the scenario Group to
+// the context of every worker it launches, so request IDs, extra log sinks
+// and deadline budgets are attached the same way everywhere.
+package ctxmw
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Middleware derives a decorated context from ctx.
+type Middleware func(ctx context.Context) context.Context
+
+// Chain returns a Middleware that applies mws in order, so the first
+// middleware decorates the context first.
+func Chain(mws ...Middleware) Middleware {
+	return func(ctx context.Context) context.Context {
+		for _, mw := range mws {
+			ctx = mw(ctx)
+		}
+		return ctx
+	}
+}
+
+// RequestID attaches a fixed request ID; see worker.WithRequestID.
+func RequestID(id string) Middleware {
+	return func(ctx context.Context) context.Context {
+		return worker.WithRequestID(ctx, id)
+	}
+}
+
+// NewRequestID attaches a freshly generated request ID each time it is
+// applied, so every worker gets its own.
+func NewRequestID() Middleware {
+	return func(ctx context.Context) context.Context {
+		var b [6]byte
+		rand.Read(b[:])
+		return worker.WithRequestID(ctx, "req-"+hex.EncodeToString(b[:]))
+	}
+}
+
+// Value attaches a key-value pair with context.WithValue.
+func Value(key, val any) Middleware {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, key, val)
+	}
+}
+
+// Hooks attaches lifecycle hooks; see worker.WithHooks.
+func Hooks(h *worker.Hooks) Middleware {
+	return func(ctx context.Context) context.Context {
+		return worker.WithHooks(ctx, h)
+	}
+}
+
+// Sink delivers the events published under the decorated context to s as
+// well as to the bus already carried by ctx.
+func Sink(s event.Sink) Middleware {
+	return func(ctx context.Context) context.Context {
+		parent := event.BusFrom(ctx)
+		return event.WithBus(ctx, event.NewBus(event.SinkFunc(parent.Publish), s))
+	}
+}
+
+// Logger narrates the events published under the decorated context to l as
+// well as to the bus already carried by ctx.
+func Logger(l worker.Logger) Middleware {
+	return Sink(worker.LogSink(l))
+}
+
+// DeadlineBudget gives the decorated context at most d to live, measured on
+// the clock carried by ctx. A sooner deadline already on ctx is kept.
+//
+// Middleware cannot return a cancel function, so the budget's resources
+// are released when the deadline passes or ctx is cancelled, whichever
+// comes first. Apply it beneath a context that is cancelled when the work
+// is done.
+func DeadlineBudget(d time.Duration) Middleware {
+	return func(ctx context.Context) context.Context {
+		ctx, _ = clock.WithTimeout(ctx, d) // released with ctx; see above
+		return ctx
+	}
+}
+
+type installedKey struct{}
+
+// Install returns a copy of ctx carrying mws, after any middleware ctx
+// already carries. Apply runs them.
+func Install(ctx context.Context, mws ...Middleware) context.Context {
+	if len(mws) == 0 {
+		return ctx
+	}
+	installed, _ := ctx.Value(installedKey{}).([]Middleware)
+	all := append(append([]Middleware(nil), installed...), mws...)
+	return context.WithValue(ctx, installedKey{}, all)
+}
+
+// Apply runs the middleware installed in ctx over ctx and returns the
+// result. It returns ctx unchanged if none is installed.
+func Apply(ctx context.Context) context.Context {
+	installed, _ := ctx.Value(installedKey{}).([]Middleware)
+	return Chain(installed...)(ctx)
+}
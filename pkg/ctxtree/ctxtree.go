@@ -0,0 +1,198 @@
+// Package ctxtree builds context hierarchies declaratively and remembers
+// their shape, so a scenario can print the tree it built and watch
+// cancellation spread through it.
+//
+//	root := ctxtree.Root()
+//	castle := root.WithCancelCause().Named("castle")
+//	castle.WithValue(houseKey, "gryffindor").WithTimeout(time.Second)
+//	castle.WithValue(houseKey, "slytherin").WithCancel()
+//	fmt.Print(root)
+//
+// Every With method derives a child of the node it is called on and returns
+// that child, so chains read top to bottom and branches start by calling
+// another With method on a node that was kept.
+package ctxtree
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxaudit"
+)
+
+// Node is one context in a tree.
+type Node struct {
+	tree     *tree
+	label    string
+	ctx      context.Context
+	cancel   func(cause error) // nil for contexts that cannot be cancelled directly
+	parent   *Node
+	children []*Node
+}
+
+// tree is shared by every node of one tree and guards its structure.
+type tree struct {
+	mu sync.Mutex
+}
+
+// Root starts a tree at context.Background().
+func Root() *Node {
+	return New(context.Background(), "background")
+}
+
+// New starts a tree at an existing context.
+func New(ctx context.Context, label string) *Node {
+	return &Node{tree: &tree{}, label: label, ctx: ctx}
+}
+
+// Context returns the node's context.
+func (n *Node) Context() context.Context { return n.ctx }
+
+// Label returns the node's label.
+func (n *Node) Label() string {
+	n.tree.mu.Lock()
+	defer n.tree.mu.Unlock()
+	return n.label
+}
+
+// Parent returns the node's parent, or nil for the root.
+func (n *Node) Parent() *Node { return n.parent }
+
+// Children returns the node's children in the order they were derived.
+func (n *Node) Children() []*Node {
+	n.tree.mu.Lock()
+	defer n.tree.mu.Unlock()
+	return append([]*Node(nil), n.children...)
+}
+
+// Named replaces the node's label and returns the node.
+func (n *Node) Named(label string) *Node {
+	n.tree.mu.Lock()
+	defer n.tree.mu.Unlock()
+	n.label = label
+	return n
+}
+
+// Cancellable reports whether Cancel has any effect on this node.
+func (n *Node) Cancellable() bool { return n.cancel != nil }
+
+// Cancel cancels the node's context, and so its whole subtree, with cause.
+// Nodes derived with WithCancel, WithTimeout or WithDeadline ignore the
+// cause and report context.Canceled. It does nothing on nodes that cannot
+// be cancelled directly, such as value nodes.
+func (n *Node) Cancel(cause error) {
+	if n.cancel != nil {
+		n.cancel(cause)
+	}
+}
+
+// CancelAll cancels every cancellable node in the subtree rooted at n. Use it
+// to release a tree once it is no longer needed.
+func (n *Node) CancelAll() {
+	n.Walk(func(m *Node, _ int) { m.Cancel(nil) })
+}
+
+// Walk calls fn for n and every node beneath it, depth first, with the
+// node's depth below n.
+func (n *Node) Walk(fn func(m *Node, depth int)) {
+	var walk func(m *Node, depth int)
+	walk = func(m *Node, depth int) {
+		fn(m, depth)
+		for _, c := range m.Children() {
+			walk(c, depth+1)
+		}
+	}
+	walk(n, 0)
+}
+
+// derive adds a child to n. It must be called directly by the With method
+// that derived ctx, so the audit points at that method's caller.
+func (n *Node) derive(label string, ctx context.Context, cancel func(error)) *Node {
+	if cancel != nil {
+		cancel = ctxaudit.Track(ctx, label, cancel, 2)
+	}
+	child := &Node{tree: n.tree, label: label, ctx: ctx, cancel: cancel, parent: n}
+	n.tree.mu.Lock()
+	defer n.tree.mu.Unlock()
+	n.children = append(n.children, child)
+	return child
+}
+
+// WithCancel derives a child with context.WithCancel.
+func (n *Node) WithCancel() *Node {
+	ctx, cancel := context.WithCancel(n.ctx)
+	return n.derive("cancel", ctx, func(error) { cancel() })
+}
+
+// WithCancelCause derives a child with context.WithCancelCause.
+func (n *Node) WithCancelCause() *Node {
+	ctx, cancel := context.WithCancelCause(n.ctx)
+	return n.derive("cancel-cause", ctx, cancel)
+}
+
+// WithTimeout derives a child with a timeout measured on the clock carried
+// by the node's context; see clock.WithTimeout.
+func (n *Node) WithTimeout(d time.Duration) *Node {
+	ctx, cancel := clock.WithTimeout(n.ctx, d)
+	return n.derive(fmt.Sprintf("timeout %v", d), ctx, func(error) { cancel() })
+}
+
+// WithTimeoutCause derives a child whose timeout reports cause.
+func (n *Node) WithTimeoutCause(d time.Duration, cause error) *Node {
+	ctx, cancel := clock.WithTimeoutCause(n.ctx, d, cause)
+	return n.derive(fmt.Sprintf("timeout %v (cause %q)", d, cause), ctx, func(error) { cancel() })
+}
+
+// WithDeadline derives a child with a deadline measured on the clock
+// carried by the node's context; see clock.WithDeadline.
+func (n *Node) WithDeadline(t time.Time) *Node {
+	ctx, cancel := clock.WithDeadline(n.ctx, t)
+	return n.derive(fmt.Sprintf("deadline %v", t.Format(time.TimeOnly)), ctx, func(error) { cancel() })
+}
+
+// WithValue derives a child with context.WithValue.
+func (n *Node) WithValue(key, val any) *Node {
+	return n.derive(fmt.Sprintf("value %v=%v", key, val), context.WithValue(n.ctx, key, val), nil)
+}
+
+// WithoutCancel derives a child with context.WithoutCancel: it keeps the
+// parent's values but is never cancelled.
+func (n *Node) WithoutCancel() *Node {
+	return n.derive("without-cancel", context.WithoutCancel(n.ctx), nil)
+}
+
+// State describes whether the node's context is still live and, if not, why.
+func (n *Node) State() string {
+	if n.ctx.Err() == nil {
+		return "active"
+	}
+	cause := context.Cause(n.ctx)
+	if cause == n.ctx.Err() {
+		return n.ctx.Err().Error()
+	}
+	return fmt.Sprintf("%v: %v", n.ctx.Err(), cause)
+}
+
+// String renders the subtree rooted at n, one node per line, with each
+// node's state.
+func (n *Node) String() string {
+	var b strings.Builder
+	n.render(&b, "", "")
+	return b.String()
+}
+
+func (n *Node) render(b *strings.Builder, prefix, childPrefix string) {
+	fmt.Fprintf(b, "%s%s [%s]\n", prefix, n.Label(), n.State())
+	children := n.Children()
+	for i, c := range children {
+		if i == len(children)-1 {
+			c.render(b, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			c.render(b, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ctxutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxaudit"
+)
+
+// Background runs work that must outlive the request that started it but
+// not the application, the job context.Background() is so often pressed
+// into and cannot do: a goroutine started with context.Background() is
+// never cancelled, so nothing stops it at shutdown.
+//
+// Every context Background hands out carries the request's values, such as
+// its ID, is never cancelled with the request, and is cancelled with the
+// application's context or by Shutdown, together with everything derived
+// from it:
+//
+//	bg := ctxutil.NewBackground(appCtx)
+//	defer bg.Shutdown(errShuttingDown, time.Second)
+//	...
+//	bg.Go(requestCtx, sendReceipt) // outlives the request, not the app
+type Background struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+}
+
+// NewBackground returns a Background whose work lasts no longer than app.
+func NewBackground(app context.Context) *Background {
+	ctx, cancel := context.WithCancelCause(app)
+	cancel = ctxaudit.Track(ctx, "background", cancel, 1)
+	return &Background{ctx: ctx, cancel: cancel}
+}
+
+// Detach returns a context for work on behalf of req that must outlive it;
+// see WithValues.
+func (b *Background) Detach(req context.Context) context.Context {
+	return WithValues(b.ctx, req)
+}
+
+// Go runs fn in a goroutine of its own with Detach(req). Shutdown waits for
+// it to return.
+func (b *Background) Go(req context.Context, fn func(ctx context.Context)) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		fn(b.Detach(req))
+	}()
+}
+
+// Shutdown cancels every context b has handed out with cause and waits up
+// to timeout, on the application context's clock, for the goroutines
+// started with Go. It reports whether they all returned in time.
+func (b *Background) Shutdown(cause error, timeout time.Duration) bool {
+	b.cancel(cause)
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	timer := clock.From(b.ctx).NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C():
+		return false
+	}
+}
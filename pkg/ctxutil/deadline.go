@@ -0,0 +1,20 @@
+package ctxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// Remaining returns how long is left before ctx's deadline, on ctx's clock,
+// and whether ctx has a deadline at all. Once the deadline has passed it
+// returns a negative duration rather than zero, so a caller can tell by how
+// much it was missed.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return deadline.Sub(clock.From(ctx).Now()), true
+}
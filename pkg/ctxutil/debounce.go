@@ -0,0 +1,53 @@
+package ctxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// Debounce returns a function that calls fn once calls to it have stopped
+// for d, on ctx's clock: a burst of calls, each less than d after the one
+// before, results in a single call of fn, d after the last of them.
+//
+// fn is called from a goroutine of Debounce's own, never two at once. Once
+// ctx is done that goroutine returns and its timer is stopped; a call still
+// waiting out its d is dropped, and calls from then on do nothing. The
+// returned function never blocks and is safe for concurrent use.
+func Debounce(ctx context.Context, d time.Duration, fn func()) func() {
+	clk := clock.From(ctx)
+	trigger := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case <-trigger:
+			case <-ctx.Done():
+				return
+			}
+			// Wait for a quiet spell of d, starting over on each trigger.
+			for quiet := false; !quiet; {
+				timer := clk.NewTimer(d)
+				select {
+				case <-timer.C():
+					quiet = true
+				case <-trigger:
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+				timer.Stop()
+			}
+			fn()
+		}
+	}()
+	return func() {
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case trigger <- struct{}{}:
+		default: // a trigger is already pending
+		}
+	}
+}
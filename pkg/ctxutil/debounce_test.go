@@ -0,0 +1,109 @@
+package ctxutil
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// timerClock is a fake clock that reports each timer made through it, so a
+// test can tell when the code under test has taken in a call and rearmed.
+type timerClock struct {
+	*clock.Fake
+	made chan time.Duration
+}
+
+func newTimerClock() *timerClock {
+	return &timerClock{Fake: clock.NewFake(epoch), made: make(chan time.Duration, 16)}
+}
+
+func (c *timerClock) NewTimer(d time.Duration) clock.Timer {
+	t := c.Fake.NewTimer(d)
+	c.made <- d
+	return t
+}
+
+// debounced returns a Debounce of d on a timerClock and a channel that
+// receives the time of each call of its function.
+func debounced(ctx context.Context, d time.Duration) (*timerClock, func(), <-chan time.Time) {
+	c := newTimerClock()
+	calls := make(chan time.Time, 16)
+	ctx = clock.With(ctx, c)
+	return c, Debounce(ctx, d, func() { calls <- c.Now() }), calls
+}
+
+func TestDebounceCallsOnceAfterBurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, call, calls := debounced(ctx, 100*time.Millisecond)
+
+	for range 3 {
+		call()
+		<-c.made
+		c.Advance(50 * time.Millisecond)
+	}
+	// The last call was 50ms ago: another 49ms is not a quiet spell of 100ms.
+	c.Advance(49 * time.Millisecond)
+	select {
+	case at := <-calls:
+		t.Fatalf("called at %v, before a quiet spell", at.Sub(epoch))
+	default:
+	}
+	c.Advance(time.Millisecond)
+	if at := <-calls; at.Sub(epoch) != 200*time.Millisecond {
+		t.Errorf("called at %v, want 200ms: 100ms after the last call", at.Sub(epoch))
+	}
+
+	// A second burst makes a second call.
+	call()
+	<-c.made
+	c.Advance(100 * time.Millisecond)
+	if at := <-calls; at.Sub(epoch) != 300*time.Millisecond {
+		t.Errorf("second burst called at %v, want 300ms", at.Sub(epoch))
+	}
+	select {
+	case at := <-calls:
+		t.Errorf("an extra call at %v", at.Sub(epoch))
+	default:
+	}
+}
+
+func TestDebounceDropsPendingCallOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c, call, calls := debounced(ctx, 100*time.Millisecond)
+
+	call()
+	<-c.made
+	cancel()
+	waitForPending(t, c.Fake, 0) // Debounce stopped its timer
+	c.Advance(time.Second)
+	call()
+	c.Advance(time.Second)
+	select {
+	case at := <-calls:
+		t.Fatalf("called at %v after cancellation", at.Sub(epoch))
+	default:
+	}
+	select {
+	case d := <-c.made:
+		t.Errorf("a call after cancellation armed a %v timer", d)
+	default:
+	}
+}
+
+// waitForPending blocks until f has n timers pending.
+func waitForPending(t *testing.T, f *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for f.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d timer(s) pending, want %d", f.Pending(), n)
+		}
+		runtime.Gosched()
+	}
+}
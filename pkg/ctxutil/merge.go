@@ -0,0 +1,43 @@
+// Package ctxutil has small helpers for combining and outliving contexts,
+// and for waiting, ticking, debouncing and throttling within their
+// lifetime, that the standard library leaves to the caller.
+package ctxutil
+
+import (
+	"context"
+
+	"github.com/context-demo/pkg/ctxaudit"
+)
+
+// MergeCancel returns a context that is cancelled as soon as any of ctxs
+// is, with the cause of whichever was done first; a context that is
+// already done when MergeCancel is called wins over the others, in the
+// order given. Values and the deadline are those of ctxs[0]: the other
+// contexts only contribute cancellation.
+//
+// As with context.WithCancel, call the returned cancel function once the
+// merged context is no longer needed, to stop watching the others.
+// MergeCancel panics if ctxs is empty.
+func MergeCancel(ctxs ...context.Context) (context.Context, context.CancelFunc) {
+	if len(ctxs) == 0 {
+		panic("ctxutil: MergeCancel of no contexts")
+	}
+	ctx, cancel := context.WithCancelCause(ctxs[0])
+	cancel = ctxaudit.Track(ctx, "merge", cancel, 1)
+	for _, c := range ctxs[1:] {
+		if c.Err() != nil {
+			cancel(context.Cause(c))
+			break
+		}
+	}
+	stops := make([]func() bool, 0, len(ctxs)-1)
+	for _, c := range ctxs[1:] {
+		stops = append(stops, context.AfterFunc(c, func() { cancel(context.Cause(c)) }))
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel(context.Canceled)
+	}
+}
@@ -0,0 +1,32 @@
+package ctxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// Sleep pauses for d on ctx's clock, or until ctx is done, whichever comes
+// first. It returns nil if the full duration elapsed, and the context's
+// cause if ctx was done first, so a caller can stop what it was pacing:
+//
+//	if err := ctxutil.Sleep(ctx, backoff); err != nil {
+//		return err
+//	}
+//
+// Unlike clock.Sleep it never outlasts ctx, and unlike a select on
+// clock.After it leaves no timer behind when cut short.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return context.Cause(ctx)
+	}
+	timer := clock.From(ctx).NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -0,0 +1,50 @@
+package ctxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/ratelimit"
+)
+
+// Throttle is a ratelimit.Limiter bound to a context: it holds up to burst
+// tokens, gains one every interval on the context's clock, and each call
+// of Wait or Allow takes one. Once the context is done, Wait fails and
+// Allow refuses.
+//
+// Use a Throttle for a worker whose limit should end with its context, so
+// that Allow needs no context of its own; share a ratelimit.Limiter instead
+// between workers under different contexts.
+type Throttle struct {
+	ctx context.Context
+	lim *ratelimit.Limiter
+}
+
+// NewThrottle returns a Throttle bound to ctx that starts full, with burst
+// tokens, and gains one every interval. A burst below 1 is taken as 1.
+func NewThrottle(ctx context.Context, interval time.Duration, burst int) *Throttle {
+	return &Throttle{ctx: ctx, lim: ratelimit.New(ratelimit.Every(interval), burst)}
+}
+
+// Wait blocks until it can take a token, and returns nil, or until ctx or
+// the throttle's own context is done, and returns that context's cause.
+// Like ratelimit.Limiter.Wait, it fails at once with
+// ratelimit.ErrWouldExceedDeadline if the token is not due before the
+// deadline of ctx.
+func (t *Throttle) Wait(ctx context.Context) error {
+	if t.ctx.Err() != nil {
+		return context.Cause(t.ctx)
+	}
+	ctx, cancel := MergeCancel(ctx, t.ctx)
+	defer cancel()
+	return t.lim.Wait(ctx)
+}
+
+// Allow takes a token if one is there, without waiting, and reports
+// whether it did. It always refuses once the throttle's context is done.
+func (t *Throttle) Allow() bool {
+	if t.ctx.Err() != nil {
+		return false
+	}
+	return t.lim.Allow(t.ctx)
+}
@@ -0,0 +1,109 @@
+package ctxutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+func TestThrottleAllowsBurstThenOnePerInterval(t *testing.T) {
+	f := clock.NewFake(epoch)
+	ctx, cancel := context.WithCancel(clock.With(context.Background(), f))
+	defer cancel()
+	th := NewThrottle(ctx, 100*time.Millisecond, 3)
+
+	for i := range 3 {
+		if !th.Allow() {
+			t.Fatalf("Allow %d of a burst of 3 refused", i+1)
+		}
+	}
+	if th.Allow() {
+		t.Fatal("Allow succeeded with the bucket empty")
+	}
+	f.Advance(100 * time.Millisecond)
+	if !th.Allow() {
+		t.Error("Allow refused a token made after one interval")
+	}
+	if th.Allow() {
+		t.Error("one interval made more than one token")
+	}
+}
+
+func TestThrottleWaitTakesTokensInOrder(t *testing.T) {
+	f := clock.NewFake(epoch)
+	ctx, cancel := context.WithCancel(clock.With(context.Background(), f))
+	defer cancel()
+	th := NewThrottle(ctx, 100*time.Millisecond, 1)
+	th.Allow()
+
+	first := make(chan error, 1)
+	go func() { first <- th.Wait(ctx) }()
+	f.BlockUntil(1)
+	second := make(chan error, 1)
+	go func() { second <- th.Wait(ctx) }()
+	f.BlockUntil(2)
+
+	f.Advance(100 * time.Millisecond)
+	if err := <-first; err != nil {
+		t.Fatalf("first Wait = %v", err)
+	}
+	select {
+	case err := <-second:
+		t.Fatalf("second Wait returned %v with the first interval's token already taken", err)
+	default:
+	}
+	f.Advance(100 * time.Millisecond)
+	if err := <-second; err != nil {
+		t.Fatalf("second Wait = %v", err)
+	}
+}
+
+func TestThrottleReleasesWaitersWithItsCause(t *testing.T) {
+	errStop := errors.New("shutting down")
+	f := clock.NewFake(epoch)
+	base := clock.With(context.Background(), f)
+	ctx, cancel := context.WithCancelCause(base)
+	th := NewThrottle(ctx, time.Hour, 1)
+	th.Allow()
+
+	done := make(chan error, 1)
+	go func() { done <- th.Wait(base) }() // the caller's own context stays live
+	f.BlockUntil(1)
+	cancel(errStop)
+	if err := <-done; !errors.Is(err, errStop) {
+		t.Fatalf("Wait when the throttle's context ended = %v, want %v", err, errStop)
+	}
+
+	f.Advance(time.Hour)
+	if th.Allow() {
+		t.Error("Allow succeeded after the throttle's context ended")
+	}
+	if err := th.Wait(base); !errors.Is(err, errStop) {
+		t.Errorf("Wait after the throttle's context ended = %v, want %v", err, errStop)
+	}
+}
+
+func TestThrottleWaitReturnsCallersCause(t *testing.T) {
+	errGone := errors.New("client went away")
+	f := clock.NewFake(epoch)
+	ctx := clock.With(context.Background(), f)
+	th := NewThrottle(ctx, time.Hour, 1)
+	th.Allow()
+
+	wctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan error, 1)
+	go func() { done <- th.Wait(wctx) }()
+	f.BlockUntil(1)
+	cancel(errGone)
+	if err := <-done; !errors.Is(err, errGone) {
+		t.Fatalf("Wait when the caller's context ended = %v, want %v", err, errGone)
+	}
+	// The throttle itself is unaffected, and got its token back.
+	f.Advance(time.Hour)
+	if !th.Allow() {
+		t.Error("Allow refused after a caller gave up waiting")
+	}
+}
@@ -0,0 +1,61 @@
+package ctxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/rng"
+)
+
+// Tick returns a channel that delivers ticks every interval on ctx's clock
+// until ctx is done. The ticker behind it is stopped as soon as ctx is
+// done, so a worker that ticks for as long as its context lives needs no
+// defer ticker.Stop() and cannot leak the ticker:
+//
+//	ticks := ctxutil.Tick(ctx, interval)
+//	for {
+//		select {
+//		case <-ticks:
+//			// work
+//		case <-ctx.Done():
+//			return context.Cause(ctx)
+//		}
+//	}
+//
+// If ctx carries an rng.Jitter other than Fixed, each interval is jittered
+// with it, drawing from ctx's rng.Rand. As with a ticker, a tick the
+// receiver is not ready for is dropped.
+//
+// The channel is never closed, so select on ctx.Done() alongside it. Only
+// use Tick where the loop ends with ctx: a worker that returns while ctx
+// is live keeps the ticker running until ctx is done, and should stop a
+// ticker of its own instead.
+func Tick(ctx context.Context, interval time.Duration) <-chan time.Time {
+	clk := clock.From(ctx)
+	j := rng.JitterFrom(ctx)
+	if j.Kind == rng.Fixed {
+		ticker := clk.NewTicker(interval)
+		context.AfterFunc(ctx, ticker.Stop)
+		return ticker.C()
+	}
+
+	r := rng.From(ctx)
+	ticks := make(chan time.Time, 1)
+	go func() {
+		for {
+			timer := clk.NewTimer(j.Apply(r, interval))
+			select {
+			case t := <-timer.C():
+				select {
+				case ticks <- t:
+				default:
+				}
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return ticks
+}
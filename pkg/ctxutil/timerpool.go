@@ -0,0 +1,48 @@
+package ctxutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// timers holds stopped *time.Timers for WaitFor to reuse.
+var timers sync.Pool
+
+// WaitFor is Sleep for hot paths, such as a retry loop or a per-message
+// timeout, that wait many thousands of times a second. On the real clock it
+// takes its timer from a pool and puts it back when done, so once the pool
+// is warm a wait allocates nothing, where a select on time.After allocates
+// a timer and a channel every time, even when ctx cuts the wait short.
+// BenchmarkWaitFor and BenchmarkTimeAfter compare them.
+//
+// On any other clock WaitFor is Sleep. Either way it returns nil if the
+// full duration elapsed, and the context's cause if ctx was done first.
+func WaitFor(ctx context.Context, d time.Duration) error {
+	if clock.From(ctx) != clock.Real {
+		return Sleep(ctx, d)
+	}
+	if d <= 0 {
+		return context.Cause(ctx)
+	}
+	t, ok := timers.Get().(*time.Timer)
+	if ok {
+		t.Reset(d)
+	} else {
+		t = time.NewTimer(d)
+	}
+	defer func() {
+		// Since Go 1.23 a stopped timer's channel is empty, so the next
+		// Reset cannot deliver a stale tick.
+		t.Stop()
+		timers.Put(t)
+	}()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
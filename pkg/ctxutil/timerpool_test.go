@@ -0,0 +1,47 @@
+package ctxutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// The benchmarks compare ways of waiting on a timer that cancellation cuts
+// short, in the tight loop of a hot timeout path: each waits an hour on a
+// context that is already done. Timers that fire are left out, since how
+// long they take to do so depends on the scheduler more than on the timer.
+
+// cancelledCtx returns a context that is already cancelled.
+func cancelledCtx() context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.New("benchmark"))
+	return ctx
+}
+
+func BenchmarkWaitFor(b *testing.B) {
+	ctx := cancelledCtx()
+	b.ReportAllocs()
+	for range b.N {
+		WaitFor(ctx, time.Hour)
+	}
+}
+
+func BenchmarkSleep(b *testing.B) {
+	ctx := cancelledCtx()
+	b.ReportAllocs()
+	for range b.N {
+		Sleep(ctx, time.Hour)
+	}
+}
+
+func BenchmarkTimeAfter(b *testing.B) {
+	ctx := cancelledCtx()
+	b.ReportAllocs()
+	for range b.N {
+		select {
+		case <-time.After(time.Hour):
+		case <-ctx.Done():
+		}
+	}
+}
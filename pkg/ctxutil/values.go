@@ -0,0 +1,31 @@
+package ctxutil
+
+import "context"
+
+// WithValues returns a context that is cancelled with parent and has its
+// deadline, but looks values up in values first and in parent only if
+// values does not carry them. It is how work detached from a request, such
+// as a write that must finish after the client has gone, keeps the
+// request's ID and trace metadata while obeying a longer-lived context:
+//
+//	ctx := ctxutil.WithValues(serverCtx, requestCtx)
+//
+// values is never used for cancellation: context.Cause on the result, and
+// on contexts derived from it, only ever reports parent's cause.
+func WithValues(parent, values context.Context) context.Context {
+	// WithoutCancel hides values' own cancellation from the standard
+	// library, which finds a parent's cancel state through Value.
+	return valuesCtx{Context: parent, values: context.WithoutCancel(values)}
+}
+
+type valuesCtx struct {
+	context.Context // parent: cancellation, deadline and fallback values
+	values          context.Context
+}
+
+func (c valuesCtx) Value(key any) any {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
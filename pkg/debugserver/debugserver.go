@@ -0,0 +1,77 @@
+// Package debugserver serves net/http/pprof and expvar while a
+// demonstration runs, so go tool pprof can be pointed at a leak as it
+// happens:
+//
+//	go tool pprof http://localhost:6060/debug/pprof/goroutine
+//
+// The expvar variables at /debug/vars count the run's events by kind and
+// ticks by worker, and report how many goroutines there are and how many
+// of them are running a worker.
+package debugserver
+
+import (
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/worker"
+)
+
+var (
+	events = expvar.NewMap("contextdemo.events")
+	ticks  = expvar.NewMap("contextdemo.ticks")
+)
+
+func init() {
+	expvar.Publish("contextdemo.goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+	expvar.Publish("contextdemo.workers", expvar.Func(func() any { return len(worker.Goroutines()) }))
+}
+
+// Sink counts every event it is given into the expvar variables. It is
+// safe to subscribe to several buses at once.
+var Sink event.Sink = event.SinkFunc(func(e event.Event) {
+	events.Add(string(e.Kind()), 1)
+	if e.Kind() == event.KindTickCompleted {
+		ticks.Add(e.EventHeader().Worker, 1)
+	}
+})
+
+// Server is a running debug server.
+type Server struct {
+	srv *http.Server
+	ln  net.Listener
+}
+
+// Start listens on addr, such as "localhost:6060" or ":0", and serves
+// /debug/pprof/ and /debug/vars from a goroutine of its own until Close.
+func Start(addr string) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	s := &Server{srv: &http.Server{Handler: mux}, ln: ln}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ln.Close()
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on, with the port filled in
+// if Start was given port 0.
+func (s *Server) Addr() string { return s.ln.Addr().String() }
+
+// Close stops the server, cutting off any request still in progress.
+func (s *Server) Close() error { return s.srv.Close() }
@@ -0,0 +1,81 @@
+// Package errgroup runs a group of goroutines working on subtasks of one
+// task, and cancels them all when the first fails.
+//
+// It is the part of golang.org/x/sync/errgroup this module uses, WithContext,
+// Go and Wait, kept here because the module depends on the standard library
+// alone, plus one function that package lacks, WithCancelCause. Scenarios
+// that run worker.Workers get the same behaviour, with each worker's result
+// recorded, from scenario.Group.CancelOnError.
+//
+// A group made by WithContext cancels its context with a plain cancel, so
+// the siblings of a failed goroutine only learn that they were cancelled,
+// not why:
+//
+//	g, ctx := errgroup.WithContext(parent)
+//	g.Go(func() error { return fetch(ctx) })
+//	g.Go(func() error { return index(ctx) })
+//	err := g.Wait() // the first error; context.Cause(ctx) is context.Canceled
+//
+// A group made by WithCancelCause cancels it with the first error as the
+// cause, so every sibling can report what stopped it.
+package errgroup
+
+import (
+	"context"
+	"sync"
+)
+
+// Group is a collection of goroutines working on subtasks of one task. The
+// zero Group is valid and does not cancel on error.
+type Group struct {
+	cancel func(error)
+
+	wg sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// WithContext returns a new Group and a context derived from ctx. The
+// context is cancelled the first time a function passed to Go returns an
+// error, or the first time Wait returns, whichever happens first. Its
+// cause is context.Canceled either way.
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{cancel: func(error) { cancel() }}, ctx
+}
+
+// WithCancelCause is WithContext, except that when a function passed to Go
+// returns an error the context is cancelled with that error as its cause,
+// for the goroutines still running to find with context.Cause.
+func WithCancelCause(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Wait blocks until every function passed to Go has returned, then returns
+// the first error, if any, from them.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
+
+// Go calls f in a new goroutine. The first call to return an error cancels
+// the group's context, if it has one, and its error is returned by Wait.
+func (g *Group) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
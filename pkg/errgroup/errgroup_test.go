@@ -0,0 +1,86 @@
+package errgroup
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithCancelCauseCancelsWithFirstError(t *testing.T) {
+	errFirst := errors.New("fetch failed")
+	g, ctx := WithCancelCause(context.Background())
+
+	failed := make(chan struct{})
+	var sawCause error
+	g.Go(func() error {
+		<-ctx.Done()
+		sawCause = context.Cause(ctx)
+		return errors.New("index stopped")
+	})
+	g.Go(func() error {
+		defer close(failed)
+		return errFirst
+	})
+	<-failed
+
+	if err := g.Wait(); !errors.Is(err, errFirst) {
+		t.Errorf("Wait = %v, want the first error %v", err, errFirst)
+	}
+	if !errors.Is(sawCause, errFirst) {
+		t.Errorf("the sibling saw cause %v, want %v", sawCause, errFirst)
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("ctx.Err() = %v, want %v", ctx.Err(), context.Canceled)
+	}
+}
+
+func TestWithContextCancelsWithoutCause(t *testing.T) {
+	errFirst := errors.New("fetch failed")
+	g, ctx := WithContext(context.Background())
+	var sawCause error
+	g.Go(func() error {
+		<-ctx.Done()
+		sawCause = context.Cause(ctx)
+		return nil
+	})
+	g.Go(func() error { return errFirst })
+
+	if err := g.Wait(); !errors.Is(err, errFirst) {
+		t.Errorf("Wait = %v, want %v", err, errFirst)
+	}
+	if sawCause != context.Canceled {
+		t.Errorf("the sibling saw cause %v, want plain %v", sawCause, context.Canceled)
+	}
+}
+
+func TestWaitCancelsContextOnSuccess(t *testing.T) {
+	g, ctx := WithCancelCause(context.Background())
+	for range 3 {
+		g.Go(func() error { return nil })
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait = %v, want nil", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("the group's context is still live after Wait")
+	}
+	if cause := context.Cause(ctx); cause != context.Canceled {
+		t.Errorf("cause after a clean Wait = %v, want %v", cause, context.Canceled)
+	}
+}
+
+func TestZeroGroup(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	var g Group
+	g.Go(func() error { return errA })
+	g.Go(func() error { return errB })
+	g.Go(func() error { return nil })
+	if err := g.Wait(); !errors.Is(err, errA) && !errors.Is(err, errB) {
+		t.Errorf("Wait = %v, want %v or %v", err, errA, errB)
+	}
+	var ok Group
+	ok.Go(func() error { return nil })
+	if err := ok.Wait(); err != nil {
+		t.Errorf("Wait with no failures = %v, want nil", err)
+	}
+}
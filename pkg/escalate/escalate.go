@@ -0,0 +1,124 @@
+// Package escalate warns, with rising urgency, as a worker's deadline
+// approaches.
+//
+// A deadline gives no sign of itself until it fires. Watch publishes an
+// event.DeadlineApproaching as each threshold of the time a worker was
+// given is used up, 50%, 80% and 95% by default, so a narration, a JSON
+// stream or the TUI shows the warnings escalate before the deadline ends
+// the work:
+//
+//	contextdemo.WithMiddleware(escalate.Middleware(0.5, 0.8, 0.95))
+//
+// The budget is measured from when the watch starts, for Middleware when
+// the worker does, to the context's deadline. A context without one is not
+// watched.
+package escalate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/worker"
+)
+
+// DefaultThresholds are the fractions of the budget warned about when none
+// are given.
+var DefaultThresholds = []float64{0.5, 0.8, 0.95}
+
+// ParseThresholds parses a comma-separated list of percentages, such as
+// "50,80,95", into fractions.
+func ParseThresholds(s string) ([]float64, error) {
+	var out []float64
+	for _, f := range strings.Split(s, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(f), "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("threshold %q: not a percentage", f)
+		}
+		if p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("threshold %q: want more than 0 and less than 100", f)
+		}
+		out = append(out, p/100)
+	}
+	return out, nil
+}
+
+// Watch publishes an event.DeadlineApproaching on the bus carried by ctx
+// as each threshold, a fraction of the time left before ctx's deadline, is
+// used up. It stops once the last threshold is passed, ctx is done, or the
+// returned function is called. Without thresholds it uses
+// DefaultThresholds; without a deadline it does nothing.
+func Watch(ctx context.Context, thresholds ...float64) (stop func()) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return func() {}
+	}
+	if len(thresholds) == 0 {
+		thresholds = DefaultThresholds
+	}
+	thresholds = slices.Sorted(slices.Values(thresholds))
+	clk := clock.From(ctx)
+	start := clk.Now()
+	total := deadline.Sub(start)
+	if total <= 0 {
+		return func() {}
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, t := range thresholds {
+			at := start.Add(time.Duration(float64(total) * t))
+			timer := clk.NewTimer(at.Sub(clk.Now()))
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-quit:
+				timer.Stop()
+				return
+			}
+			left := deadline.Sub(clk.Now())
+			name, _ := worker.WorkerName(ctx)
+			msg := fmt.Sprintf("%s has used %.0f%% of its %v budget; %v left before the deadline.", name, t*100, total, left)
+			event.BusFrom(ctx).Publish(event.DeadlineApproaching{Header: worker.Header(ctx, msg), Consumed: t, Remaining: left})
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+		<-done
+	}
+}
+
+type stopKey struct{}
+
+// Middleware watches every worker from when it starts until it exits; see
+// Watch.
+func Middleware(thresholds ...float64) ctxmw.Middleware {
+	hooks := &worker.Hooks{
+		OnStart: func(ctx context.Context) {
+			if stop, ok := ctx.Value(stopKey{}).(*func()); ok {
+				*stop = Watch(ctx, thresholds...)
+			}
+		},
+		OnExit: func(ctx context.Context, _ worker.Result) {
+			if stop, ok := ctx.Value(stopKey{}).(*func()); ok && *stop != nil {
+				(*stop)()
+			}
+		},
+	}
+	return func(ctx context.Context) context.Context {
+		ctx = context.WithValue(ctx, stopKey{}, new(func()))
+		return worker.WithHooks(ctx, hooks)
+	}
+}
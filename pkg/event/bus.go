@@ -0,0 +1,68 @@
+package event
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// Sink consumes events.
+type Sink interface {
+	Handle(e Event)
+}
+
+// SinkFunc adapts an ordinary function to the Sink interface.
+type SinkFunc func(e Event)
+
+// Handle calls f(e).
+func (f SinkFunc) Handle(e Event) { f(e) }
+
+// Bus delivers every published event to every subscribed sink.
+//
+// Delivery is synchronous and serialised: sinks see events one at a time,
+// in publication order, so a sink needs no locking of its own. A sink must
+// not publish to the bus that is delivering to it.
+type Bus struct {
+	mu    sync.Mutex
+	sinks []Sink
+}
+
+// NewBus returns a Bus delivering to sinks.
+func NewBus(sinks ...Sink) *Bus {
+	return &Bus{sinks: sinks}
+}
+
+// Subscribe adds s to the sinks the bus delivers to.
+func (b *Bus) Subscribe(s Sink) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sinks = append(b.sinks, s)
+}
+
+// Publish delivers e to every sink.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.sinks {
+		s.Handle(e)
+	}
+}
+
+// Default is the bus used when a context carries none. It narrates to
+// standard output.
+var Default = NewBus(NewTextSink(os.Stdout))
+
+type busKey struct{}
+
+// WithBus returns a copy of ctx carrying b.
+func WithBus(ctx context.Context, b *Bus) context.Context {
+	return context.WithValue(ctx, busKey{}, b)
+}
+
+// BusFrom returns the bus carried by ctx, or Default if there is none.
+func BusFrom(ctx context.Context) *Bus {
+	if b, ok := ctx.Value(busKey{}).(*Bus); ok {
+		return b
+	}
+	return Default
+}
@@ -0,0 +1,150 @@
+// Package event defines the typed events emitted while a demonstration runs
+// and the Bus that fans them out to sinks.
+//
+// Workers and scenarios never print directly: they publish events, and
+// sinks decide what to do with them, whether that is narrating to a
+// terminal, writing JSON, or counting.
+package event
+
+import "time"
+
+// Kind names an event type. It is stable and suitable for machine output.
+type Kind string
+
+// The kinds of event.
+const (
+	KindWorkerStarted        Kind = "worker_started"
+	KindTickCompleted        Kind = "tick_completed"
+	KindCancellationReceived Kind = "cancellation_received"
+	KindWorkerExited         Kind = "worker_exited"
+	KindWorkerLeaked         Kind = "worker_leaked"
+	KindNote                 Kind = "note"
+	KindGoroutineSample      Kind = "goroutine_sample"
+	KindLowBudget            Kind = "low_budget"
+	KindDeadlineApproaching  Kind = "deadline_approaching"
+)
+
+// Event is implemented by every event type in this package.
+type Event interface {
+	Kind() Kind
+	// EventHeader returns the fields every event shares.
+	EventHeader() Header
+}
+
+// Header holds the fields every event shares.
+type Header struct {
+	// Time is when the event happened, on the demonstration's clock.
+	Time time.Time
+	// Scenario, Worker and RequestID identify where the event came from.
+	// Any of them may be empty.
+	Scenario  string
+	Worker    string
+	RequestID string
+	// Message is the human narration for the event, if any. It never ends
+	// in a newline but may contain blank lines used for layout.
+	Message string
+}
+
+// EventHeader returns h. Event types embed Header to satisfy Event.
+func (h Header) EventHeader() Header { return h }
+
+// WorkerStarted is published just before a worker's Run method is called.
+type WorkerStarted struct {
+	Header
+}
+
+// TickCompleted is published after a worker finishes a unit of work.
+type TickCompleted struct {
+	Header
+	// Processed is the worker's running total.
+	Processed int64
+}
+
+// CancellationReceived is published when a worker observes ctx.Done().
+type CancellationReceived struct {
+	Header
+	// Err is ctx.Err() and Cause is context.Cause(ctx) at that moment.
+	Err   error
+	Cause error
+}
+
+// WorkerExited is published after a worker's Run method returns.
+type WorkerExited struct {
+	Header
+	// Exit is the worker's exit reason, such as "cancelled" or "failed".
+	Exit string
+	// Err is the error Run returned and Cause the context's cause, if any.
+	Err   error
+	Cause error
+	// CtxErr is ctx.Err() when the worker exited, which tells a deadline
+	// from a cancellation whatever the cause; see Reason.
+	CtxErr error
+	// Processed is the number of units of work the worker completed.
+	Processed int64
+}
+
+// WorkerLeaked is published for a worker that is still running when its
+// scenario finishes.
+type WorkerLeaked struct {
+	Header
+	// Processed is the number of units of work completed so far.
+	Processed int64
+	// Blocked reports that the worker had stopped reporting ticks, so it
+	// is stuck rather than still working.
+	Blocked bool
+}
+
+// Note is free-form narration that is not tied to a lifecycle step.
+type Note struct {
+	Header
+}
+
+// GoroutineSample is published periodically in watch mode with the number
+// of goroutines in the process.
+type GoroutineSample struct {
+	Header
+	Goroutines int
+}
+
+func (WorkerStarted) Kind() Kind        { return KindWorkerStarted }
+func (TickCompleted) Kind() Kind        { return KindTickCompleted }
+func (CancellationReceived) Kind() Kind { return KindCancellationReceived }
+func (WorkerExited) Kind() Kind         { return KindWorkerExited }
+func (WorkerLeaked) Kind() Kind         { return KindWorkerLeaked }
+func (Note) Kind() Kind                 { return KindNote }
+func (GoroutineSample) Kind() Kind      { return KindGoroutineSample }
+func (LowBudget) Kind() Kind            { return KindLowBudget }
+func (DeadlineApproaching) Kind() Kind  { return KindDeadlineApproaching }
+
+// LowBudget is published when a worker starts an operation with less time
+// left before its context's deadline than the operation usually takes.
+type LowBudget struct {
+	Header
+	// Op names the operation.
+	Op string
+	// Remaining is the time left before the deadline, and Expected what
+	// the operation has cost before.
+	Remaining time.Duration
+	Expected  time.Duration
+}
+
+// DeadlineApproaching is published as a worker uses up each threshold of
+// the time it had before its context's deadline; see package escalate.
+type DeadlineApproaching struct {
+	Header
+	// Consumed is the fraction of the budget used up, such as 0.8, and
+	// Remaining the time left before the deadline.
+	Consumed  float64
+	Remaining time.Duration
+}
+
+// Cause returns the cancellation cause an event carries, or nil.
+func Cause(e Event) error {
+	switch e := e.(type) {
+	case CancellationReceived:
+		return e.Cause
+	case WorkerExited:
+		return e.Cause
+	}
+	return nil
+}
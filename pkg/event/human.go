@@ -0,0 +1,47 @@
+package event
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/context-demo/pkg/ansi"
+)
+
+// kindColors is how NewHumanSink colours each kind of event. Notes are left
+// plain.
+var kindColors = map[Kind]string{
+	KindWorkerStarted:        ansi.Cyan,
+	KindTickCompleted:        ansi.Dim,
+	KindCancellationReceived: ansi.Yellow,
+	KindWorkerExited:         ansi.Green,
+	KindWorkerLeaked:         ansi.Bold + ansi.Red,
+	KindGoroutineSample:      ansi.Bold,
+	KindLowBudget:            ansi.Yellow,
+	KindDeadlineApproaching:  ansi.Yellow,
+}
+
+// NewHumanSink returns a Sink that writes messages to w like NewTextSink.
+// Events without a message are written as a short line naming the event
+// and its worker; filter them out with AtLevel below LevelDebug.
+//
+// With color set, each message is coloured by its kind, and a cancellation
+// cause quoted in the message is picked out in its own colour.
+func NewHumanSink(w io.Writer, color bool) Sink {
+	return SinkFunc(func(e Event) {
+		h := e.EventHeader()
+		msg := h.Message
+		if msg == "" {
+			msg = fmt.Sprintf("· %s %s", e.Kind(), h.Worker)
+		}
+		if !color {
+			fmt.Fprintln(w, msg)
+			return
+		}
+		c := kindColors[e.Kind()]
+		if cause := Cause(e); cause != nil && c != "" {
+			msg = strings.ReplaceAll(msg, cause.Error(), ansi.Magenta+cause.Error()+ansi.Reset+c)
+		}
+		fmt.Fprintln(w, ansi.Paint(c, msg))
+	})
+}
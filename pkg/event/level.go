@@ -0,0 +1,39 @@
+package event
+
+// Level is how much of a run is shown to a human reader.
+type Level int
+
+// The levels, from least to most detail. Each shows everything the levels
+// below it do.
+const (
+	// LevelQuiet shows no events at all, leaving only the final summary.
+	LevelQuiet Level = iota
+	// LevelNormal shows narration and lifecycle events.
+	LevelNormal
+	// LevelVerbose adds every completed tick.
+	LevelVerbose
+	// LevelDebug adds events that carry no message.
+	LevelDebug
+)
+
+// LevelOf returns the least detailed level at which e is shown.
+func LevelOf(e Event) Level {
+	switch {
+	case e.EventHeader().Message == "":
+		return LevelDebug
+	case e.Kind() == KindTickCompleted:
+		return LevelVerbose
+	default:
+		return LevelNormal
+	}
+}
+
+// AtLevel returns a Sink that passes on to s only the events shown at
+// level l.
+func AtLevel(s Sink, l Level) Sink {
+	return SinkFunc(func(e Event) {
+		if LevelOf(e) <= l {
+			s.Handle(e)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Exit reasons, as counted by Metrics. They refine a worker's exit by
+// telling cancellations apart from deadlines.
+const (
+	ReasonCompleted        = "completed"
+	ReasonFailed           = "failed"
+	ReasonCanceled         = "canceled"
+	ReasonDeadlineExceeded = "deadline_exceeded"
+	ReasonLeaked           = "leaked"
+)
+
+// Reason returns the exit reason for a worker that exited with exit, such
+// as "cancelled", while its context's Err was ctxErr.
+func Reason(exit string, ctxErr error) string {
+	if exit != "cancelled" {
+		return exit
+	}
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		return ReasonDeadlineExceeded
+	}
+	return ReasonCanceled
+}
+
+// CauseKey returns the key a cancellation cause is counted under: the type
+// of a typed cause, such as "*cause.ShutdownRequested", and the text of any
+// other, so causes made with errors.New or fmt.Errorf are told apart by
+// what they say, flattened onto one line. It returns "" for a nil cause.
+func CauseKey(cause error) string {
+	if cause == nil {
+		return ""
+	}
+	switch t := fmt.Sprintf("%T", cause); t {
+	case "*errors.errorString", "*fmt.wrapError", "*fmt.wrapErrors", "*errors.joinError", "context.deadlineExceededError":
+		return strings.ReplaceAll(cause.Error(), "\n", "; ")
+	default:
+		return t
+	}
+}
+
+// ctxErrOf returns the context error with text s, as written by Record.
+func ctxErrOf(s string) error {
+	switch s {
+	case "":
+		return nil
+	case context.Canceled.Error():
+		return context.Canceled
+	case context.DeadlineExceeded.Error():
+		return context.DeadlineExceeded
+	default:
+		return errors.New(s)
+	}
+}
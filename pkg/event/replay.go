@@ -0,0 +1,56 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+)
+
+// Replay reads events recorded by NewJSONSink from r and delivers them to s
+// at their recorded offsets from the first event, divided by speed. A speed
+// of zero or less delivers them as fast as possible.
+//
+// Each event is due at a time fixed when replay starts, measured on the
+// clock carried by ctx, rather than after its gap from the one before: time
+// spent reading, decoding and handling events is taken out of the wait, so
+// the playback does not drift behind the recording however long it runs.
+// Replay stops early if ctx is done.
+func Replay(ctx context.Context, r io.Reader, s Sink, speed float64) error {
+	clk := clock.From(ctx)
+	var first, start time.Time
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		e, err := Decode(rec)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+
+		if t := e.EventHeader().Time; speed > 0 && !t.IsZero() {
+			if first.IsZero() {
+				first, start = t, clk.Now()
+			}
+			due := start.Add(time.Duration(float64(t.Sub(first)) / speed))
+			if wait := due.Sub(clk.Now()); wait > 0 {
+				if err := ctxutil.Sleep(ctx, wait); err != nil {
+					return err
+				}
+			}
+		}
+		s.Handle(e)
+	}
+	return sc.Err()
+}
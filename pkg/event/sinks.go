@@ -0,0 +1,190 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// NewTextSink returns a Sink that writes the message of every event that
+// has one to w, one per line. Events without a message are skipped.
+func NewTextSink(w io.Writer) Sink {
+	return SinkFunc(func(e Event) {
+		if h := e.EventHeader(); h.Message != "" {
+			fmt.Fprintln(w, h.Message)
+		}
+	})
+}
+
+// NewJSONSink returns a Sink that writes every event to w as a single-line
+// JSON object. It may be shared by several buses.
+func NewJSONSink(w io.Writer) Sink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return SinkFunc(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(Record(e))
+	})
+}
+
+// Record flattens e into a map suitable for encoding. Every record has
+// time and event fields; the others are present only when set. The message
+// is kept verbatim, layout included, so Decode can restore it.
+func Record(e Event) map[string]any {
+	h := e.EventHeader()
+	rec := map[string]any{
+		"time":  h.Time.Format(time.RFC3339Nano),
+		"event": e.Kind(),
+	}
+	set := func(k string, v any) {
+		switch v := v.(type) {
+		case nil:
+			return
+		case string:
+			if v == "" {
+				return
+			}
+		case error:
+			rec[k] = v.Error()
+			return
+		}
+		rec[k] = v
+	}
+	set("scenario", h.Scenario)
+	set("worker", h.Worker)
+	set("request_id", h.RequestID)
+	set("message", h.Message)
+
+	switch e := e.(type) {
+	case TickCompleted:
+		set("processed", e.Processed)
+	case CancellationReceived:
+		set("err", e.Err)
+		set("cause", e.Cause)
+	case WorkerExited:
+		set("exit", e.Exit)
+		set("err", e.Err)
+		set("cause", e.Cause)
+		set("ctx_err", e.CtxErr)
+		set("processed", e.Processed)
+	case WorkerLeaked:
+		set("processed", e.Processed)
+		if e.Blocked {
+			set("blocked", true)
+		}
+	case GoroutineSample:
+		set("goroutines", e.Goroutines)
+	case LowBudget:
+		set("op", e.Op)
+		set("remaining", e.Remaining.String())
+		set("expected", e.Expected.String())
+	case DeadlineApproaching:
+		set("consumed", e.Consumed)
+		set("remaining", e.Remaining.String())
+	}
+	return rec
+}
+
+// Decode rebuilds the event described by rec, as produced by Record and
+// read back from JSON. Errors come back as plain errors with the original
+// text.
+func Decode(rec map[string]any) (Event, error) {
+	str := func(k string) string {
+		s, _ := rec[k].(string)
+		return s
+	}
+	num := func(k string) int64 {
+		n, _ := rec[k].(float64) // encoding/json decodes numbers as float64
+		return int64(n)
+	}
+	dur := func(k string) time.Duration {
+		d, _ := time.ParseDuration(str(k))
+		return d
+	}
+	errOf := func(k string) error {
+		if s := str(k); s != "" {
+			return errors.New(s)
+		}
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, str("time"))
+	if err != nil {
+		return nil, fmt.Errorf("event record: bad time %q", str("time"))
+	}
+	h := Header{
+		Time:      t,
+		Scenario:  str("scenario"),
+		Worker:    str("worker"),
+		RequestID: str("request_id"),
+		Message:   str("message"),
+	}
+	switch k := Kind(str("event")); k {
+	case KindWorkerStarted:
+		return WorkerStarted{Header: h}, nil
+	case KindTickCompleted:
+		return TickCompleted{Header: h, Processed: num("processed")}, nil
+	case KindCancellationReceived:
+		return CancellationReceived{Header: h, Err: errOf("err"), Cause: errOf("cause")}, nil
+	case KindWorkerExited:
+		return WorkerExited{Header: h, Exit: str("exit"), Err: errOf("err"), Cause: errOf("cause"), CtxErr: ctxErrOf(str("ctx_err")), Processed: num("processed")}, nil
+	case KindWorkerLeaked:
+		blocked, _ := rec["blocked"].(bool)
+		return WorkerLeaked{Header: h, Processed: num("processed"), Blocked: blocked}, nil
+	case KindNote:
+		return Note{Header: h}, nil
+	case KindGoroutineSample:
+		return GoroutineSample{Header: h, Goroutines: int(num("goroutines"))}, nil
+	case KindLowBudget:
+		return LowBudget{Header: h, Op: str("op"), Remaining: dur("remaining"), Expected: dur("expected")}, nil
+	case KindDeadlineApproaching:
+		consumed, _ := rec["consumed"].(float64)
+		return DeadlineApproaching{Header: h, Consumed: consumed, Remaining: dur("remaining")}, nil
+	default:
+		return nil, fmt.Errorf("event record: unknown event %q", k)
+	}
+}
+
+// Metrics is a Sink that counts events. Read it once publishing is done, or
+// from another sink on the same bus.
+type Metrics struct {
+	// Events counts events by kind.
+	Events map[Kind]int
+	// Ticks counts TickCompleted events by worker.
+	Ticks map[string]int
+	// Exits counts exited and leaked workers by Reason.
+	Exits map[string]int
+	// Causes counts the workers that exited on cancellation by the
+	// CauseKey of their cause.
+	Causes map[string]int
+}
+
+// NewMetrics returns an empty Metrics sink.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Events: make(map[Kind]int),
+		Ticks:  make(map[string]int),
+		Exits:  make(map[string]int),
+		Causes: make(map[string]int),
+	}
+}
+
+// Handle implements Sink.
+func (m *Metrics) Handle(e Event) {
+	m.Events[e.Kind()]++
+	switch e := e.(type) {
+	case TickCompleted:
+		m.Ticks[e.Worker]++
+	case WorkerExited:
+		m.Exits[Reason(e.Exit, e.CtxErr)]++
+		if k := CauseKey(e.Cause); k != "" && e.Err == nil {
+			m.Causes[k]++
+		}
+	case WorkerLeaked:
+		m.Exits[ReasonLeaked]++
+	}
+}
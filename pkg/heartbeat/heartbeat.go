@@ -0,0 +1,114 @@
+// Package heartbeat tells leaked workers that are still working apart from
+// those that are stuck.
+//
+// Every tick a worker reports is a heartbeat, and so is its start. A
+// Monitor subscribed to the run's bus notes when each worker last beat. A
+// worker that has gone quiet without exiting is stale: blocked on a
+// channel, a lock or a deadlock. A leaked worker that keeps beating is
+// spinning instead, still doing work nobody wants.
+package heartbeat
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/event"
+)
+
+// Monitor is an event.Sink that tracks the heartbeats of every worker on a
+// bus. It is safe for concurrent use.
+type Monitor struct {
+	after time.Duration
+
+	mu      sync.Mutex
+	workers map[string]*pulse
+}
+
+// pulse is what a Monitor knows about one worker.
+type pulse struct {
+	last   time.Time
+	gap    time.Duration // longest time between two beats so far
+	beats  int
+	exited bool
+}
+
+// NewMonitor returns a Monitor that judges a worker that has beaten only
+// once, on starting, to be stale once it has been quiet for longer than
+// after.
+func NewMonitor(after time.Duration) *Monitor {
+	return &Monitor{after: after, workers: make(map[string]*pulse)}
+}
+
+// Handle records the heartbeats and exits among the events of a run.
+func (m *Monitor) Handle(e event.Event) {
+	h := e.EventHeader()
+	if h.Worker == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.workers[h.Worker]
+	if p == nil {
+		p = &pulse{}
+		m.workers[h.Worker] = p
+	}
+	switch e.(type) {
+	case event.WorkerStarted, event.TickCompleted:
+		if p.beats > 0 {
+			p.gap = max(p.gap, h.Time.Sub(p.last))
+		}
+		p.last = h.Time
+		p.beats++
+	case event.WorkerExited:
+		p.exited = true
+	}
+}
+
+// Stale reports whether the worker called name has stopped beating without
+// exiting, as of now: it has been quiet for more than twice the longest gap
+// between its beats so far, or, if it has beaten only once, for longer than
+// the Monitor allows. A nil Monitor reports nothing as stale.
+func (m *Monitor) Stale(name string, now time.Time) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.workers[name]
+	if p == nil || p.exited || p.beats == 0 {
+		return false
+	}
+	limit := m.after
+	if p.beats > 1 {
+		limit = 2 * p.gap
+	}
+	return now.Sub(p.last) > limit
+}
+
+// LastBeat returns when the worker called name last beat, or the zero
+// time if it never has.
+func (m *Monitor) LastBeat(name string) time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p := m.workers[name]; p != nil {
+		return p.last
+	}
+	return time.Time{}
+}
+
+type monitorKey struct{}
+
+// With returns a copy of ctx carrying m.
+func With(ctx context.Context, m *Monitor) context.Context {
+	return context.WithValue(ctx, monitorKey{}, m)
+}
+
+// From returns the Monitor carried by ctx, or nil.
+func From(ctx context.Context) *Monitor {
+	m, _ := ctx.Value(monitorKey{}).(*Monitor)
+	return m
+}
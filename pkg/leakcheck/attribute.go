@@ -0,0 +1,51 @@
+package leakcheck
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Site is a group of leaked goroutines that have the same label and were
+// started by the same go statement.
+type Site struct {
+	// Label names what the goroutines were running, such as a worker type.
+	Label      string
+	CreatedBy  Frame
+	Goroutines []Goroutine
+}
+
+// String summarises s, as in "2 goroutine(s) leaked from *worker.LeakyCauldron,
+// started by scenario.(*Group).Launch at group.go:72".
+func (s Site) String() string {
+	return fmt.Sprintf("%d goroutine(s) leaked from %s, started by %v", len(s.Goroutines), s.Label, s.CreatedBy)
+}
+
+// Attribute groups gs by label and creation site, largest group first.
+// label names what a goroutine was running; where it returns "", the
+// goroutine's Top function is used instead.
+func Attribute(gs []Goroutine, label func(g Goroutine) string) []Site {
+	type key struct {
+		label string
+		site  Frame
+	}
+	index := make(map[key]int)
+	var sites []Site
+	for _, g := range gs {
+		k := key{label(g), g.CreatedBy}
+		if k.label == "" {
+			k.label = g.Top().Func
+		}
+		i, ok := index[k]
+		if !ok {
+			i = len(sites)
+			index[k] = i
+			sites = append(sites, Site{Label: k.label, CreatedBy: k.site})
+		}
+		sites[i].Goroutines = append(sites[i].Goroutines, g)
+	}
+	slices.SortStableFunc(sites, func(a, b Site) int {
+		return cmp.Or(cmp.Compare(len(b.Goroutines), len(a.Goroutines)), cmp.Compare(a.Label, b.Label))
+	})
+	return sites
+}
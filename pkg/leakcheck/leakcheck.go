@@ -0,0 +1,257 @@
+// Package leakcheck measures goroutine leaks instead of taking a worker's
+// word for them.
+//
+// Take a Snapshot of every goroutine's stack before the code under
+// suspicion runs and call Find afterwards: it reports the goroutines that
+// have appeared since and are still there, with the function each is
+// blocked in and the go statement that started it. Tools that want the raw
+// comparison, without Find's filtering and waiting, can Take a second
+// Snapshot and call DiffFrom.
+//
+// Goroutines are told apart by ID, so a snapshot only says something about
+// code that ran alone between it and Find. Anything else started in the
+// meantime, such as an overlapping run, is reported too.
+package leakcheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frame is one call in a goroutine's stack.
+type Frame struct {
+	Func string
+	File string
+	Line int
+}
+
+// String returns the frame as function at file:line, with the file's
+// directory left out.
+func (f Frame) String() string {
+	if f.File == "" {
+		return f.Func
+	}
+	return fmt.Sprintf("%s at %s:%d", f.Func, filepath.Base(f.File), f.Line)
+}
+
+// Goroutine is a goroutine as seen in a stack dump.
+type Goroutine struct {
+	ID int
+	// State is what the goroutine was doing, such as "select" or
+	// "chan receive, 2 minutes".
+	State string
+	// Stack holds the goroutine's calls, innermost first.
+	Stack []Frame
+	// CreatedBy is the go statement that started the goroutine. Its Func
+	// is empty for the main goroutine.
+	CreatedBy Frame
+}
+
+// Top returns the innermost call of g that is not in the runtime, which is
+// usually the code that is stuck.
+func (g Goroutine) Top() Frame {
+	for _, f := range g.Stack {
+		if !strings.HasPrefix(f.Func, "runtime.") {
+			return f
+		}
+	}
+	if len(g.Stack) > 0 {
+		return g.Stack[0]
+	}
+	return Frame{}
+}
+
+// String summarises g on one line.
+func (g Goroutine) String() string {
+	return fmt.Sprintf("goroutine %d [%s] in %s, created by %s", g.ID, g.State, g.Top(), g.CreatedBy)
+}
+
+// Snapshot is the set of goroutines alive at one moment.
+type Snapshot []Goroutine
+
+// Take returns a Snapshot of every goroutine in the process.
+func Take() Snapshot {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return parse(string(buf[:n]))
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// Diff is how the goroutines of a process changed between two snapshots.
+type Diff struct {
+	// Started holds the goroutines in the later snapshot that were not in
+	// the earlier one, in the later snapshot's order.
+	Started []Goroutine
+	// Exited holds the goroutines in the earlier snapshot that are gone
+	// from the later one, in the earlier snapshot's order.
+	Exited []Goroutine
+	// Kept is the number of goroutines in both.
+	Kept int
+}
+
+// DiffFrom compares s with before, an earlier snapshot of the same
+// process. Unlike Find it neither waits nor filters: it reports the two
+// snapshots as they are, for tools and tests to draw their own
+// conclusions.
+func (s Snapshot) DiffFrom(before Snapshot) Diff {
+	now := make(map[int]bool, len(s))
+	for _, g := range s {
+		now[g.ID] = true
+	}
+	was := make(map[int]bool, len(before))
+	var d Diff
+	for _, g := range before {
+		was[g.ID] = true
+		if now[g.ID] {
+			d.Kept++
+		} else {
+			d.Exited = append(d.Exited, g)
+		}
+	}
+	for _, g := range s {
+		if !was[g.ID] {
+			d.Started = append(d.Started, g)
+		}
+	}
+	return d
+}
+
+// Leaked returns the goroutines in d.Started that no filter, including
+// those in Safe, accepts.
+func (d Diff) Leaked(filters ...Filter) []Goroutine {
+	return Without(d.Started, append(append([]Filter(nil), Safe...), filters...)...)
+}
+
+// Filter reports whether a goroutine is known to be safe, so Find should
+// not report it.
+type Filter func(g Goroutine) bool
+
+// IgnoreTop ignores goroutines whose Top function is fn.
+func IgnoreTop(fn string) Filter {
+	return func(g Goroutine) bool { return g.Top().Func == fn }
+}
+
+// IgnoreCreatedBy ignores goroutines started by a go statement in fn.
+func IgnoreCreatedBy(fn string) Filter {
+	return func(g Goroutine) bool { return g.CreatedBy.Func == fn }
+}
+
+// Safe lists goroutines the standard library starts on demand and keeps
+// for the life of the process. Find always applies it.
+var Safe = []Filter{
+	IgnoreTop("os/signal.signal_recv"),
+	IgnoreTop("os/signal.loop"),
+	IgnoreTop("runtime/pprof.profileWriter"),
+	IgnoreTop("runtime.ReadTrace"),
+	IgnoreCreatedBy("runtime/trace.Start"),
+	IgnoreCreatedBy("runtime/pprof.StartCPUProfile"),
+}
+
+// Find reports the goroutines alive now that were not in before and that
+// no filter, including those in Safe, accepts. Goroutines that are on
+// their way out need a moment to finish, so Find checks again until none
+// are left or settle has passed, and reports what is left then.
+func Find(before Snapshot, settle time.Duration, filters ...Filter) []Goroutine {
+	deadline := time.Now().Add(settle)
+	for wait := time.Millisecond; ; wait *= 2 {
+		leaked := Take().DiffFrom(before).Leaked(filters...)
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(min(wait, time.Until(deadline)))
+	}
+}
+
+// Without returns the goroutines in gs that no filter accepts.
+func Without(gs []Goroutine, filters ...Filter) []Goroutine {
+	var out []Goroutine
+	for _, g := range gs {
+		if !ignored(g, filters) {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func ignored(g Goroutine, filters []Filter) bool {
+	for _, f := range filters {
+		if f(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse reads a dump written by runtime.Stack with all set.
+func parse(dump string) Snapshot {
+	var out Snapshot
+	for _, block := range strings.Split(strings.TrimSpace(dump), "\n\n") {
+		lines := strings.Split(block, "\n")
+		g, ok := parseHeader(lines[0])
+		if !ok {
+			continue
+		}
+		for i := 1; i < len(lines); i++ {
+			fn := lines[i]
+			var loc string
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+				loc = lines[i+1]
+				i++
+			}
+			f := parseFrame(fn, loc)
+			if rest, ok := strings.CutPrefix(fn, "created by "); ok {
+				f.Func, _, _ = strings.Cut(rest, " in goroutine ")
+				g.CreatedBy = f
+				continue
+			}
+			g.Stack = append(g.Stack, f)
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+// parseHeader parses a line such as "goroutine 7 [select]:".
+func parseHeader(line string) (Goroutine, bool) {
+	rest, ok := strings.CutPrefix(line, "goroutine ")
+	if !ok {
+		return Goroutine{}, false
+	}
+	id, state, ok := strings.Cut(rest, " [")
+	if !ok {
+		return Goroutine{}, false
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return Goroutine{}, false
+	}
+	return Goroutine{ID: n, State: strings.TrimSuffix(state, "]:")}, true
+}
+
+// parseFrame parses a call line such as "main.f(0x1, ...)" and its location
+// line, such as "\t/src/main.go:12 +0x1d".
+func parseFrame(call, loc string) Frame {
+	var f Frame
+	f.Func = call
+	if i := strings.LastIndex(call, "("); i > 0 && strings.HasSuffix(call, ")") {
+		f.Func = call[:i]
+	}
+	loc = strings.TrimSpace(loc)
+	if i := strings.LastIndex(loc, " +0x"); i >= 0 {
+		loc = loc[:i]
+	}
+	if i := strings.LastIndex(loc, ":"); i >= 0 {
+		if n, err := strconv.Atoi(loc[i+1:]); err == nil {
+			f.File, f.Line = loc[:i], n
+		}
+	}
+	return f
+}
@@ -0,0 +1,196 @@
+package leakcheck
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+const dump = `goroutine 1 [running]:
+main.main()
+	/src/main.go:12 +0x1d
+
+goroutine 7 [chan receive, 2 minutes]:
+runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)
+	/usr/local/go/src/runtime/proc.go:424 +0xce
+github.com/context-demo/pkg/worker.(*LeakyCauldron).Run(0xc000010000, {0x5f1a40, 0xc000020000})
+	/src/pkg/worker/leaky.go:40 +0x65
+created by github.com/context-demo/pkg/scenario.(*Group).Launch in goroutine 1
+	/src/pkg/scenario/group.go:72 +0x8a
+
+goroutine 9 [select]:
+main.wait(...)
+	/src/main.go:30
+created by main.main in goroutine 1
+	/src/main.go:14 +0x25
+`
+
+func TestParse(t *testing.T) {
+	gs := parse(dump)
+	if len(gs) != 3 {
+		t.Fatalf("parse found %d goroutines, want 3", len(gs))
+	}
+
+	main := gs[0]
+	if main.ID != 1 || main.State != "running" || main.CreatedBy.Func != "" {
+		t.Errorf("main goroutine = %+v", main)
+	}
+	if want := (Frame{"main.main", "/src/main.go", 12}); main.Top() != want {
+		t.Errorf("main goroutine Top() = %+v, want %+v", main.Top(), want)
+	}
+
+	leaky := gs[1]
+	if leaky.ID != 7 || leaky.State != "chan receive, 2 minutes" {
+		t.Errorf("goroutine 7 header parsed as ID %d, state %q", leaky.ID, leaky.State)
+	}
+	if len(leaky.Stack) != 2 {
+		t.Fatalf("goroutine 7 has %d frames, want 2", len(leaky.Stack))
+	}
+	if want := (Frame{"runtime.gopark", "/usr/local/go/src/runtime/proc.go", 424}); leaky.Stack[0] != want {
+		t.Errorf("goroutine 7 Stack[0] = %+v, want %+v", leaky.Stack[0], want)
+	}
+	if want := (Frame{"github.com/context-demo/pkg/worker.(*LeakyCauldron).Run", "/src/pkg/worker/leaky.go", 40}); leaky.Top() != want {
+		t.Errorf("goroutine 7 Top() = %+v, want %+v, skipping the runtime", leaky.Top(), want)
+	}
+	if want := (Frame{"github.com/context-demo/pkg/scenario.(*Group).Launch", "/src/pkg/scenario/group.go", 72}); leaky.CreatedBy != want {
+		t.Errorf("goroutine 7 CreatedBy = %+v, want %+v", leaky.CreatedBy, want)
+	}
+
+	// An inlined call has no offset on its location line.
+	if want := (Frame{"main.wait", "/src/main.go", 30}); gs[2].Top() != want {
+		t.Errorf("goroutine 9 Top() = %+v, want %+v", gs[2].Top(), want)
+	}
+	if got, want := gs[2].String(), "goroutine 9 [select] in main.wait at main.go:30, created by main.main at main.go:14"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestTakeListsCallerFirst(t *testing.T) {
+	s := Take()
+	if len(s) == 0 {
+		t.Fatal("Take returned no goroutines")
+	}
+	if !slices.ContainsFunc(s[0].Stack, func(f Frame) bool { return f.Func == "github.com/context-demo/pkg/leakcheck.TestTakeListsCallerFirst" }) {
+		t.Errorf("first goroutine of Take is not the caller: %v", s[0])
+	}
+}
+
+// parkA and parkB block until release is closed. They are separate
+// functions so that the goroutines running them can be told apart by stack.
+func parkA(release <-chan struct{}) { <-release }
+func parkB(release <-chan struct{}) { <-release }
+
+// exitAfter runs for d and returns.
+func exitAfter(d time.Duration) { time.Sleep(d) }
+
+func TestDiffFrom(t *testing.T) {
+	inParkA := IgnoreAnyFunction("github.com/context-demo/pkg/leakcheck.parkA")
+	release := make(chan struct{})
+	before := Take()
+	go parkA(release)
+	waitFor(t, func(s Snapshot) bool { return slices.ContainsFunc(s, inParkA) })
+
+	during := Take()
+	d := during.DiffFrom(before)
+	if !slices.ContainsFunc(d.Started, inParkA) {
+		t.Errorf("DiffFrom did not report the goroutine started since: %+v", d.Started)
+	}
+	if d.Kept == 0 {
+		t.Error("DiffFrom kept no goroutines, not even the caller")
+	}
+
+	close(release)
+	waitFor(t, func(s Snapshot) bool { return !slices.ContainsFunc(s, inParkA) })
+	d = Take().DiffFrom(during)
+	if !slices.ContainsFunc(d.Exited, inParkA) {
+		t.Errorf("DiffFrom did not report the goroutine that exited: %+v", d.Exited)
+	}
+}
+
+func TestFindReportsLeak(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	before := Take()
+	go parkA(release)
+
+	leaked := Find(before, 50*time.Millisecond)
+	if len(leaked) != 1 {
+		t.Fatalf("Find reported %d goroutines, want 1: %v", len(leaked), leaked)
+	}
+	if got, want := leaked[0].Top().Func, "github.com/context-demo/pkg/leakcheck.parkA"; got != want {
+		t.Errorf("Find reported a goroutine in %s, want %s", got, want)
+	}
+	if got, want := leaked[0].CreatedBy.Func, "github.com/context-demo/pkg/leakcheck.TestFindReportsLeak"; got != want {
+		t.Errorf("leaked goroutine created by %s, want %s", got, want)
+	}
+	if leaked := Find(before, 0, IgnoreTop("github.com/context-demo/pkg/leakcheck.parkA")); len(leaked) != 0 {
+		t.Errorf("Find reported a filtered goroutine: %v", leaked)
+	}
+}
+
+func TestFindWaitsForGoroutinesOnTheirWayOut(t *testing.T) {
+	before := Take()
+	go exitAfter(20 * time.Millisecond)
+	if leaked := Find(before, 5*time.Second); len(leaked) != 0 {
+		t.Errorf("Find reported goroutines that exit within the grace period: %v", leaked)
+	}
+}
+
+func TestVerifyAllowlist(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	current := IgnoreCurrent()
+	go parkA(release)
+	go parkB(release)
+
+	err := Verify(current, Settle(20*time.Millisecond), Allow(IgnoreAnyFunction("github.com/context-demo/pkg/leakcheck.parkA")))
+	var lerr *Error
+	if !errors.As(err, &lerr) {
+		t.Fatalf("Verify = %v, want an *Error", err)
+	}
+	if len(lerr.Goroutines) != 1 {
+		t.Fatalf("Verify reported %d goroutines, want 1: %v", len(lerr.Goroutines), err)
+	}
+	if got, want := lerr.Goroutines[0].Top().Func, "github.com/context-demo/pkg/leakcheck.parkB"; got != want {
+		t.Errorf("Verify reported a goroutine in %s, want only the one not allowed, in %s", got, want)
+	}
+
+	err = Verify(current, Settle(0), Allow(
+		IgnoreAnyFunction("github.com/context-demo/pkg/leakcheck.parkA"),
+		IgnoreAnyFunction("github.com/context-demo/pkg/leakcheck.parkB"),
+	))
+	if err != nil {
+		t.Errorf("Verify with both goroutines allowed = %v, want nil", err)
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	gs := parse(dump)
+	sites := Attribute(append(gs[1:], gs[1]), func(g Goroutine) string {
+		if g.ID == 7 {
+			return "*worker.LeakyCauldron"
+		}
+		return ""
+	})
+	if len(sites) != 2 {
+		t.Fatalf("Attribute made %d sites, want 2: %v", len(sites), sites)
+	}
+	if got, want := sites[0].String(), "2 goroutine(s) leaked from *worker.LeakyCauldron, started by github.com/context-demo/pkg/scenario.(*Group).Launch at group.go:72"; got != want {
+		t.Errorf("largest site = %q, want %q", got, want)
+	}
+	if got, want := sites[1].Label, "main.wait"; got != want {
+		t.Errorf("unlabelled site label = %q, want the Top function %q", got, want)
+	}
+}
+
+// waitFor takes snapshots until ok accepts one, failing t after a second.
+func waitFor(t *testing.T, ok func(Snapshot) bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !ok(Take()); {
+		if time.Now().After(deadline) {
+			t.Fatal("goroutines did not reach the expected state")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,141 @@
+package leakcheck
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultSettle is how long Verify and VerifyNone give goroutines on their
+// way out to finish, unless told otherwise with Settle.
+const DefaultSettle = 100 * time.Millisecond
+
+// Error reports goroutines left behind that no filter allowed.
+type Error struct {
+	Goroutines []Goroutine
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Goroutines))
+	for i, g := range e.Goroutines {
+		lines[i] = g.String()
+	}
+	return fmt.Sprintf("%d unexpected goroutine(s):\n\t%s", len(e.Goroutines), strings.Join(lines, "\n\t"))
+}
+
+// IgnoreAnyFunction ignores goroutines with fn anywhere in their stack. It
+// is how an allowlist names a worker that leaks on purpose, whatever it is
+// blocked in.
+func IgnoreAnyFunction(fn string) Filter {
+	return func(g Goroutine) bool {
+		for _, f := range g.Stack {
+			if f.Func == fn {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Option configures Verify, VerifyNone and VerifyTestMain.
+type Option func(*verifyOptions)
+
+type verifyOptions struct {
+	before Snapshot
+	settle time.Duration
+	allow  []Filter
+}
+
+// IgnoreCurrent takes a Snapshot when the option is created and ignores
+// every goroutine in it, so only goroutines started afterwards count.
+// Create it at the start of the code under test:
+//
+//	defer leakcheck.VerifyNone(t, leakcheck.IgnoreCurrent())
+func IgnoreCurrent() Option {
+	before := Take()
+	return func(o *verifyOptions) { o.before = append(o.before, before...) }
+}
+
+// Settle sets how long to wait for goroutines on their way out to finish
+// before reporting them; the default is DefaultSettle.
+func Settle(d time.Duration) Option {
+	return func(o *verifyOptions) { o.settle = d }
+}
+
+// Allow adds filters for goroutines that may be left behind, such as
+// workers that leak on purpose.
+func Allow(filters ...Filter) Option {
+	return func(o *verifyOptions) { o.allow = append(o.allow, filters...) }
+}
+
+// testFramework lists the goroutines of the test framework itself, which are
+// alive around every test and are not leaks.
+var testFramework = []Filter{
+	IgnoreCreatedBy(""), // the main goroutine
+	IgnoreCreatedBy("testing.(*T).Run"),
+	IgnoreCreatedBy("testing.runFuzzTests"),
+	IgnoreCreatedBy("testing.runFuzzing"),
+	IgnoreTop("testing.(*M).Run"),
+	IgnoreTop("testing.tRunner"),
+}
+
+// Verify is Find as an error, in the manner of go.uber.org/goleak: it
+// returns an *Error listing the goroutines alive that neither Safe, the
+// goroutines of the test framework nor an Allow filter accepts, or nil if
+// there are none. The calling goroutine is never reported.
+func Verify(opts ...Option) error {
+	o := verifyOptions{settle: DefaultSettle}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	self := Take()[0] // runtime.Stack lists the caller first
+	before := append(o.before, self)
+	filters := append(append([]Filter(nil), testFramework...), o.allow...)
+	if leaked := Find(before, o.settle, filters...); len(leaked) > 0 {
+		return &Error{Goroutines: leaked}
+	}
+	return nil
+}
+
+// TestingT is the part of testing.TB that VerifyNone uses.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// VerifyNone fails t if Verify reports goroutines left behind. Defer it at
+// the start of a test, with IgnoreCurrent if goroutines started by earlier
+// tests or package init are still around:
+//
+//	defer leakcheck.VerifyNone(t, leakcheck.IgnoreCurrent(),
+//		leakcheck.Allow(contextdemo.IntentionalLeaks...))
+func VerifyNone(t TestingT, opts ...Option) {
+	t.Helper()
+	if err := Verify(opts...); err != nil {
+		t.Errorf("leakcheck: %v", err)
+	}
+}
+
+// TestingM is the part of testing.M that VerifyTestMain uses.
+type TestingM interface {
+	Run() int
+}
+
+// VerifyTestMain runs the tests in m and then checks the whole package for
+// leaks with Verify, failing the test binary if any are found. Call it from
+// TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		leakcheck.VerifyTestMain(m)
+//	}
+func VerifyTestMain(m TestingM, opts ...Option) {
+	code := m.Run()
+	if code == 0 {
+		if err := Verify(opts...); err != nil {
+			fmt.Fprintf(os.Stderr, "leakcheck: errors on successful test run: %v\n", err)
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
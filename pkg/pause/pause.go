@@ -0,0 +1,119 @@
+// Package pause lets workers be suspended and resumed without being
+// cancelled.
+//
+// A context can only be cancelled once, and for good. A Switch, carried by
+// the context alongside it, adds a state that comes and goes: while the
+// switch is paused, workers that watch it stop taking new work, and when it
+// is resumed they carry on where they left off. Cancellation still wins:
+// a paused worker whose context is cancelled should return at once.
+//
+// Workers watch the switch the way they watch ctx.Done():
+//
+//	select {
+//	case <-pause.Paused(ctx):
+//		if err := pause.Wait(ctx); err != nil {
+//			return err // cancelled while paused
+//		}
+//	case <-ctx.Done():
+//		return context.Cause(ctx)
+//	case <-ticker.C():
+//		// work
+//	}
+package pause
+
+import (
+	"context"
+	"sync"
+)
+
+// Switch pauses and resumes the workers beneath the context it was made
+// with. It starts out running.
+type Switch struct {
+	mu      sync.Mutex
+	paused  chan struct{} // closed while paused
+	resumed chan struct{} // closed while running
+}
+
+type switchKey struct{}
+
+// With returns a copy of parent carrying a new Switch, and the switch.
+func With(parent context.Context) (context.Context, *Switch) {
+	s := &Switch{paused: make(chan struct{}), resumed: make(chan struct{})}
+	close(s.resumed)
+	return context.WithValue(parent, switchKey{}, s), s
+}
+
+// Pause suspends the workers. It is a no-op while already paused.
+func (s *Switch) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isPaused() {
+		return
+	}
+	close(s.paused)
+	s.resumed = make(chan struct{})
+}
+
+// Resume lets the workers carry on. It is a no-op while running.
+func (s *Switch) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isPaused() {
+		return
+	}
+	close(s.resumed)
+	s.paused = make(chan struct{})
+}
+
+// IsPaused reports whether the switch is paused.
+func (s *Switch) IsPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isPaused()
+}
+
+// isPaused is IsPaused with s.mu held.
+func (s *Switch) isPaused() bool {
+	select {
+	case <-s.paused:
+		return true
+	default:
+		return false
+	}
+}
+
+// channels returns the switch's current channels.
+func (s *Switch) channels() (paused, resumed <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused, s.resumed
+}
+
+// Paused returns a channel that is closed once the Switch carried by ctx is
+// paused. Without a switch it returns nil, which never becomes ready in a
+// select, so workers can watch it unconditionally. Each pause needs a fresh
+// call: after a resume, the channel returned earlier stays closed.
+func Paused(ctx context.Context) <-chan struct{} {
+	if s, ok := ctx.Value(switchKey{}).(*Switch); ok {
+		paused, _ := s.channels()
+		return paused
+	}
+	return nil
+}
+
+// Wait blocks while the Switch carried by ctx is paused. It returns nil
+// once the switch is resumed, or at once if it is running or ctx carries
+// none, and the context's cause if ctx is cancelled first.
+func Wait(ctx context.Context) error {
+	s, ok := ctx.Value(switchKey{}).(*Switch)
+	if !ok {
+		return nil
+	}
+	_, resumed := s.channels()
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
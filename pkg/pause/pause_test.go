@@ -0,0 +1,131 @@
+package pause
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+func TestPauseResume(t *testing.T) {
+	ctx, s := With(context.Background())
+	if s.IsPaused() || Paused(ctx) == nil {
+		t.Fatal("a new switch is paused, or has no channel to watch")
+	}
+	if err := Wait(ctx); err != nil {
+		t.Fatalf("Wait on a running switch = %v", err)
+	}
+
+	s.Pause()
+	s.Pause() // a no-op
+	paused := Paused(ctx)
+	select {
+	case <-paused:
+	default:
+		t.Fatal("Paused(ctx) is not closed after Pause")
+	}
+	done := make(chan error)
+	go func() { done <- Wait(ctx) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v while paused", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.Resume()
+	s.Resume() // a no-op
+	if err := <-done; err != nil {
+		t.Fatalf("Wait after Resume = %v", err)
+	}
+	select {
+	case <-Paused(ctx):
+		t.Error("a fresh Paused(ctx) is closed after Resume")
+	default:
+	}
+	select {
+	case <-paused:
+	default:
+		t.Error("the channel returned before Resume reopened")
+	}
+}
+
+func TestPauseThenCancel(t *testing.T) {
+	errStop := errors.New("shutting down")
+	ctx, s := With(context.Background())
+	ctx, cancel := context.WithCancelCause(ctx)
+	s.Pause()
+
+	done := make(chan error)
+	go func() { done <- Wait(ctx) }()
+	cancel(errStop)
+	if err := <-done; !errors.Is(err, errStop) {
+		t.Errorf("Wait when cancelled while paused = %v, want %v", err, errStop)
+	}
+}
+
+func TestWithoutSwitch(t *testing.T) {
+	ctx := context.Background()
+	if Paused(ctx) != nil {
+		t.Error("Paused without a switch is not nil")
+	}
+	if err := Wait(ctx); err != nil {
+		t.Errorf("Wait without a switch = %v", err)
+	}
+}
+
+// TestWorkerLoop drives the loop of the package documentation on a fake
+// clock through a pause, a resume, and a cancellation while paused.
+func TestWorkerLoop(t *testing.T) {
+	errStop := errors.New("shutting down")
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, s := With(clock.With(context.Background(), f))
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	events := make(chan string)
+	done := make(chan error)
+	go func() {
+		ticker := clock.From(ctx).NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-Paused(ctx):
+				events <- "paused"
+				if err := Wait(ctx); err != nil {
+					done <- err
+					return
+				}
+				events <- "resumed"
+			case <-ctx.Done():
+				done <- context.Cause(ctx)
+				return
+			case <-ticker.C():
+				events <- "tick"
+			}
+		}
+	}()
+	expect := func(want string) {
+		t.Helper()
+		if got := <-events; got != want {
+			t.Fatalf("worker reported %q, want %q", got, want)
+		}
+	}
+
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	expect("tick")
+	s.Pause()
+	expect("paused")
+	f.Advance(5 * time.Second) // no work while paused; the ticker keeps one tick
+	s.Resume()
+	expect("resumed")
+	expect("tick")
+
+	s.Pause()
+	expect("paused")
+	cancel(errStop)
+	if err := <-done; !errors.Is(err, errStop) {
+		t.Errorf("worker paused when cancelled returned %v, want %v", err, errStop)
+	}
+}
@@ -0,0 +1,231 @@
+// Package pool runs tasks on a fixed number of goroutines, with a bounded
+// queue in front of them.
+//
+// Submit hands a task to the pool, waiting while every goroutine is busy
+// and the queue is full, for as long as the submitter's context allows:
+//
+//	p := pool.New(ctx, 4, 8, pool.Drain)
+//	for _, job := range jobs {
+//		if err := p.Submit(ctx, job); err != nil {
+//			break // ctx is done, or the pool is closed
+//		}
+//	}
+//	p.Close()
+//	err := p.Wait()
+//
+// When the context given to New is cancelled the pool stops accepting
+// tasks, and its Policy decides what happens to those it has accepted: a
+// Drain pool runs them to completion, an Abort pool cancels the running
+// ones with the context's cause and drops those still queued.
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by Submit once the pool accepts no more tasks.
+var ErrClosed = errors.New("pool: closed")
+
+// Task is a unit of work run by a Pool.
+type Task func(ctx context.Context) error
+
+// Policy is what a Pool does with the tasks it has accepted when its
+// context is cancelled.
+type Policy int
+
+// The policies.
+const (
+	// Drain runs every accepted task to completion, under a context that
+	// keeps the pool's values but not its cancellation.
+	Drain Policy = iota
+	// Abort cancels the running tasks with the cause of the pool's
+	// context and drops the queued ones unrun.
+	Abort
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Drain:
+		return "drain"
+	case Abort:
+		return "abort"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// Pool runs submitted tasks on a fixed number of goroutines.
+type Pool struct {
+	policy Policy
+	tasks  chan Task
+
+	// ctx is the context tasks run with, and abort cancels it.
+	ctx   context.Context
+	abort context.CancelCauseFunc
+	stop  func() bool // stops the context.AfterFunc watching the parent
+
+	mu        sync.Mutex
+	closing   bool
+	closed    chan struct{} // closed once no more tasks are accepted
+	senders   sync.WaitGroup
+	workers   sync.WaitGroup
+	errs      []error
+	closeOnce sync.Once
+
+	completed, failed, dropped, running atomic.Int64
+}
+
+// Stats is what has happened to a pool's tasks so far.
+type Stats struct {
+	// Completed counts the tasks that returned nil, and Failed those that
+	// returned an error. Dropped counts the accepted tasks that an abort
+	// kept from running.
+	Completed, Failed, Dropped int64
+	// Running is the number of tasks in flight.
+	Running int64
+}
+
+// New starts a pool of size goroutines, which queues up to queue submitted
+// tasks while they are all busy, and which reacts to the cancellation of
+// ctx as policy says. A size less than one is taken as one.
+func New(ctx context.Context, size, queue int, policy Policy) *Pool {
+	p := &Pool{
+		policy: policy,
+		tasks:  make(chan Task, max(queue, 0)),
+		closed: make(chan struct{}),
+	}
+	base := ctx
+	if policy == Drain {
+		base = context.WithoutCancel(ctx)
+	}
+	p.ctx, p.abort = context.WithCancelCause(base)
+	p.stop = context.AfterFunc(ctx, func() {
+		if policy == Abort {
+			p.Abort(context.Cause(ctx))
+			return
+		}
+		p.Close()
+	})
+	for range max(size, 1) {
+		p.workers.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit hands task to the pool. It waits while the pool is saturated and
+// returns nil once the task is accepted, the cause of ctx if ctx is done
+// first, or ErrClosed if the pool closes first.
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	p.senders.Add(1)
+	p.mu.Unlock()
+	defer p.senders.Done()
+
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-p.closed:
+		return ErrClosed
+	}
+}
+
+// Close stops the pool accepting tasks. Those it has accepted still run;
+// Wait waits for them. Close may be called more than once.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		p.closing = true
+		p.mu.Unlock()
+		close(p.closed)
+		go func() {
+			// No Submit still waiting can send once every one has returned.
+			p.senders.Wait()
+			close(p.tasks)
+		}()
+	})
+}
+
+// Abort closes the pool, cancels its running tasks with cause and drops
+// its queued ones, whatever its Policy. It lets a caller cut short a
+// drain that is taking too long.
+func (p *Pool) Abort(cause error) {
+	p.Close()
+	p.abort(cause)
+}
+
+// Closed returns a channel that is closed once the pool accepts no more
+// tasks.
+func (p *Pool) Closed() <-chan struct{} { return p.closed }
+
+// Wait waits until the pool is closed, by Close, Abort or the cancellation
+// of its context, and every task it accepted has run or been dropped. It
+// returns the errors of the tasks that failed, joined in the order they
+// happened, or nil if none did.
+func (p *Pool) Wait() error {
+	p.workers.Wait()
+	p.stop()
+	p.abort(nil)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return errors.Join(p.errs...)
+}
+
+// Stats returns what has happened to the pool's tasks so far.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Dropped:   p.dropped.Load(),
+		Running:   p.running.Load(),
+	}
+}
+
+// work runs queued tasks until the queue is closed and empty, or until the
+// pool is aborted, when it drops the rest.
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for {
+		select {
+		case task, ok := <-p.tasks:
+			if !ok {
+				return
+			}
+			if p.ctx.Err() != nil {
+				p.dropped.Add(1)
+				continue
+			}
+			p.run(task)
+		case <-p.ctx.Done():
+			for range p.tasks {
+				p.dropped.Add(1)
+			}
+			return
+		}
+	}
+}
+
+// run runs task and records how it ended.
+func (p *Pool) run(task Task) {
+	p.running.Add(1)
+	err := task(p.ctx)
+	p.running.Add(-1)
+	if err == nil {
+		p.completed.Add(1)
+		return
+	}
+	p.failed.Add(1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs = append(p.errs, err)
+}
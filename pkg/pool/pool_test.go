@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// blocker is a task that signals when it starts and returns once released,
+// reporting the cause of its context if that is done first.
+type blocker struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlocker() *blocker {
+	return &blocker{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *blocker) run(ctx context.Context) error {
+	close(b.started)
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// fill starts a pool of one goroutine, busy with a blocker, with queued
+// tasks waiting behind it, and returns the pool and the blocker.
+func fill(t *testing.T, ctx context.Context, queued int, policy Policy) (*Pool, *blocker) {
+	t.Helper()
+	p := New(ctx, 1, queued, policy)
+	b := newBlocker()
+	if err := p.Submit(ctx, b.run); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	<-b.started
+	for range queued {
+		if err := p.Submit(ctx, func(context.Context) error { return nil }); err != nil {
+			t.Fatalf("Submit = %v", err)
+		}
+	}
+	return p, b
+}
+
+func TestDrainRunsAcceptedTasksAfterCancel(t *testing.T) {
+	errStop := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	p, b := fill(t, ctx, 2, Drain)
+
+	cancel(errStop)
+	<-p.Closed()
+	if err := p.Submit(context.Background(), func(context.Context) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after cancel = %v, want %v", err, ErrClosed)
+	}
+	close(b.release)
+	if err := p.Wait(); err != nil {
+		t.Fatalf("Wait = %v, want nil: a drained task must not see the cancellation", err)
+	}
+	if st := p.Stats(); st.Completed != 3 || st.Dropped != 0 {
+		t.Errorf("Stats() = %+v, want all 3 tasks completed", st)
+	}
+}
+
+func TestAbortCancelsRunningAndDropsQueued(t *testing.T) {
+	errStop := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	p, _ := fill(t, ctx, 2, Abort)
+
+	cancel(errStop)
+	err := p.Wait()
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Wait = %v, want the running task's %v", err, errStop)
+	}
+	if st := p.Stats(); st.Failed != 1 || st.Dropped != 2 || st.Completed != 0 {
+		t.Errorf("Stats() = %+v, want 1 failed and 2 dropped", st)
+	}
+}
+
+func TestAbortCutsDrainShort(t *testing.T) {
+	errLate := errors.New("drain took too long")
+	ctx, cancel := context.WithCancel(context.Background())
+	p, _ := fill(t, ctx, 1, Drain)
+
+	cancel()
+	p.Abort(errLate)
+	if err := p.Wait(); !errors.Is(err, errLate) {
+		t.Fatalf("Wait = %v, want %v", err, errLate)
+	}
+	if st := p.Stats(); st.Dropped != 1 {
+		t.Errorf("Stats() = %+v, want the queued task dropped", st)
+	}
+}
+
+func TestSubmitWaitsWhileSaturated(t *testing.T) {
+	errGone := errors.New("caller gave up")
+	p, b := fill(t, context.Background(), 1, Drain)
+
+	sctx, cancel := context.WithCancelCause(context.Background())
+	done := make(chan error)
+	go func() { done <- p.Submit(sctx, func(context.Context) error { return nil }) }()
+	cancel(errGone)
+	if err := <-done; !errors.Is(err, errGone) {
+		t.Errorf("Submit to a saturated pool after cancel = %v, want %v", err, errGone)
+	}
+
+	close(b.release)
+	p.Close()
+	if err := p.Wait(); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if st := p.Stats(); st.Completed != 2 {
+		t.Errorf("Stats() = %+v, want the 2 accepted tasks completed", st)
+	}
+}
+
+func TestWaitJoinsTaskErrors(t *testing.T) {
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	ctx := context.Background()
+	p := New(ctx, 1, 2, Drain)
+	p.Submit(ctx, func(context.Context) error { return errA })
+	p.Submit(ctx, func(context.Context) error { return nil })
+	p.Submit(ctx, func(context.Context) error { return errB })
+	p.Close()
+	p.Close() // a second Close is harmless
+
+	err := p.Wait()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("Wait = %v, want both %v and %v", err, errA, errB)
+	}
+	if st := p.Stats(); st.Completed != 1 || st.Failed != 2 {
+		t.Errorf("Stats() = %+v, want 1 completed and 2 failed", st)
+	}
+}
@@ -0,0 +1,108 @@
+// Package queue provides a bounded FIFO queue whose Push and Pop wait for
+// room or for an item only as long as their context allows.
+//
+// A full queue pushes back on its producers: Push waits until a consumer
+// makes room, and a producer that would rather do something else than
+// wait bounds the wait with a deadline and backs off when it passes:
+//
+//	pctx, cancel := clock.WithTimeout(ctx, patience)
+//	err := q.Push(pctx, letter)
+//	cancel()
+//	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+//		// the queue stayed full; back off and try again
+//	}
+//
+// Cancelling the context of a blocked Push or Pop unblocks it at once, so
+// producers and consumers alike unwind with their context.
+package queue
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClosed is returned by Push once the queue is closed, and by Pop once
+// it is closed and empty.
+var ErrClosed = errors.New("queue: closed")
+
+// Queue is a bounded FIFO queue of T, safe for concurrent use.
+type Queue[T any] struct {
+	items  chan T
+	closed chan struct{}
+}
+
+// New returns an empty queue that holds up to capacity items. A capacity
+// less than one is taken as one.
+func New[T any](capacity int) *Queue[T] {
+	return &Queue[T]{
+		items:  make(chan T, max(capacity, 1)),
+		closed: make(chan struct{}),
+	}
+}
+
+// Push adds v to the back of the queue, waiting while it is full. It
+// returns nil once v is queued, the cause of ctx if ctx is done first, or
+// ErrClosed if the queue is closed first.
+func (q *Queue[T]) Push(ctx context.Context, v T) error {
+	select {
+	case <-q.closed:
+		return ErrClosed
+	default:
+	}
+	select {
+	case q.items <- v:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-q.closed:
+		return ErrClosed
+	}
+}
+
+// TryPush adds v to the back of the queue if there is room, without
+// waiting, and reports whether it did.
+func (q *Queue[T]) TryPush(v T) bool {
+	select {
+	case <-q.closed:
+		return false
+	default:
+	}
+	select {
+	case q.items <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pop removes and returns the item at the front of the queue, waiting while
+// it is empty. It returns the cause of ctx if ctx is done first, or
+// ErrClosed if the queue is closed and empty. Items queued before Close
+// are still popped.
+func (q *Queue[T]) Pop(ctx context.Context) (T, error) {
+	select {
+	case v := <-q.items:
+		return v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, context.Cause(ctx)
+	case <-q.closed:
+		select {
+		case v := <-q.items:
+			return v, nil
+		default:
+			var zero T
+			return zero, ErrClosed
+		}
+	}
+}
+
+// Close stops the queue accepting items and wakes every Push waiting for
+// room. It must be called at most once.
+func (q *Queue[T]) Close() { close(q.closed) }
+
+// Len returns the number of items queued.
+func (q *Queue[T]) Len() int { return len(q.items) }
+
+// Cap returns the most items the queue holds.
+func (q *Queue[T]) Cap() int { return cap(q.items) }
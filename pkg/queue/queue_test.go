@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+func TestPushPopInOrder(t *testing.T) {
+	ctx := context.Background()
+	q := New[int](3)
+	for i := range 3 {
+		if err := q.Push(ctx, i); err != nil {
+			t.Fatalf("Push(%d) = %v", i, err)
+		}
+	}
+	if q.TryPush(3) {
+		t.Error("TryPush succeeded on a full queue")
+	}
+	for want := range 3 {
+		if got, err := q.Pop(ctx); err != nil || got != want {
+			t.Fatalf("Pop() = %d, %v, want %d, nil", got, err, want)
+		}
+	}
+	if n := q.Len(); n != 0 {
+		t.Errorf("Len() = %d after popping everything, want 0", n)
+	}
+}
+
+func TestPushToFullQueueBacksOffAtDeadline(t *testing.T) {
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := clock.With(context.Background(), f)
+	q := New[string](1)
+	q.Push(ctx, "first")
+
+	pctx, cancel := clock.WithTimeout(ctx, time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- q.Push(pctx, "second") }()
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Push to a full queue past its deadline = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := q.Len(); n != 1 {
+		t.Errorf("Len() = %d, want 1: the timed-out item must not be queued", n)
+	}
+}
+
+func TestPopReturnsCauseOnCancel(t *testing.T) {
+	errStop := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	q := New[int](1)
+
+	done := make(chan error)
+	go func() {
+		_, err := q.Pop(ctx)
+		done <- err
+	}()
+	cancel(errStop)
+	if err := <-done; !errors.Is(err, errStop) {
+		t.Errorf("Pop on an empty queue after cancel = %v, want %v", err, errStop)
+	}
+}
+
+func TestCloseWakesBlockedPop(t *testing.T) {
+	q := New[int](1)
+	done := make(chan error)
+	go func() {
+		_, err := q.Pop(context.Background())
+		done <- err
+	}()
+	q.Close()
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("Pop blocked on an empty queue when it was closed = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestCloseWakesBlockedPush(t *testing.T) {
+	q := New[int](1)
+	q.Push(context.Background(), 1)
+	done := make(chan error)
+	go func() { done <- q.Push(context.Background(), 2) }()
+	q.Close()
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("Push blocked on a full queue when it was closed = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestDrainAfterClose(t *testing.T) {
+	ctx := context.Background()
+	q := New[int](3)
+	q.Push(ctx, 1)
+	q.Push(ctx, 2)
+	q.Close()
+
+	if err := q.Push(ctx, 3); !errors.Is(err, ErrClosed) {
+		t.Errorf("Push after Close = %v, want %v", err, ErrClosed)
+	}
+	if q.TryPush(3) {
+		t.Error("TryPush after Close succeeded")
+	}
+	for want := 1; want <= 2; want++ {
+		if got, err := q.Pop(ctx); err != nil || got != want {
+			t.Fatalf("Pop() after Close = %d, %v, want %d, nil", got, err, want)
+		}
+	}
+	if _, err := q.Pop(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("Pop() on a closed, drained queue = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,192 @@
+// Package ratelimit provides a token-bucket rate limiter whose Wait gives
+// up as soon as its context is done.
+//
+// A Limiter lets events happen at up to Limit per second on average, in
+// bursts of up to Burst. It keeps no goroutine or ticker of its own: the
+// bucket is brought up to date on the clock carried by each call's context
+// whenever it is used, so a Limiter may be shared by workers under
+// different contexts. ctxutil.Throttle binds one to a single context, for a
+// worker whose limit should end with it.
+//
+//	lim := ratelimit.New(10, 1) // 10 events a second
+//	for {
+//		if err := lim.Wait(ctx); err != nil {
+//			return err // the cause of ctx, or ErrWouldExceedDeadline
+//		}
+//		// ...
+//	}
+//
+// Waiters are served in the order they called Wait: each takes the next
+// token due, and a waiter whose context is done gives its token back.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// ErrWouldExceedDeadline is returned by Wait, at once, when the token it
+// would wait for is not due until after the context's deadline.
+var ErrWouldExceedDeadline = errors.New("ratelimit: wait would exceed the context deadline")
+
+// Limit is a rate of events per second.
+type Limit float64
+
+// Inf is the Limit that allows every event at once; Burst is ignored.
+const Inf = Limit(math.MaxFloat64)
+
+// Every returns the Limit of one event every interval. An interval of zero
+// or less is Inf.
+func Every(interval time.Duration) Limit {
+	if interval <= 0 {
+		return Inf
+	}
+	return 1 / Limit(interval.Seconds())
+}
+
+// durationFor returns how long the limit takes to make tokens tokens, and
+// false if it never does.
+func (l Limit) durationFor(tokens float64) (time.Duration, bool) {
+	if l <= 0 {
+		return 0, false
+	}
+	return time.Duration(tokens / float64(l) * float64(time.Second)), true
+}
+
+// Limiter is a token bucket holding up to Burst tokens and gaining Limit
+// of them a second.
+type Limiter struct {
+	limit Limit
+	burst int
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time // when tokens was last brought up to date; zero before first use
+	waiting int
+}
+
+// New returns a Limiter of limit events a second in bursts of up to burst,
+// which starts full. A burst below 1 is taken as 1.
+func New(limit Limit, burst int) *Limiter {
+	return &Limiter{limit: limit, burst: max(burst, 1)}
+}
+
+// Limit returns the limiter's rate.
+func (l *Limiter) Limit() Limit { return l.limit }
+
+// Burst returns the most tokens the limiter holds.
+func (l *Limiter) Burst() int { return l.burst }
+
+// Waiting returns the number of calls of Wait waiting for a token.
+func (l *Limiter) Waiting() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waiting
+}
+
+// Allow is AllowN(ctx, 1).
+func (l *Limiter) Allow(ctx context.Context) bool {
+	return l.AllowN(ctx, 1)
+}
+
+// AllowN takes n tokens if they are there now, on the clock carried by ctx,
+// and reports whether it did. It never waits, and never takes a token a
+// caller of Wait is waiting for.
+func (l *Limiter) AllowN(ctx context.Context, n int) bool {
+	if l.limit == Inf {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(clock.From(ctx).Now())
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// Wait is WaitN(ctx, 1).
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN waits until n tokens are due and takes them. It returns the cause
+// of ctx if ctx is done first, having given the tokens back, and returns
+// ErrWouldExceedDeadline at once, taking nothing, if they are not due
+// until after the deadline of ctx. It fails if n exceeds the burst.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l.limit == Inf {
+		return nil
+	}
+	if n > l.burst {
+		return fmt.Errorf("ratelimit: WaitN(n=%d) exceeds the burst of %d", n, l.burst)
+	}
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	clk := clock.From(ctx)
+	l.mu.Lock()
+	now := clk.Now()
+	l.advance(now)
+	l.tokens -= float64(n)
+	var wait time.Duration
+	forever := false
+	if l.tokens < 0 {
+		var ok bool
+		wait, ok = l.limit.durationFor(-l.tokens)
+		forever = !ok
+	}
+	if deadline, ok := ctx.Deadline(); ok && (forever || now.Add(wait).After(deadline)) {
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ErrWouldExceedDeadline
+	}
+	if wait <= 0 && !forever {
+		l.mu.Unlock()
+		return nil
+	}
+	l.waiting++
+	l.mu.Unlock()
+
+	var due <-chan time.Time // nil, and never ready, if the tokens never come
+	if !forever {
+		t := clk.NewTimer(wait)
+		defer t.Stop()
+		due = t.C()
+	}
+	select {
+	case <-due:
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.waiting--
+		l.advance(clk.Now())
+		l.tokens = min(l.tokens+float64(n), float64(l.burst))
+		l.mu.Unlock()
+		return context.Cause(ctx)
+	}
+}
+
+// advance adds the tokens made since l.last, up to the burst, and moves
+// l.last to now. The first call fills the bucket. l.mu must be held.
+func (l *Limiter) advance(now time.Time) {
+	if l.last.IsZero() {
+		l.tokens, l.last = float64(l.burst), now
+		return
+	}
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.tokens+elapsed.Seconds()*float64(l.limit), float64(l.burst))
+		l.last = now
+	}
+}
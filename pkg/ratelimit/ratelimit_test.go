@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// fakeContext returns a context carrying a fake clock, and the clock.
+func fakeContext(t *testing.T) (context.Context, *clock.Fake) {
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(clock.With(context.Background(), f))
+	t.Cleanup(cancel)
+	return ctx, f
+}
+
+func TestWaitTakesTokensAsTheyAreMade(t *testing.T) {
+	ctx, f := fakeContext(t)
+	lim := New(Every(time.Second), 2)
+
+	for i := range 2 {
+		if err := lim.Wait(ctx); err != nil {
+			t.Fatalf("Wait %d of the burst: %v", i+1, err)
+		}
+	}
+	done := make(chan error)
+	go func() { done <- lim.Wait(ctx) }()
+	f.BlockUntil(1)
+	if n := lim.Waiting(); n != 1 {
+		t.Errorf("Waiting() = %d, want 1", n)
+	}
+
+	f.Advance(999 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v before its token was due", err)
+	default:
+	}
+	f.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait = %v once its token was due", err)
+	}
+}
+
+func TestCancelledWaitGivesItsTokenBack(t *testing.T) {
+	ctx, f := fakeContext(t)
+	lim := New(Every(time.Second), 1)
+	if !lim.Allow(ctx) {
+		t.Fatal("a new limiter refused its first token")
+	}
+
+	errGone := errors.New("client went away")
+	wctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan error)
+	go func() { done <- lim.Wait(wctx) }()
+	f.BlockUntil(1)
+	cancel(errGone)
+	if err := <-done; !errors.Is(err, errGone) {
+		t.Fatalf("cancelled Wait = %v, want its cause %v", err, errGone)
+	}
+	if n := lim.Waiting(); n != 0 {
+		t.Errorf("Waiting() = %d after the waiter gave up, want 0", n)
+	}
+
+	// Had the token stayed reserved, the bucket would be empty a second on.
+	f.Advance(time.Second)
+	if !lim.Allow(ctx) {
+		t.Error("the token made in the next second went to the waiter that gave up")
+	}
+}
+
+func TestAllowDoesNotTakeReservedTokens(t *testing.T) {
+	ctx, f := fakeContext(t)
+	lim := New(2, 2)
+	if !lim.AllowN(ctx, 2) {
+		t.Fatal("a new limiter refused its burst")
+	}
+
+	done := make(chan error)
+	go func() { done <- lim.WaitN(ctx, 2) }()
+	f.BlockUntil(1)
+
+	f.Advance(500 * time.Millisecond) // one token made, and reserved
+	if lim.Allow(ctx) {
+		t.Error("Allow took a token a waiter had reserved")
+	}
+	f.Advance(500 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("WaitN = %v once its tokens were due", err)
+	}
+	if lim.Allow(ctx) {
+		t.Error("Allow took a token after the waiter was served both")
+	}
+}
+
+func TestWaitWouldExceedDeadline(t *testing.T) {
+	ctx, f := fakeContext(t)
+	lim := New(Every(time.Second), 1)
+	lim.Allow(ctx)
+
+	short, cancel := clock.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	if err := lim.Wait(short); !errors.Is(err, ErrWouldExceedDeadline) {
+		t.Fatalf("Wait with 500ms left for a token due in 1s = %v, want %v", err, ErrWouldExceedDeadline)
+	}
+	if n := lim.Waiting(); n != 0 {
+		t.Errorf("Waiting() = %d, want 0: Wait should give up at once", n)
+	}
+
+	// The refused call took nothing: a second on there is a whole token.
+	f.Advance(time.Second)
+	if !lim.Allow(ctx) {
+		t.Fatal("the refused Wait kept its token")
+	}
+
+	long, cancel := clock.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- lim.Wait(long) }()
+	f.BlockUntil(2) // the deadline's timer and the token's
+	f.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Wait with 2s left for a token due in 1s = %v, want nil", err)
+	}
+}
+
+func TestWaitNBeyondBurst(t *testing.T) {
+	ctx, _ := fakeContext(t)
+	lim := New(1, 3)
+	if err := lim.WaitN(ctx, 4); err == nil {
+		t.Error("WaitN(4) with a burst of 3 succeeded")
+	}
+	if !lim.AllowN(ctx, 3) {
+		t.Error("the failed WaitN took tokens")
+	}
+}
+
+func TestInfAllowsEverything(t *testing.T) {
+	ctx, _ := fakeContext(t)
+	lim := New(Inf, 1)
+	for range 100 {
+		if !lim.Allow(ctx) {
+			t.Fatal("Allow refused under Inf")
+		}
+	}
+	if err := lim.WaitN(ctx, 10); err != nil {
+		t.Errorf("WaitN(10) under Inf = %v, want nil", err)
+	}
+}
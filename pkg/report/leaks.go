@@ -0,0 +1,144 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/leakcheck"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Verdicts of a leak analysis, from best to worst.
+const (
+	VerdictUnchecked  = "unchecked"   // goroutines were not measured; see Leaks.Workers
+	VerdictClean      = "clean"       // the run left no goroutines behind
+	VerdictLeaked     = "leaked"      // it left some, and nothing said it must not
+	VerdictUnexpected = "unexpected"  // it left goroutines -verify-leaks did not allow
+	VerdictOverBudget = "over_budget" // it left more than -max-leaked allows
+)
+
+// Leaks is the leak analysis of one run, laid out for machines: CI jobs
+// read it from a -report file ending in .json, or from the leak_report
+// record that -json adds to the event stream after each run.
+type Leaks struct {
+	// Event is always "leak_report", so the analysis can share a stream
+	// with event records.
+	Event    string `json:"event"`
+	Scenario string `json:"scenario"`
+	Seed     uint64 `json:"seed"`
+	Verdict  string `json:"verdict"`
+	// Error is the error the run ended with, if any, such as a broken
+	// leak budget.
+	Error string `json:"error,omitempty"`
+	// Workers lists the workers that had not exited when the scenario
+	// ended.
+	Workers []LeakedWorker `json:"leaked_workers"`
+	// Goroutines lists the goroutines measured as left behind, grouped by
+	// Sites; both are empty unless the run was leak checked.
+	Goroutines  []LeakedGoroutine `json:"goroutines"`
+	Sites       []LeakSite        `json:"sites"`
+	Timers      []string          `json:"unstopped_timers"`
+	Uncancelled []string          `json:"uncancelled_contexts"`
+}
+
+// LeakedWorker is a worker that was still running when its scenario ended.
+type LeakedWorker struct {
+	Name      string `json:"name"`
+	Processed int64  `json:"processed"`
+	// Observed reports that it saw cancellation and was still shutting
+	// down; Blocked that it had stopped reporting ticks.
+	Observed bool `json:"observed"`
+	Blocked  bool `json:"blocked"`
+}
+
+// LeakedGoroutine is a goroutine left behind by a run.
+type LeakedGoroutine struct {
+	ID        int      `json:"id"`
+	State     string   `json:"state"`
+	Top       string   `json:"top"`
+	CreatedBy string   `json:"created_by"`
+	Stack     []string `json:"stack"`
+}
+
+// LeakSite is a group of leaked goroutines with the same label and
+// creation site; see leakcheck.Site.
+type LeakSite struct {
+	Label      string `json:"label"`
+	CreatedBy  string `json:"created_by"`
+	Goroutines []int  `json:"goroutines"`
+}
+
+// Analyze returns the leak analysis of res, which ended with err.
+func Analyze(res *scenario.Result, err error) Leaks {
+	l := Leaks{
+		Event:       "leak_report",
+		Scenario:    res.Scenario,
+		Seed:        res.Seed,
+		Workers:     []LeakedWorker{},
+		Goroutines:  []LeakedGoroutine{},
+		Sites:       []LeakSite{},
+		Timers:      []string{},
+		Uncancelled: []string{},
+	}
+	if err != nil {
+		l.Error = err.Error()
+	}
+	for _, w := range res.Workers {
+		if w.Exit == worker.ExitLeaked {
+			l.Workers = append(l.Workers, LeakedWorker{Name: w.Worker, Processed: w.Processed, Observed: w.Observed, Blocked: w.Blocked})
+		}
+	}
+	for _, g := range res.Goroutines {
+		lg := LeakedGoroutine{ID: g.ID, State: g.State, Top: g.Top().String(), CreatedBy: g.CreatedBy.String()}
+		for _, f := range g.Stack {
+			lg.Stack = append(lg.Stack, f.String())
+		}
+		l.Goroutines = append(l.Goroutines, lg)
+	}
+	for _, s := range res.LeakSites {
+		ls := LeakSite{Label: s.Label, CreatedBy: s.CreatedBy.String()}
+		for _, g := range s.Goroutines {
+			ls.Goroutines = append(ls.Goroutines, g.ID)
+		}
+		l.Sites = append(l.Sites, ls)
+	}
+	for _, t := range res.Timers {
+		l.Timers = append(l.Timers, t.String())
+	}
+	for _, u := range res.Uncancelled {
+		l.Uncancelled = append(l.Uncancelled, u.String())
+	}
+
+	switch {
+	case errors.Is(err, contextdemo.ErrLeakBudget):
+		l.Verdict = VerdictOverBudget
+	case errors.As(err, new(*leakcheck.Error)):
+		l.Verdict = VerdictUnexpected
+	case !res.LeakChecked:
+		l.Verdict = VerdictUnchecked
+	case len(res.Goroutines) > 0:
+		l.Verdict = VerdictLeaked
+	default:
+		l.Verdict = VerdictClean
+	}
+	return l
+}
+
+// WriteJSON writes the leak analysis of every run added to the report to w,
+// as a JSON document with one entry per run.
+func (r *Report) WriteJSON(w io.Writer) error {
+	r.mu.Lock()
+	runs := make([]Leaks, len(r.runs))
+	for i, run := range r.runs {
+		runs[i] = Analyze(run.res, run.err)
+	}
+	r.mu.Unlock()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Runs []Leaks `json:"runs"`
+	}{runs})
+}
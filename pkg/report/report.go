@@ -0,0 +1,290 @@
+// Package report turns the events and results of demonstration runs into a
+// document to keep: a Markdown or HTML page with each run's worker outcomes,
+// cancellation causes, leaks and a timeline of what happened, fit to attach
+// to an incident writeup or a homework submission, or a JSON document of
+// the leak analysis alone, for CI.
+//
+// A Report is an event.Sink. Subscribe it to one or more runs, hand it each
+// run's result with Add, and write it out once they are over.
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Report collects the events of demonstration runs and the runs' results.
+// It is safe for concurrent use, so runs may overlap.
+type Report struct {
+	mu      sync.Mutex
+	pending []event.Event // events of runs whose result has not been added
+	runs    []run
+}
+
+type run struct {
+	res    *scenario.Result
+	err    error
+	events []event.Event
+}
+
+// New returns an empty Report.
+func New() *Report {
+	return &Report{}
+}
+
+// Handle records e for the timeline of the run it belongs to.
+func (r *Report) Handle(e event.Event) {
+	if e.Kind() == event.KindTickCompleted {
+		return // far too many to be worth a row each
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, e)
+}
+
+// Add closes the run that produced res and ended with err: the events
+// recorded so far for its scenario become its timeline. Call it once per
+// run, after the run returns.
+func (r *Report) Add(res *scenario.Result, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var mine, rest []event.Event
+	for _, e := range r.pending {
+		if e.EventHeader().Scenario == res.Scenario {
+			mine = append(mine, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	r.pending = rest
+	r.runs = append(r.runs, run{res: res, err: err, events: mine})
+}
+
+// WriteMarkdown writes the report to w as Markdown.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	return markdown.Execute(w, r.sections())
+}
+
+// WriteHTML writes the report to w as a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return html.Execute(w, r.sections())
+}
+
+// section is what the templates show for one run.
+type section struct {
+	Scenario string
+	Seed     uint64
+	Wall     string
+	Workers  []outcome
+	Exited   int
+	Leaked   int
+	TimedOut int
+	Blocked  int
+	Causes   []cause
+	Sites    []string // measured leaks, by where they came from, unstopped timers and uncancelled contexts
+	Timeline []entry
+}
+
+type outcome struct {
+	Worker, Exit, Propagation, Latency, Cause string
+	Processed                                 int64
+}
+
+type cause struct {
+	Cause   string
+	Workers string
+}
+
+type entry struct {
+	At, Worker, Kind, Detail string
+}
+
+func (r *Report) sections() []section {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]section, len(r.runs))
+	for i, run := range r.runs {
+		out[i] = newSection(run)
+	}
+	return out
+}
+
+func newSection(run run) section {
+	res := run.res
+	s := section{
+		Scenario: res.Scenario,
+		Seed:     res.Seed,
+		Wall:     res.Wall().String(),
+		Exited:   res.Exited(),
+		Leaked:   res.Leaked(),
+		TimedOut: res.TimedOut(),
+		Blocked:  res.Blocked(),
+	}
+	for _, site := range res.LeakSites {
+		s.Sites = append(s.Sites, site.String())
+	}
+	for _, t := range res.Timers {
+		s.Sites = append(s.Sites, "unstopped "+t.String())
+	}
+	for _, u := range res.Uncancelled {
+		s.Sites = append(s.Sites, u.String())
+	}
+	byCause := make(map[string][]string)
+	for _, w := range res.Workers {
+		o := outcome{Worker: w.Worker, Exit: w.Exit.String(), Processed: w.Processed, Propagation: "-", Latency: "-", Cause: "-"}
+		if w.Observed {
+			o.Propagation = w.Propagation.String()
+		}
+		if w.Exit == worker.ExitCancelled {
+			o.Latency = w.Latency.String()
+		}
+		if w.Cause != nil {
+			o.Cause = w.Cause.Error()
+			byCause[o.Cause] = append(byCause[o.Cause], w.Worker)
+		}
+		if w.Err != nil {
+			o.Cause = w.Err.Error()
+		}
+		s.Workers = append(s.Workers, o)
+	}
+	for _, c := range slices.Sorted(maps.Keys(byCause)) {
+		s.Causes = append(s.Causes, cause{Cause: c, Workers: strings.Join(byCause[c], ", ")})
+	}
+
+	var start time.Time
+	if len(run.events) > 0 {
+		start = run.events[0].EventHeader().Time
+	}
+	for _, e := range run.events {
+		h := e.EventHeader()
+		detail := describe(e)
+		if e.Kind() == event.KindNote && detail == "" {
+			continue // layout only
+		}
+		s.Timeline = append(s.Timeline, entry{
+			At:     "+" + h.Time.Sub(start).Round(time.Millisecond).String(),
+			Worker: h.Worker,
+			Kind:   string(e.Kind()),
+			Detail: detail,
+		})
+	}
+	return s
+}
+
+// describe returns the timeline text for e: its narration flattened onto
+// one line, or a summary of its fields if it has none.
+func describe(e event.Event) string {
+	msg := strings.Join(strings.Fields(e.EventHeader().Message), " ")
+	if strings.Trim(msg, "-") != "" {
+		return msg
+	}
+	switch e := e.(type) {
+	case event.CancellationReceived:
+		return fmt.Sprintf("cause: %v", e.Cause)
+	case event.WorkerExited:
+		if e.Err != nil {
+			return fmt.Sprintf("%s: %v", e.Exit, e.Err)
+		}
+		return e.Exit
+	case event.WorkerLeaked:
+		return fmt.Sprintf("still running after %d unit(s) of work", e.Processed)
+	case event.GoroutineSample:
+		return fmt.Sprintf("%d goroutine(s)", e.Goroutines)
+	case event.DeadlineApproaching:
+		return fmt.Sprintf("%.0f%% of the budget used, %v left", e.Consumed*100, e.Remaining)
+	case event.LowBudget:
+		return fmt.Sprintf("%s started with %v left, usually takes %v", e.Op, e.Remaining, e.Expected)
+	}
+	return ""
+}
+
+// cell escapes s for a Markdown table cell.
+func cell(s string) string {
+	if s == "" {
+		return " "
+	}
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+var markdown = template.Must(template.New("markdown").Funcs(template.FuncMap{"cell": cell}).Parse(
+	`{{range $i, $s := .}}{{if $i}}
+---
+
+{{end}}# Run report: {{$s.Scenario}}
+
+Seed {{$s.Seed}}. Ran for {{$s.Wall}}. {{$s.Exited}} of {{len $s.Workers}} worker(s) exited.
+
+## Workers
+
+| Worker | Exit | Processed | Propagation | Latency | Cause |
+|---|---|---:|---|---|---|
+{{range $s.Workers}}| {{cell .Worker}} | {{.Exit}} | {{.Processed}} | {{.Propagation}} | {{.Latency}} | {{cell .Cause}} |
+{{end}}
+## Cancellation causes
+
+{{range $s.Causes}}- ` + "`{{.Cause}}`" + `: {{.Workers}}
+{{else}}No worker was cancelled.
+{{end}}
+## Leaks
+
+{{if $s.Leaked}}{{$s.Leaked}} worker(s) leaked{{if $s.TimedOut}}, {{$s.TimedOut}} of them still shutting down when the grace period ran out{{end}}{{if $s.Blocked}}; {{$s.Blocked}} blocked, no longer ticking{{end}}.
+{{else}}No worker leaked.
+{{end}}{{range $s.Sites}}- {{.}}
+{{end}}
+## Timeline
+
+| Time | Worker | Event | Detail |
+|---:|---|---|---|
+{{range $s.Timeline}}| {{.At}} | {{cell .Worker}} | {{.Kind}} | {{cell .Detail}} |
+{{end}}{{end}}`))
+
+var html = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Context demonstration report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+.leaked { color: #b00; font-weight: bold; }
+</style>
+</head>
+<body>
+{{range .}}<h1>Run report: {{.Scenario}}</h1>
+<p>Seed {{.Seed}}. Ran for {{.Wall}}. {{.Exited}} of {{len .Workers}} worker(s) exited.</p>
+<h2>Workers</h2>
+<table>
+<tr><th>Worker</th><th>Exit</th><th>Processed</th><th>Propagation</th><th>Latency</th><th>Cause</th></tr>
+{{range .Workers}}<tr><td>{{.Worker}}</td><td{{if eq .Exit "leaked"}} class="leaked"{{end}}>{{.Exit}}</td><td>{{.Processed}}</td><td>{{.Propagation}}</td><td>{{.Latency}}</td><td>{{.Cause}}</td></tr>
+{{end}}</table>
+<h2>Cancellation causes</h2>
+{{if .Causes}}<ul>
+{{range .Causes}}<li><code>{{.Cause}}</code>: {{.Workers}}</li>
+{{end}}</ul>
+{{else}}<p>No worker was cancelled.</p>
+{{end}}<h2>Leaks</h2>
+{{if .Leaked}}<p class="leaked">{{.Leaked}} worker(s) leaked{{if .TimedOut}}, {{.TimedOut}} of them still shutting down when the grace period ran out{{end}}{{if .Blocked}}; {{.Blocked}} blocked, no longer ticking{{end}}.</p>
+{{else}}<p>No worker leaked.</p>
+{{end}}{{if .Sites}}<ul>
+{{range .Sites}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}<h2>Timeline</h2>
+<table>
+<tr><th>Time</th><th>Worker</th><th>Event</th><th>Detail</th></tr>
+{{range .Timeline}}<tr><td>{{.At}}</td><td>{{.Worker}}</td><td>{{.Kind}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,106 @@
+package rng
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// JitterKind is the way a jittered interval varies around its nominal
+// value.
+type JitterKind int
+
+// The kinds of jitter.
+const (
+	// Fixed leaves every interval at its nominal value, so workers started
+	// together tick, and stop, in lockstep.
+	Fixed JitterKind = iota
+	// Uniform moves each interval up or down by at most Jitter.Spread of
+	// itself, with every value in between equally likely.
+	Uniform
+	// Exponential draws each interval from an exponential distribution
+	// whose mean is the nominal value, as between the arrivals of a Poisson
+	// process: mostly short, now and then long.
+	Exponential
+)
+
+var jitterNames = []string{Fixed: "fixed", Uniform: "uniform", Exponential: "exponential"}
+
+func (k JitterKind) String() string {
+	if k >= 0 && int(k) < len(jitterNames) {
+		return jitterNames[k]
+	}
+	return fmt.Sprintf("JitterKind(%d)", int(k))
+}
+
+// ParseJitterKind returns the kind named s: fixed, uniform or exponential.
+func ParseJitterKind(s string) (JitterKind, error) {
+	for k, name := range jitterNames {
+		if s == name {
+			return JitterKind(k), nil
+		}
+	}
+	return Fixed, fmt.Errorf("unknown jitter %q; want fixed, uniform or exponential", s)
+}
+
+// DefaultSpread is the spread of Uniform jitter when Jitter.Spread is zero.
+const DefaultSpread = 0.5
+
+// Jitter describes how intervals vary. The zero value is Fixed.
+type Jitter struct {
+	Kind JitterKind
+	// Spread is the largest fraction of the interval Uniform jitter moves
+	// it by. Zero means DefaultSpread; other kinds ignore it.
+	Spread float64
+}
+
+func (j Jitter) String() string {
+	if j.Kind == Uniform {
+		return fmt.Sprintf("uniform ±%g%%", 100*j.spread())
+	}
+	return j.Kind.String()
+}
+
+func (j Jitter) spread() float64 {
+	if j.Spread <= 0 {
+		return DefaultSpread
+	}
+	return j.Spread
+}
+
+// Apply returns an interval of nominal length d with j applied, drawing
+// from r. It never returns less than a nanosecond for a positive d.
+func (j Jitter) Apply(r *Rand, d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	switch j.Kind {
+	case Uniform:
+		d = r.Jitter(d, j.spread())
+	case Exponential:
+		d = r.Exp(d)
+	}
+	return max(d, 1)
+}
+
+// Exp returns a duration drawn from an exponential distribution with mean
+// d.
+func (r *Rand) Exp(d time.Duration) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Duration(r.r.ExpFloat64() * float64(d))
+}
+
+type jitterKey struct{}
+
+// WithJitter returns a copy of ctx carrying j, for the tickers of every
+// worker beneath it; see ctxutil.Tick.
+func WithJitter(ctx context.Context, j Jitter) context.Context {
+	return context.WithValue(ctx, jitterKey{}, j)
+}
+
+// JitterFrom returns the Jitter carried by ctx, or Fixed if there is none.
+func JitterFrom(ctx context.Context) Jitter {
+	j, _ := ctx.Value(jitterKey{}).(Jitter)
+	return j
+}
@@ -0,0 +1,109 @@
+// Package rungroup starts dependent components in order and stops them in
+// reverse order when the context is cancelled.
+//
+// This is the shape of most real services: the database comes up before the
+// cache, the cache before the HTTP server, and on shutdown the HTTP server
+// must stop before the things it depends on disappear.
+package rungroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/worker"
+)
+
+// DefaultStopTimeout bounds each stop function when Group.StopTimeout is zero.
+const DefaultStopTimeout = time.Second
+
+// StartFunc starts a component. It should return once the component is
+// running, not block for the component's lifetime.
+type StartFunc func(ctx context.Context) error
+
+// StopFunc stops a component. It should return before ctx expires; one
+// that does not is abandoned, still running, once it has.
+type StopFunc func(ctx context.Context) error
+
+type component struct {
+	name  string
+	start StartFunc
+	stop  StopFunc
+}
+
+// Group is an ordered set of components. The zero value is ready to use.
+type Group struct {
+	// StopTimeout bounds each individual stop function.
+	StopTimeout time.Duration
+
+	components []component
+}
+
+// Add registers a component. Components start in the order they are added
+// and stop in the reverse order. Either function may be nil.
+func (g *Group) Add(name string, start StartFunc, stop StopFunc) {
+	g.components = append(g.components, component{name: name, start: start, stop: stop})
+}
+
+// Run starts every component, waits for ctx to be cancelled, and then stops
+// the components in reverse registration order. If a component fails to
+// start, the ones already started are stopped and the start error is
+// returned. Stop errors, including timeouts, are joined into the result.
+// Run does not wait for a stop function past its timeout.
+func (g *Group) Run(ctx context.Context) error {
+	for i, c := range g.components {
+		worker.Notef(ctx, "Run group: starting %s", c.name)
+		if c.start == nil {
+			continue
+		}
+		if err := c.start(ctx); err != nil {
+			err = fmt.Errorf("start %s: %w", c.name, err)
+			return errors.Join(err, g.stop(ctx, i))
+		}
+	}
+
+	<-ctx.Done()
+	worker.Notef(ctx, "Run group: cancelled (%v). Stopping in reverse order.", context.Cause(ctx))
+	return g.stop(ctx, len(g.components))
+}
+
+// stop stops the first n components in reverse order. Each stop runs under
+// its own timeout, derived from ctx without its cancellation so that the
+// stop function is not handed a context that is already done. A stop still
+// running when its timeout passes is reported as timed out and left behind,
+// and the next component is stopped.
+func (g *Group) stop(ctx context.Context, n int) error {
+	clk := clock.From(ctx)
+	timeout := g.StopTimeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+
+	var errs []error
+	for i := n - 1; i >= 0; i-- {
+		c := g.components[i]
+		if c.stop == nil {
+			continue
+		}
+		stopCtx, cancel := clock.WithTimeout(context.WithoutCancel(ctx), timeout)
+		start := clk.Now()
+		done := make(chan error, 1) // buffered, so an abandoned stop can still return
+		go func() { done <- c.stop(stopCtx) }()
+		var err error
+		select {
+		case err = <-done:
+		case <-stopCtx.Done():
+			err = fmt.Errorf("abandoned after its %v timeout: %w", timeout, context.Cause(stopCtx))
+		}
+		cancel()
+		if err != nil {
+			worker.Notef(ctx, "Run group: %s failed to stop after %v: %v", c.name, clock.Since(clk, start).Round(time.Millisecond), err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+			continue
+		}
+		worker.Notef(ctx, "Run group: %s stopped in %v", c.name, clock.Since(clk, start).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,99 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/adaptive"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("adaptive-timeout", scenario.Metadata{
+		Description: "Time out each unit of work at p99×factor of the latencies observed so far, against a workload that slows down for a while",
+		Outcome:     "The fixed timeout abandons every unit while the workload is slow. The adaptive one loses the first few, grows to fit the slower latencies, and shrinks back once they recover.",
+		Tags:        []string{scenario.TagTimeout},
+		Duration:    2 * time.Second,
+		Params: []scenario.Param{
+			{Name: "op-time", Kind: scenario.ParamDuration, Default: "15ms", Usage: "typical latency of a unit of work"},
+			{Name: "slowdown", Kind: scenario.ParamInt, Default: "4", Usage: "how many times slower units are during the middle third of the run"},
+			{Name: "fixed", Kind: scenario.ParamDuration, Default: "50ms", Usage: "timeout of the worker with a fixed one"},
+			{Name: "factor", Kind: scenario.ParamInt, Default: "2", Usage: "safety factor the adaptive p99 is multiplied by"},
+		},
+	}, runAdaptive))
+}
+
+// adaptiveInterval is how often both workers start a unit of work; short,
+// so the adaptive timeout has samples enough to follow the slowdown.
+const adaptiveInterval = 25 * time.Millisecond
+
+// runAdaptive runs two Hogwarts through the same workload, one with a
+// fixed timeout per unit and one with an adaptive.Timeout, and samples the
+// adaptive timeout as the workload slows down and recovers.
+func runAdaptive(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Adaptive Timeouts...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	opTime := env.DurationParam("op-time")
+	slowdown := time.Duration(env.IntParam("slowdown"))
+	start := env.Clock.Now()
+	slowFrom, slowUntil := start.Add(env.CancelAfter/3), start.Add(2*env.CancelAfter/3)
+	workload := func(int64) time.Duration {
+		d := env.Rand.Jitter(opTime, 0.5)
+		if now := env.Clock.Now(); !now.Before(slowFrom) && now.Before(slowUntil) {
+			d *= slowdown
+		}
+		return d
+	}
+
+	at := &adaptive.Timeout{
+		Factor:  float64(env.IntParam("factor")),
+		Window:  20,
+		Warmup:  5,
+		Initial: 4 * opTime,
+	}
+	fixed := &worker.Hogwarts{Interval: adaptiveInterval, OpTime: workload, OpTimeout: env.DurationParam("fixed")}
+	tuned := &worker.Hogwarts{Interval: adaptiveInterval, OpTime: workload, Adaptive: at}
+	env.Printf("Units take about %v, and %d times that from %v to %v in.\n",
+		opTime, slowdown, env.CancelAfter/3, 2*env.CancelAfter/3)
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "hogwarts-fixed", fixed)
+	g.Launch(ctx, "hogwarts-adaptive", tuned)
+
+	const samples = 6
+	live := true
+	for i := 1; i <= samples && live; i++ {
+		live = env.Sleep(env.CancelAfter / samples)
+		p, ok := at.Latency()
+		if !ok {
+			env.Printf("[%v] adaptive timeout %v (warming up)\n", env.CancelAfter*time.Duration(i)/samples, at.Current())
+			continue
+		}
+		env.Printf("[%v] adaptive timeout %v (p99 %v)\n", env.CancelAfter*time.Duration(i)/samples, at.Current(), p)
+	}
+	if live {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on both workers with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("adaptive-timeout", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Fixed %v timeout: %d units completed, %d abandoned.\n", fixed.OpTimeout, fixed.Processed(), fixed.TimedOut())
+	env.Printf("Adaptive timeout: %d units completed, %d abandoned.\n", tuned.Processed(), tuned.TimedOut())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
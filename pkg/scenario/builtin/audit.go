@@ -0,0 +1,76 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("audit-log", scenario.Metadata{
+		Description: "Write a final audit record after cancellation with context.WithoutCancel and a timeout of its own",
+		Outcome:     "The scribe that detaches its audit write with context.WithoutCancel gets it written; the one whose ink is too slow loses it to the audit timeout, and the naive one, writing under its cancelled context, loses it at once. No audit goroutine outlives its timeout.",
+		Tags:        []string{scenario.TagShutdown, scenario.TagCause},
+		Duration:    1900 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "audit-timeout", Kind: scenario.ParamDuration, Default: "300ms", Usage: "how long an audit write may take"},
+		},
+	}, runAudit))
+}
+
+// runAudit cancels three scribes that differ only in how they write their
+// audit record, and waits for the records before reporting.
+func runAudit(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Detached Audit Writes...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	timeout := env.DurationParam("audit-timeout")
+	var scribes []*worker.Scribe
+	var g scenario.Group
+	defer g.Release()
+	for _, sc := range []struct {
+		name  string
+		ink   time.Duration
+		naive bool
+	}{
+		{"scribe", timeout / 3, false},
+		{"scribe-slow-ink", 2 * timeout, false},
+		{"scribe-naive", timeout / 3, true},
+	} {
+		g.Spawn(ctx, sc.name, env.Workers, func() worker.Worker {
+			s := &worker.Scribe{Interval: env.TickInterval, AuditTime: sc.ink, AuditTimeout: timeout, Naive: sc.naive}
+			scribes = append(scribes, s)
+			return s
+		})
+	}
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Printf("Waiting at most %v for the audit records...\n", timeout)
+	for _, s := range scribes {
+		s.WaitAudits()
+	}
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("audit-log", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Only a write detached with context.WithoutCancel survives the cancellation, and its own timeout keeps it from leaking.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
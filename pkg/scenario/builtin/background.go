@@ -0,0 +1,94 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("background-tree", scenario.Metadata{
+		Description:   "Run work that outlives its request under an application-lifetime context instead of context.Background()",
+		Outcome:       "Every worker carries on after the request ends. At shutdown the thumbnails worker, the indexer and the indexer's shards, all detached with ctxutil.Background, stop; the mailer, detached the naive way, never learns of the shutdown and, as it never checks its context either, leaks its goroutine.",
+		Tags:          []string{scenario.TagLeak, scenario.TagShutdown},
+		ExpectedLeaks: 1,
+		Duration:      1500 * time.Millisecond,
+	}, runBackgroundTree))
+}
+
+// errRequestDone is the cause given to the request's context when the
+// request has been answered.
+var errRequestDone = errors.New("response sent to the client")
+
+// runBackgroundTree answers one request that leaves work behind, ends the
+// request a third of the way into Env.CancelAfter and shuts the
+// application down at Env.CancelAfter.
+func runBackgroundTree(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Background Task Tree...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	app, cancelApp := context.WithCancelCause(parent)
+	defer cancelApp(nil)
+	bg := ctxutil.NewBackground(app)
+	defer bg.Shutdown(nil, 0)
+
+	req, endRequest := context.WithCancelCause(worker.WithRequestID(app, "req-owl-order"))
+	defer endRequest(nil)
+
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(bg.Detach(req), "thumbnails", env.Workers, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+	g.Launch(bg.Detach(req), "indexer", worker.Func(func(ctx context.Context) error {
+		for i := 1; i <= 2; i++ {
+			shard := &worker.Hogwarts{Interval: env.TickInterval}
+			name := fmt.Sprintf("indexer-shard-%d", i)
+			bg.Go(ctx, func(ctx context.Context) { shard.Run(worker.WithWorkerName(ctx, name)) })
+		}
+		worker.Notef(ctx, "Indexer: started 2 shards of its own in the background.")
+		<-ctx.Done()
+		worker.ReportCancel(ctx, fmt.Sprintf("Indexer: stopping. Cause: %v", context.Cause(ctx)))
+		return nil
+	}))
+	// The naive way: context.WithoutCancel keeps the request's values, as
+	// the narration needs, and like context.Background() is never cancelled.
+	// The mailer never checks its context either, so not even Release, which
+	// cancels the context the Group derives for each worker, stops it.
+	g.Spawn(context.WithoutCancel(req), "mailer", env.Workers, func() worker.Worker {
+		return &worker.LeakyCauldron{Interval: env.TickInterval}
+	})
+
+	if env.Sleep(env.CancelAfter / 3) {
+		env.Printf("\n>>> The request is answered: calling its cancel(cause) with cause: '%v' <<<\n", errRequestDone)
+		endRequest(errRequestDone)
+		env.Printf("Every worker keeps running: none of them is cancelled with the request.\n")
+	}
+	if env.Sleep(env.CancelAfter - env.CancelAfter/3) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Shutting the application down with cause: '%v' <<<\n", env.Cause)
+		cancelApp(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	reaped := bg.Shutdown(env.Cause, env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("background-tree", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	if reaped {
+		env.Printf("Shutdown reaped the indexer's shards, which no Group was waiting for.\n")
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
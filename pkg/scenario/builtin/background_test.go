@@ -0,0 +1,33 @@
+package builtin_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+)
+
+func TestBackgroundTreeLeaksOnlyTheMailer(t *testing.T) {
+	errCause := errors.New("the castle is closing")
+	r := runScenario(t, "background-tree",
+		contextdemo.WithCancelAfter(time.Second),
+		contextdemo.WithCause(errCause),
+		contextdemo.WithVerifyNoLeaks(contextdemo.IntentionalLeaks...))
+
+	for _, name := range []string{"thumbnails", "indexer"} {
+		if e := r.exits()[name]; e.Exit != "cancelled" || !errors.Is(e.Cause, errCause) {
+			t.Errorf("%s exited %q with cause %v, want cancelled at shutdown with %v", name, e.Exit, e.Cause, errCause)
+		}
+	}
+	leaks := eventsOf[event.WorkerLeaked](r)
+	if len(leaks) != 1 || leaks[0].Worker != "mailer" {
+		t.Errorf("leaked %v, want only mailer", leaks)
+	}
+	// The result table and the leak check must agree: the mailer's
+	// goroutine is still there after the run.
+	if len(r.res.LeakSites) != 1 || r.res.LeakSites[0].Label != "*worker.LeakyCauldron" {
+		t.Errorf("goroutines left behind by %v, want only the mailer's", r.res.LeakSites)
+	}
+}
@@ -0,0 +1,125 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/queue"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/supervisor"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("backpressure", scenario.Metadata{
+		Description: "Feed a slow sorting office from three owls through a bounded queue, and cancel them while it is full",
+		Outcome:     "The queue fills, and each owl's Push outlasts its patience; the owls back off, longer each time, and retry. On cancellation the owls waiting in Push or in a back-off and the sorting office waiting in Pop or sorting all return at once; the letters still queued are left behind.",
+		Tags:        []string{scenario.TagChannels, scenario.TagShutdown},
+		Duration:    2000 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "capacity", Kind: scenario.ParamInt, Default: "4", Usage: "letters the queue holds"},
+			{Name: "send-every", Kind: scenario.ParamDuration, Default: "60ms", Usage: "how often each owl has a letter to push"},
+			{Name: "patience", Kind: scenario.ParamDuration, Default: "100ms", Usage: "how long an owl waits in Push before it backs off"},
+			{Name: "sort-time", Kind: scenario.ParamDuration, Default: "150ms", Usage: "how long the sorting office takes over each letter"},
+		},
+	}, runBackpressure))
+}
+
+// owls are the producers of the backpressure scenario.
+var owls = []string{"hedwig", "errol", "pigwidgeon"}
+
+// letter is an item in the backpressure scenario's queue.
+type letter struct {
+	From string
+	N    int
+}
+
+// runBackpressure launches the owls, which push letters into a
+// queue.Queue, and the sorting office, which pops them, and cancels them
+// all at Env.CancelAfter.
+func runBackpressure(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Bounded Queue and Backpressure...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	q := queue.New[letter](env.IntParam("capacity"))
+	every, patience := env.DurationParam("send-every"), env.DurationParam("patience")
+	sortTime := env.DurationParam("sort-time")
+	backoff := supervisor.Exponential(every, 8*every)
+	var sent, sorted, backoffs atomic.Int64
+
+	var g scenario.Group
+	defer g.Release()
+	for _, name := range owls {
+		g.Launch(ctx, name, worker.Func(func(ctx context.Context) error {
+			attempt := 0
+			for n := 1; ; {
+				pctx, stop := clock.WithTimeout(ctx, patience)
+				err := q.Push(pctx, letter{From: name, N: n})
+				stop()
+				var pause time.Duration
+				switch {
+				case err == nil:
+					sent.Add(1)
+					attempt, pause = 0, every
+					n++
+				case ctx.Err() != nil:
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: cancelled while waiting to push letter %d, with the queue at %d/%d. Cause: %v", name, n, q.Len(), q.Cap(), err))
+					return nil
+				default:
+					pause = backoff(attempt)
+					attempt++
+					backoffs.Add(1)
+					worker.Notef(ctx, "%s: the queue stayed full for %v; backing off %v before retrying letter %d.", name, patience, pause, n)
+				}
+				if ctxutil.Sleep(ctx, pause) != nil {
+					doing := "between letters"
+					if attempt > 0 {
+						doing = fmt.Sprintf("while backing off from letter %d", n)
+					}
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: cancelled %s. Cause: %v", name, doing, context.Cause(ctx)))
+					return nil
+				}
+			}
+		}))
+	}
+	g.Launch(ctx, "sorting-office", worker.Func(func(ctx context.Context) error {
+		for {
+			l, err := q.Pop(ctx)
+			if err != nil {
+				worker.ReportCancel(ctx, fmt.Sprintf("sorting-office: cancelled while waiting to pop. Cause: %v", err))
+				return nil
+			}
+			if ctxutil.Sleep(ctx, sortTime) != nil {
+				worker.ReportCancel(ctx, fmt.Sprintf("sorting-office: cancelled while sorting letter %d from %s. Cause: %v", l.N, l.From, context.Cause(ctx)))
+				return nil
+			}
+			worker.ReportTick(ctx, sorted.Add(1), fmt.Sprintf("Sorted letter %d from %s; %d/%d queued", l.N, l.From, q.Len(), q.Cap()))
+		}
+	}))
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with %d/%d letter(s) queued, with cause: '%v' <<<\n", q.Len(), q.Cap(), env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("backpressure", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Letters pushed: %d. Sorted: %d. Back-offs: %d. Left in the queue: %d.\n", sent.Load(), sorted.Load(), backoffs.Load(), q.Len())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
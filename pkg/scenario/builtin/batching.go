@@ -0,0 +1,108 @@
+package builtin
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/batch"
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("batching", scenario.Metadata{
+		Description: "Collect owl post into batches flushed when full, when the deadline draws near, and once more on cancellation",
+		Outcome:     "Full batches are flushed as they fill. The partial batch is flushed shortly before the deadline, and what arrives after it is flushed on cancellation instead of being dropped, so every letter sent is written.",
+		Tags:        []string{scenario.TagShutdown, scenario.TagTimeout},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "size", Kind: scenario.ParamInt, Default: "8", Usage: "letters in a full batch"},
+			{Name: "item-every", Kind: scenario.ParamDuration, Default: "60ms", Usage: "how often a letter arrives"},
+			{Name: "margin", Kind: scenario.ParamDuration, Default: "300ms", Usage: "how long before the deadline the partial batch is flushed"},
+			{Name: "flush-time", Kind: scenario.ParamDuration, Default: "20ms", Usage: "how long a flush takes"},
+		},
+	}, runBatching))
+}
+
+// runBatching launches a producer of letters and a batch.Batcher whose
+// context has a deadline a little after Env.CancelAfter, then cancels both
+// at Env.CancelAfter.
+func runBatching(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Deadline-Aware Batcher...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+	// The deadline falls after the cancellation, so its margin flushes a
+	// batch before the cancel does.
+	batchCtx, stop := clock.WithTimeout(ctx, env.CancelAfter+env.DurationParam("margin")/2)
+	defer stop()
+
+	letters := make(chan int)
+	var sent, written atomic.Int64
+	every := env.DurationParam("item-every")
+	producer := worker.Func(func(ctx context.Context) error {
+		ticks := ctxutil.Tick(ctx, every)
+		for n := 1; ; n++ {
+			select {
+			case <-ticks:
+			case <-ctx.Done():
+				return nil
+			}
+			select {
+			case letters <- n:
+				sent.Add(1)
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+
+	flushTime := env.DurationParam("flush-time")
+	var flushes atomic.Int64
+	b := &batch.Batcher[int]{
+		Size:   env.IntParam("size"),
+		Margin: env.DurationParam("margin"),
+		Flush: func(ctx context.Context, items []int, why batch.Reason) error {
+			if err := ctxutil.Sleep(ctx, flushTime); err != nil {
+				return err
+			}
+			written.Add(int64(len(items)))
+			worker.Notef(ctx, "Batcher: flushed batch %d, letters %d-%d (%s).", flushes.Add(1), items[0], items[len(items)-1], why)
+			return nil
+		},
+	}
+	batcher := worker.Func(func(ctx context.Context) error {
+		return b.Run(ctx, letters)
+	})
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "producer", producer)
+	g.Launch(batchCtx, "batcher", batcher)
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on the producer and batcher with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+		// A deadline on a simulated clock learns of its parent's
+		// cancellation from a goroutine; let it, before Wait looks.
+		<-batchCtx.Done()
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("batching", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Letters sent: %d. Letters written: %d, in %d batch(es).\n", sent.Load(), written.Load(), flushes.Load())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,112 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/budget"
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("budget", scenario.Metadata{
+		Description:      "Split a request deadline across fetch, process and write stages, and watch the last one fail fast",
+		Outcome:          "Fetch finishes inside its 60% and leaves its slack to process, which only checks its context between steps and overruns its slice. Write is left less than the minimum it needs and fails at once with a DeadlineBudgetExhausted cause instead of starting.",
+		Tags:             []string{scenario.TagTimeout, scenario.TagCause},
+		ExpectedFailures: 1,
+		Duration:         1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "process-step", Kind: scenario.ParamDuration, Default: "250ms", Usage: "length of each of the process stage's three uninterruptible steps"},
+		},
+	}, runBudget))
+}
+
+// runBudget runs one pipeline worker under a deadline of Env.CancelAfter
+// and splits the deadline between its stages with a budget.Plan.
+func runBudget(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Deadline Budget...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := clock.WithTimeout(parent, env.CancelAfter)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	plan, err := budget.Split(ctx,
+		budget.Stage{Name: "fetch", Share: 0.6},
+		budget.Stage{Name: "process", Share: 0.3},
+		budget.Stage{Name: "write", Share: 0.1, Min: env.CancelAfter / 15})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"fetch", "process", "write"} {
+		d, _ := plan.Budget(name)
+		env.Printf("Stage %-7s has a budget of %v.\n", name, d)
+	}
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "pipeline", pipeline(plan, env.CancelAfter*7/15, env.DurationParam("process-step")))
+
+	env.Enter(scenario.PhaseCancel)
+	<-ctx.Done()
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("budget", deadline)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("The overrun in one stage came out of the next one's budget, and the last stage failed fast rather than start.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
+
+// pipeline returns a worker that runs the stages of plan in order: a fetch
+// that takes fetch, three process steps of step each that it cannot
+// interrupt, and a write.
+func pipeline(plan *budget.Plan, fetch, step time.Duration) worker.Worker {
+	return worker.Func(func(ctx context.Context) error {
+		clk := clock.From(ctx)
+
+		stage, done, err := plan.Enter(ctx, "fetch")
+		if err != nil {
+			return err
+		}
+		timer := clk.NewTimer(fetch)
+		select {
+		case <-timer.C():
+			worker.Notef(stage, "Fetch done in %v.", fetch)
+		case <-stage.Done():
+			timer.Stop()
+			done()
+			return context.Cause(stage)
+		}
+		done()
+
+		stage, done, err = plan.Enter(ctx, "process")
+		if err != nil {
+			return err
+		}
+		start := clk.Now()
+		for i := 1; i <= 3; i++ {
+			clk.Sleep(step) // a step, once started, runs to the end
+			worker.Notef(stage, "Process step %d of 3 done.", i)
+		}
+		if stage.Err() != nil {
+			worker.Notef(stage, "Process finished in %v, overrunning its slice: %v", clock.Since(clk, start), context.Cause(stage))
+		}
+		done()
+
+		stage, done, err = plan.Enter(ctx, "write")
+		if err != nil {
+			worker.Notef(ctx, "Write fails fast: %v", err)
+			return fmt.Errorf("write: %w", err)
+		}
+		defer done()
+		worker.Notef(stage, "Write done.")
+		return nil
+	})
+}
@@ -0,0 +1,10 @@
+// Package builtin registers the demonstrations that ship with contextdemo.
+//
+// Like a database/sql driver, it is imported for its side effects:
+//
+//	import _ "github.com/context-demo/pkg/scenario/builtin"
+//
+// Third-party scenario packages follow the same pattern: register from an
+// init function, and let a custom binary blank-import them alongside this
+// one to have them appear in the same runner and CLI.
+package builtin
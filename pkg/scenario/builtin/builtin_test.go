@@ -0,0 +1,170 @@
+package builtin_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+// recorder is an event.Sink that keeps every event it is given, in order.
+type recorder struct {
+	mu     sync.Mutex
+	events []event.Event
+}
+
+func (r *recorder) Handle(e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// recorded returns the events recorded so far. Workers that leak may go on
+// publishing after their run is over.
+func (r *recorder) recorded() []event.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]event.Event(nil), r.events...)
+}
+
+// run is one scenario run in virtual time.
+type run struct {
+	scenario scenario.Scenario
+	res      *scenario.Result
+	// events are those the run published, in order.
+	events []event.Event
+}
+
+// runScenario runs the scenario called name on a simulated clock, with its
+// narration discarded and its events recorded, and fails t if it does not
+// run to the end. Further options apply on top.
+func runScenario(t *testing.T, name string, opts ...contextdemo.Option) *run {
+	t.Helper()
+	s, ok := scenario.Lookup(name)
+	if !ok {
+		t.Fatalf("%v: %q", scenario.ErrUnknownScenario, name)
+	}
+	rec := &recorder{}
+	opts = append([]contextdemo.Option{
+		contextdemo.WithScenario(name),
+		contextdemo.WithDeterministic(),
+		contextdemo.WithOutput(io.Discard),
+		contextdemo.WithLogger(worker.Discard),
+		contextdemo.WithSink(rec),
+	}, opts...)
+	res, err := contextdemo.Run(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("run %s: %v", name, err)
+	}
+	return &run{scenario: s, res: res, events: rec.recorded()}
+}
+
+// check fails t for each check r fails of those that hold for every
+// scenario: each worker started is accounted for by exactly one exit or
+// leak, nothing is reported for a worker once it has exited, and the run
+// fails and leaks no more than its metadata expects.
+func (r *run) check(t *testing.T) {
+	t.Helper()
+	for _, p := range r.lifecycle() {
+		t.Error(p)
+	}
+	md := r.scenario.Metadata()
+	if n := r.res.Failed(); n > md.ExpectedFailures {
+		t.Errorf("%d worker(s) failed, %d expected", n, md.ExpectedFailures)
+	}
+	if n := r.res.TimedOut(); n > 0 {
+		t.Errorf("%d worker(s) were still shutting down at the end of the grace period", n)
+	}
+	if n := r.res.Leaked() - r.res.TimedOut(); n > r.res.ExpectedLeaks {
+		t.Errorf("%d worker(s) leaked, %d expected", n, r.res.ExpectedLeaks)
+	}
+}
+
+// lifecycle checks the lifecycle events of each worker: one start, then
+// one exit or leak, and no tick or cancellation receipt after an exit.
+func (r *run) lifecycle() []string {
+	type life struct{ started, ended int }
+	lives := make(map[string]*life)
+	var order []string
+	var problems []string
+	for _, e := range r.events {
+		name := e.EventHeader().Worker
+		if name == "" {
+			continue
+		}
+		l, ok := lives[name]
+		if !ok {
+			l = &life{}
+			lives[name] = l
+			order = append(order, name)
+		}
+		switch e.(type) {
+		case event.WorkerStarted:
+			l.started++
+		case event.WorkerExited, event.WorkerLeaked:
+			l.ended++
+		case event.TickCompleted, event.CancellationReceived:
+			if l.ended >= l.started && l.started > 0 {
+				problems = append(problems, fmt.Sprintf("%s: %s after it exited", name, e.Kind()))
+			}
+		}
+	}
+	for _, name := range order {
+		if l := lives[name]; l.started != l.ended {
+			problems = append(problems, fmt.Sprintf("%s: started %d time(s) but exited or leaked %d", name, l.started, l.ended))
+		}
+	}
+	return problems
+}
+
+// eventsOf returns the events of r of type E, in order.
+func eventsOf[E event.Event](r *run) []E {
+	var out []E
+	for _, e := range r.events {
+		if e, ok := e.(E); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// exits returns the WorkerExited event of each worker of r, by name.
+func (r *run) exits() map[string]event.WorkerExited {
+	out := make(map[string]event.WorkerExited)
+	for _, e := range eventsOf[event.WorkerExited](r) {
+		out[e.Worker] = e
+	}
+	return out
+}
+
+// cancellations returns the CancellationReceived event of each worker of r,
+// by name.
+func (r *run) cancellations() map[string]event.CancellationReceived {
+	out := make(map[string]event.CancellationReceived)
+	for _, e := range eventsOf[event.CancellationReceived](r) {
+		out[e.Worker] = e
+	}
+	return out
+}
+
+// at returns when e happened, measured from the start of the run.
+func (r *run) at(e event.Event) time.Duration {
+	return e.EventHeader().Time.Sub(r.res.StartedAt)
+}
+
+// TestScenarios runs every registered scenario and checks the events it
+// published against what holds for all of them.
+func TestScenarios(t *testing.T) {
+	for _, s := range scenario.All() {
+		t.Run(s.Name(), func(t *testing.T) {
+			runScenario(t, s.Name()).check(t)
+		})
+	}
+}
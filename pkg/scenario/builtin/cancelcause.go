@@ -0,0 +1,68 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("cancel-cause", scenario.Metadata{
+		Description:   "Cancel a well-behaved worker with a cause while a leaky one keeps running",
+		Outcome:       "Hogwarts stops and reports the cause it was cancelled with; the Leaky Cauldron never checks its context and is still running when the scenario ends.",
+		Tags:          []string{scenario.TagCause, scenario.TagLeak},
+		ExpectedLeaks: 1,
+		Duration:      1500 * time.Millisecond,
+	}, runCancelCause))
+}
+
+// runCancelCause is the original demonstration: Hogwarts observes the
+// cancellation and reports its cause, the Leaky Cauldron never notices.
+func runCancelCause(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Cancel Cause...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+
+	// Use defer to call cancel with a nil cause for standard function exit cleanup.
+	defer cancel(nil)
+
+	// Start both workers
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(ctx, "hogwarts", env.Workers, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+	// The Leaky Cauldron is never handed a cancellable context at all. Like
+	// context.Background(), context.WithoutCancel is never cancelled, but it
+	// keeps the values (clock, event bus, names) the narration relies on.
+	g.Spawn(context.WithoutCancel(parent), "leaky-cauldron", env.Workers, func() worker.Worker {
+		return &worker.LeakyCauldron{Interval: env.TickInterval}
+	})
+
+	// Let the workers run for a short time
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		// Cancel the context, providing a specific cause.
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause) // Pass the cause error here
+	}
+	cancelledAt := env.Clock.Now()
+
+	// Wait for the workers that were cancelled to signal that they are done.
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("cancel-cause", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("%d of %d workers shut down gracefully, reporting the '%v' cause.\n", res.Exited(), len(res.Workers), context.Cause(ctx))
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
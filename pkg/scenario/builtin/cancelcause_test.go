@@ -0,0 +1,37 @@
+package builtin_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+)
+
+func TestCancelCause(t *testing.T) {
+	errCause := errors.New("the castle is under attack")
+	r := runScenario(t, "cancel-cause", contextdemo.WithCancelAfter(time.Second), contextdemo.WithCause(errCause))
+
+	c, ok := r.cancellations()["hogwarts"]
+	if !ok {
+		t.Fatal("hogwarts never received the cancellation")
+	}
+	if !errors.Is(c.Cause, errCause) {
+		t.Errorf("hogwarts saw cause %v, want %v", c.Cause, errCause)
+	}
+	if got := r.at(c); got != time.Second {
+		t.Errorf("hogwarts received the cancellation at %v, want 1s", got)
+	}
+	if e := r.exits()["hogwarts"]; e.Exit != "cancelled" || !errors.Is(e.Cause, errCause) {
+		t.Errorf("hogwarts exited %q with cause %v, want cancelled with %v", e.Exit, e.Cause, errCause)
+	}
+
+	if _, ok := r.exits()["leaky-cauldron"]; ok {
+		t.Error("the Leaky Cauldron exited; it never checks its context")
+	}
+	leaks := eventsOf[event.WorkerLeaked](r)
+	if len(leaks) != 1 || leaks[0].Worker != "leaky-cauldron" {
+		t.Errorf("leaked %v, want only leaky-cauldron", leaks)
+	}
+}
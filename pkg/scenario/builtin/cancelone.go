@@ -0,0 +1,75 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("cancel-one", scenario.Metadata{
+		Description: "Cancel a single worker through its own handle while its siblings keep running",
+		Outcome:     "The chosen instance stops early with its own cause, the others stop later with the parent's, and nothing leaks.",
+		Tags:        []string{scenario.TagCause},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "victim", Kind: scenario.ParamInt, Default: "2", Usage: "1-based index of the instance to cancel on its own"},
+		},
+	}, runCancelOne))
+}
+
+// errExpelled is the cause given to the one instance cancelled on its own.
+var errExpelled = errors.New("expelled by Umbridge")
+
+// runCancelOne starts at least three Hogwarts instances, cancels the one
+// picked by the victim parameter through its own handle, lets the others carry on, and only then cancels
+// the shared parent.
+func runCancelOne(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Individual Cancel Handles...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	var g scenario.Group
+	defer g.Release()
+	n := env.IntParam("victim")
+	if n < 1 || n > max(env.Workers, 3) {
+		return nil, fmt.Errorf("victim %d out of range", n)
+	}
+	instances := g.Spawn(ctx, "hogwarts", max(env.Workers, 3), func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter/2)
+	env.Sleep(env.CancelAfter / 2)
+
+	victim := instances[n-1]
+	env.Printf("\n>>> Calling %s.Cancel(cause) with cause: '%v' <<<\n", victim.Name(), errExpelled)
+	victim.Cancel(errExpelled)
+	<-victim.Done()
+
+	env.Printf("\n%s has left; its siblings keep working for another %v...\n", victim.Name(), env.CancelAfter/2)
+	if env.Sleep(env.CancelAfter / 2) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on the parent with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("cancel-one", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("%s stopped with its own cause; the others stopped with the parent's.\n", victim.Name())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("cause-matrix", scenario.Metadata{
+		Description: "Cancel three identical groups of copyists with three typed causes and get three different shutdowns",
+		Outcome:     "The group aborted by an operator drops its pages at once, the group told to shut down finishes its chapters, and the preempted group writes a checkpoint before it leaves. The worker code is the same in all three; only the cause differs.",
+		Tags:        []string{scenario.TagCause, scenario.TagShutdown},
+		Duration:    2500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "drain", Kind: scenario.ParamDuration, Default: "1s", Usage: "how long a copyist may go on copying after an orderly shutdown"},
+			{Name: "checkpoint-time", Kind: scenario.ParamDuration, Default: "150ms", Usage: "how long a preempted copyist takes to write its checkpoint"},
+		},
+	}, runCauseMatrix))
+}
+
+// matrixRow is one group of the cause matrix: its name, how its copyists
+// stop and the cause that makes them.
+type matrixRow struct {
+	group, behavior string
+	cause           func(at time.Time) error
+}
+
+var causeMatrix = []matrixRow{
+	{"abort", "drop the page in hand", func(at time.Time) error {
+		return &cause.OperatorAbort{Who: "Madam Pince", Why: "ink spilt on the shelves", At: at}
+	}},
+	{"drain", "finish the chapter in hand", func(at time.Time) error {
+		return &cause.ShutdownRequested{Who: "Madam Pince", Why: "the library is closing", At: at}
+	}},
+	{"checkpoint", "write down where to resume", func(at time.Time) error {
+		return &cause.Preempted{Who: "Madam Pince", Why: "the desks are needed for exams", At: at}
+	}},
+}
+
+// runCauseMatrix gives each group of Copyists its own context, cancels
+// each context with the group's cause at the same moment and tabulates how
+// the groups stopped.
+func runCauseMatrix(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Cause Matrix...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	var g scenario.Group
+	defer g.Release()
+	cancels := make([]context.CancelCauseFunc, len(causeMatrix))
+	copyists := make([][]*worker.Copyist, len(causeMatrix))
+	for i, row := range causeMatrix {
+		ctx, cancel := context.WithCancelCause(parent)
+		defer cancel(nil)
+		cancels[i] = cancel
+		g.Spawn(ctx, "copyist-"+row.group, env.Workers, func() worker.Worker {
+			c := &worker.Copyist{
+				Interval:       env.TickInterval,
+				Drain:          env.DurationParam("drain"),
+				CheckpointTime: env.DurationParam("checkpoint-time"),
+			}
+			copyists[i] = append(copyists[i], c)
+			return c
+		})
+	}
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		at := env.Clock.Now()
+		for i, row := range causeMatrix {
+			c := row.cause(at)
+			env.Printf("\n>>> Cancelling the %s group with cause: '%v' <<<\n", row.group, c)
+			cancels[i](c)
+		}
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("cause-matrix", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("  %-10s %-26s %-28s %s\n", "GROUP", "CAUSE", "BEHAVIOR", "PAGES")
+	var checkpoints []string
+	for i, row := range causeMatrix {
+		var pages int64
+		for _, c := range copyists[i] {
+			pages += c.Processed()
+			if page := c.Checkpoint(); page > 0 {
+				checkpoints = append(checkpoints, fmt.Sprintf("page %d", page))
+			}
+		}
+		env.Printf("  %-10s %-26T %-28s %d\n", row.group, row.cause(time.Time{}), row.behavior, pages)
+	}
+	if len(checkpoints) > 0 {
+		env.Printf("\nThe preempted copyists will resume from %s.\n", strings.Join(checkpoints, ", "))
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,70 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("cause-types", scenario.Metadata{
+		Description: "Cancel four identical queue workers with four typed causes and watch each react differently",
+		Outcome:     "The Owlery told a shutdown was requested drains its accepted letters; those cancelled for an upstream failure, an exhausted budget or an operator abort drop them at once. Each reports its cause's who, why and when.",
+		Tags:        []string{scenario.TagCause, scenario.TagShutdown},
+		Duration:    2500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "drain", Kind: scenario.ParamDuration, Default: "1s", Usage: "how long a worker may send accepted letters after an orderly shutdown"},
+		},
+	}, runCauseTypes))
+}
+
+// runCauseTypes gives each Owlery its own typed cause, so the only
+// difference between them is what errors.As finds in context.Cause.
+func runCauseTypes(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Typed Causes...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	drain := env.DurationParam("drain")
+	var g scenario.Group
+	defer g.Release()
+	for _, name := range []string{"owlery-shutdown", "owlery-upstream", "owlery-budget", "owlery-abort"} {
+		g.Launch(ctx, name, &worker.Owlery{Interval: env.TickInterval, Drain: drain})
+	}
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		at := env.Clock.Now()
+		causes := map[string]error{
+			"owlery-shutdown": &cause.ShutdownRequested{Who: "Dumbledore", Why: "end of term", At: at},
+			"owlery-upstream": &cause.UpstreamFailure{Who: "the Floo Network", Why: "fireplaces went cold", At: at, Err: errors.New("connection refused")},
+			"owlery-budget":   &cause.DeadlineBudgetExhausted{Who: "the morning post", Why: "breakfast is over", At: at, Budget: env.CancelAfter},
+			"owlery-abort":    &cause.OperatorAbort{Who: "Filch", Why: "owl droppings on the stairs", At: at},
+		}
+		for _, in := range g.Instances() {
+			env.Printf("\n>>> Calling %s.Cancel(cause) with cause: '%v' <<<\n", in.Name(), causes[in.Name()])
+			in.Cancel(causes[in.Name()])
+		}
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("cause-types", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Only the orderly shutdown was worth draining for; errors.As on context.Cause told each worker which it had.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
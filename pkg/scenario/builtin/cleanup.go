@@ -0,0 +1,63 @@
+package builtin
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("cleanup", scenario.Metadata{
+		Description: "Compare deferred cleanup with a context.AfterFunc cleanup registry in a worker that is slow to notice cancellation",
+		Outcome:     "Both goblins finish their cart ride before returning. The one using deferred calls holds its ledger and vault key until then; the one using cleanup.Registry releases them the moment it is cancelled, in reverse order, acknowledging its exit there and then, and a cleanup that panics does not stop the others.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    2100 * time.Millisecond,
+	}, runCleanup))
+}
+
+// runCleanup cancels two goblin clerks in the middle of a cart ride, one
+// cleaning up with defer and one with a cleanup.Registry.
+func runCleanup(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Cleanup Registry...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	ride := cmp.Or(3*env.TickInterval, worker.DefaultGoblinInterval)
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(ctx, "goblin-defer", env.Workers, func() worker.Worker {
+		return &worker.GoblinClerk{Interval: ride}
+	})
+	g.Spawn(ctx, "goblin-registry", env.Workers, func() worker.Worker {
+		return &worker.GoblinClerk{Interval: ride, Registry: true}
+	})
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	confirmed, missing := g.AwaitAcks(ride / 6)
+	env.Printf("\nExit acknowledged within %v by: %s; not yet by: %s.\n", ride/6, scenario.Names(confirmed), scenario.Names(missing))
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("cleanup", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Deferred cleanup waited for the worker to return; the registry's ran on cancellation.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/ctxtree"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("context-tree", scenario.Metadata{
+		Description: "Build a context tree declaratively and watch cancellation spread through it",
+		Outcome:     "Gryffindor stops with the parent's cause, Slytherin with its own earlier deadline, and the owlery, detached with WithoutCancel, only at its own timeout.",
+		Tags:        []string{scenario.TagValues, scenario.TagTimeout, scenario.TagCause},
+		Duration:    2500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "release", Default: "all", Usage: "all to release the tree with a deferred CancelAll, or none to forget, like a missing defer cancel(); -leakcheck then lists the contexts never cancelled"},
+		},
+	}, runContextTree))
+}
+
+// houseKey is the context key for a worker's Hogwarts house.
+type houseKey string
+
+// runContextTree builds a castle with three branches: one cancelled with
+// the castle, one that times out on its own first, and one detached with
+// WithoutCancel that outlives the castle until its own timeout.
+func runContextTree(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Context Tree...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	release := env.Param("release")
+	if release != "all" && release != "none" {
+		return nil, fmt.Errorf("release %q: want all or none", release)
+	}
+	root := ctxtree.New(parent, "scenario")
+	if release == "all" {
+		defer root.CancelAll()
+	}
+
+	castle := root.WithCancelCause().Named("castle")
+	gryffindor := castle.WithValue(houseKey("house"), "gryffindor").WithCancel()
+	slytherin := castle.WithValue(houseKey("house"), "slytherin").WithTimeout(env.CancelAfter / 2)
+	owlery := castle.WithoutCancel().Named("owlery (without-cancel)").WithTimeout(env.CancelAfter + env.Grace()/2)
+
+	env.Printf("\nThe tree as built:\n\n%s", root)
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(gryffindor.Context(), "gryffindor", &worker.Hogwarts{Interval: env.TickInterval})
+	g.Launch(slytherin.Context(), "slytherin", &worker.Hogwarts{Interval: env.TickInterval})
+	owl := g.Launch(owlery.Context(), "owlery", &worker.Hogwarts{Interval: env.TickInterval})
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Cancelling the castle with cause: '%v' <<<\n", env.Cause)
+		castle.Cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	env.Printf("\nThe tree after cancelling the castle:\n\n%s", root)
+
+	// The owlery was never cancelled by the castle; give it until its own
+	// timeout before reporting.
+	select {
+	case <-owl.Done():
+	case <-env.Clock.After(env.Grace()):
+	}
+	pending = g.Pending()
+	res := g.Result("context-tree", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Cancellation flowed down from the castle to Gryffindor; Slytherin had already timed out,\n")
+	env.Printf("and the owlery, detached with WithoutCancel, ran on until its own timeout.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
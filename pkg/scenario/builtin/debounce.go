@@ -0,0 +1,101 @@
+package builtin
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("debounce-throttle", scenario.Metadata{
+		Description: "Feed bursts of events through a debouncer and a throttle bound to the worker's context, then cancel it",
+		Outcome:     "Each burst is saved once by the debouncer, and the throttle lets through only what its tokens allow. Cancelling mid-burst drops the pending save and releases the debouncer's goroutine and timer with the context; the throttle keeps neither, and refuses from then on.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "burst", Kind: scenario.ParamInt, Default: "10", Usage: "events in each burst"},
+			{Name: "burst-every", Kind: scenario.ParamDuration, Default: "350ms", Usage: "time between the starts of bursts"},
+			{Name: "quiet", Kind: scenario.ParamDuration, Default: "150ms", Usage: "quiet spell the debouncer waits for before saving"},
+			{Name: "refill", Kind: scenario.ParamDuration, Default: "100ms", Usage: "how often the throttle gains a token"},
+		},
+	}, runDebounce))
+}
+
+// eventGap is the time between events within a burst.
+const eventGap = 10 * time.Millisecond
+
+// runDebounce launches a worker that sends bursts of owl post through a
+// ctxutil.Debounce and a ctxutil.Throttle, both bound to its context, and
+// cancels it part way through a burst.
+func runDebounce(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Debounce and Throttle...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	burst, every := env.IntParam("burst"), env.DurationParam("burst-every")
+	quiet, refill := env.DurationParam("quiet"), env.DurationParam("refill")
+	var sent, saves, delivered, dropped atomic.Int64
+	post := worker.Func(func(ctx context.Context) error {
+		var sinceSave atomic.Int64
+		save := ctxutil.Debounce(ctx, quiet, func() {
+			saves.Add(1)
+			worker.Notef(ctx, "Owl post: quiet for %v, saving the %d letter(s) since the last save.", quiet, sinceSave.Swap(0))
+		})
+		throttle := ctxutil.NewThrottle(ctx, refill, 3)
+
+		clk := clock.From(ctx)
+		for n := 1; ; n++ {
+			start := clk.Now()
+			before := delivered.Load()
+			for range burst {
+				if ctxutil.Sleep(ctx, eventGap) != nil {
+					worker.Notef(ctx, "Owl post: cancelled mid-burst, with %d letter(s) still waiting to be saved.", sinceSave.Load())
+					return nil
+				}
+				sent.Add(1)
+				sinceSave.Add(1)
+				save()
+				if throttle.Allow() {
+					delivered.Add(1)
+				} else {
+					dropped.Add(1)
+				}
+			}
+			worker.Notef(ctx, "Owl post: burst %d of %d letters, %d let through by the throttle.", n, burst, delivered.Load()-before)
+			if ctxutil.Sleep(ctx, every-clock.Since(clk, start)) != nil {
+				return nil
+			}
+		}
+	})
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "owl-post", post)
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on the owl post with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("debounce-throttle", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Letters sent: %d. Saves: %d. Let through by the throttle: %d; dropped: %d.\n",
+		sent.Load(), saves.Load(), delivered.Load(), dropped.Load())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("detach-values", scenario.Metadata{
+		Description: "Detach follow-up work from a cancelled request while keeping the request's values",
+		Outcome:     "The request handler stops when the client hangs up. The follow-up job, made with ctxutil.WithValues, keeps the request ID in its narration but runs on until the server shuts down, and reports the server's cause rather than the request's.",
+		Tags:        []string{scenario.TagValues, scenario.TagCause},
+		Duration:    1500 * time.Millisecond,
+	}, runDetach))
+}
+
+// errClientGone is the cause the request context is cancelled with.
+var errClientGone = errors.New("the client hung up the Floo call")
+
+// runDetach starts a handler under a request context carrying a request ID,
+// and a follow-up job whose cancellation comes from the server context and
+// whose values come from the request.
+func runDetach(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Detached Values...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	server, shutdown := context.WithCancelCause(parent)
+	defer shutdown(nil)
+	request, hangUp := context.WithCancelCause(server)
+	defer hangUp(nil)
+	request = worker.WithRequestID(request, fmt.Sprintf("req-%04d", env.Rand.IntN(10000)))
+	id, _ := worker.RequestID(request)
+
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(request, "handler", env.Workers, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+	g.Spawn(ctxutil.WithValues(server, request), "follow-up", env.Workers, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+
+	env.Printf("\nServing request %s for %v...\n", id, env.CancelAfter/2)
+	if env.Sleep(env.CancelAfter / 2) {
+		env.Printf("\n>>> Cancelling the request with cause: '%v' <<<\n", errClientGone)
+		hangUp(errClientGone)
+	}
+	env.Printf("\nThe follow-up job carries on for another %v...\n", env.CancelAfter/2)
+	if env.Sleep(env.CancelAfter / 2) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Shutting down the server with cause: '%v' <<<\n", env.Cause)
+		shutdown(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("detach-values", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("The follow-up job outlived request %s but kept its ID; only the server could stop it.\n", id)
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package builtin
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/shutdown"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("drain", scenario.Metadata{
+		Description: "Shut down in two phases: stop taking new work, drain, then cancel whatever is left",
+		Outcome:     "The quick house-elf finishes its order in hand during the drain and exits completed; the slow one is still mid-order when the drain period ends and is cancelled with the cause.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    2100 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "drain", Kind: scenario.ParamDuration, Default: "600ms", Usage: "how long workers may finish accepted work before the hard cancel"},
+		},
+	}, runDrain))
+}
+
+// runDrain runs a quick and a slow house-elf under a shutdown.Coordinator
+// and shuts them down in two phases.
+func runDrain(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Two-Phase Shutdown...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, sd := shutdown.New(parent)
+	defer sd.Stop(nil)
+
+	base := cmp.Or(env.TickInterval, worker.DefaultOrderTime)
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(ctx, "elf-quick", env.Workers, func() worker.Worker {
+		return &worker.HouseElf{OrderTime: base}
+	})
+	g.Spawn(ctx, "elf-slow", env.Workers, func() worker.Worker {
+		return &worker.HouseElf{OrderTime: 6 * base}
+	})
+
+	// allDone closes once every elf has left. It gives up at the hard stop,
+	// after which Group.Wait takes over.
+	allDone := make(chan struct{})
+	go func() {
+		for _, in := range g.Instances() {
+			select {
+			case <-in.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		close(allDone)
+	}()
+
+	drain := env.DurationParam("drain")
+	env.Printf("\nAllowing the kitchen to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Draining: no new orders. Hard stop in %v with cause: '%v' <<<\n", drain, env.Cause)
+		if sd.Shutdown(drain, allDone, env.Cause) {
+			env.Printf("\n>>> Every house-elf finished its order in hand; nothing left to cancel <<<\n")
+		} else {
+			env.Printf("\n>>> The drain period is over: cancelling the house-elves still cooking <<<\n")
+		}
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("drain", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Draining let in-flight orders finish; only what could not finish in time was cancelled.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
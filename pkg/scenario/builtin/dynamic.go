@@ -0,0 +1,113 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("dynamic", scenario.Metadata{
+		Description:   "Broadcast one cancellation to workers that joined and left while the scenario ran",
+		Outcome:       "A worker joins every spawn interval under the same parent, or -workers of them at once. Visitors leave on their own before the cancel; the Hogwarts workers still there exit on it, and every leaky-th worker, a Leaky Cauldron, leaks.",
+		Tags:          []string{scenario.TagLeak, scenario.TagShutdown},
+		ExpectedLeaks: 1,
+		Leaks:         dynamicLeaks,
+		Duration:      1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "spawn-every", Kind: scenario.ParamDuration, Default: "300ms", Usage: "how often a new worker joins"},
+			{Name: "leaky", Kind: scenario.ParamInt, Default: "4", Usage: "every how many-th worker ignores its context (0 for none)"},
+		},
+	}, runDynamic))
+}
+
+// runDynamic launches workers one at a time under a single parent until it
+// is time to cancel, then cancels the parent once for all of them.
+func runDynamic(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Dynamic Set of Workers...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	every, leaky := env.DurationParam("spawn-every"), env.IntParam("leaky")
+	if every <= 0 {
+		return nil, fmt.Errorf("spawn-every %v must be positive", every)
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	var g scenario.Group
+	defer g.Release()
+	env.Printf("\nA new worker joins every %v for %v...\n", every, env.CancelAfter)
+	start := env.Clock.Now()
+	ok := true
+	for i := 1; ok && clock.Since(env.Clock, start) < env.CancelAfter; i++ {
+		switch {
+		case leaky > 0 && i%leaky == 0:
+			g.Spawn(context.WithoutCancel(ctx), fmt.Sprintf("leaky-cauldron-%d", i), env.Workers, func() worker.Worker {
+				return &worker.LeakyCauldron{Interval: env.TickInterval}
+			})
+		case i%2 == 1:
+			g.Spawn(ctx, fmt.Sprintf("visitor-%d", i), env.Workers, func() worker.Worker { return visitor(2 * every) })
+		default:
+			g.Spawn(ctx, fmt.Sprintf("hogwarts-%d", i), env.Workers, func() worker.Worker {
+				return &worker.Hogwarts{Interval: env.TickInterval}
+			})
+		}
+		ok = env.Sleep(min(every, env.CancelAfter-clock.Since(env.Clock, start)))
+	}
+	joined := len(g.Instances())
+	left := joined - len(g.Pending())
+
+	if ok {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> %d worker(s) joined and %d left; calling cancel(cause) on the rest with cause: '%v' <<<\n", joined, left, env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("dynamic", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Of %d worker(s) that joined, %d left before the cancel, %d exited on it and %d leaked.\n",
+		joined, left, res.Exited()-left, res.Leaked())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
+
+// dynamicLeaks returns the number of Leaky Cauldrons a dynamic run starts:
+// one for every leaky-th of the workers that join, at 0, spawn-every,
+// 2×spawn-every and so on until CancelAfter, times Env.Workers.
+func dynamicLeaks(env *scenario.Env) int {
+	every, leaky := env.DurationParam("spawn-every"), env.IntParam("leaky")
+	if every <= 0 || leaky <= 0 || env.CancelAfter <= 0 {
+		return 0
+	}
+	joins := int((env.CancelAfter + every - 1) / every)
+	return joins / leaky * max(env.Workers, 1)
+}
+
+// visitor returns a worker that stays for stay and then leaves on its own,
+// unless it is cancelled first.
+func visitor(stay time.Duration) worker.Worker {
+	return worker.Func(func(ctx context.Context) error {
+		worker.Notef(ctx, "A visitor drops in for %v.", stay)
+		timer := clock.From(ctx).NewTimer(stay)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			worker.Notef(ctx, "The visitor leaves on its own.")
+		case <-ctx.Done():
+			worker.ReportCancel(ctx, fmt.Sprintf("The visitor is sent home early: %v", context.Cause(ctx)))
+		}
+		return nil
+	})
+}
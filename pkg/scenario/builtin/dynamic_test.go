@@ -0,0 +1,38 @@
+package builtin_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+)
+
+// TestDynamicLeaks checks that the expected leak count follows the run's
+// parameters: one Leaky Cauldron for every leaky-th worker to join before
+// the cancel, for each of -workers.
+func TestDynamicLeaks(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []contextdemo.Option
+		want int
+	}{
+		{"defaults", nil, 1},
+		{"longer run", []contextdemo.Option{contextdemo.WithCancelAfter(3 * time.Second)}, 2},
+		{"two workers", []contextdemo.Option{contextdemo.WithWorkers(2)}, 2},
+		{"every worker leaky", []contextdemo.Option{contextdemo.WithParam("leaky", "1")}, 5},
+		{"none leaky", []contextdemo.Option{contextdemo.WithParam("leaky", "0")}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := runScenario(t, "dynamic", tt.opts...)
+			r.check(t)
+			if r.res.ExpectedLeaks != tt.want {
+				t.Errorf("expected %d leak(s), want %d", r.res.ExpectedLeaks, tt.want)
+			}
+			if got := len(eventsOf[event.WorkerLeaked](r)); got != tt.want {
+				t.Errorf("%d worker(s) leaked, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("err-vs-cause", scenario.Metadata{
+		Description: "Stop one worker with cancel(cause) and an identical one with a timeout, and compare ctx.Err() with context.Cause()",
+		Outcome:     "Both workers stop at the same moment. The cancelled one reports context.Canceled as its error and the given cause; the timed-out one reports context.DeadlineExceeded as both, since WithTimeout has no cause to give.",
+		Tags:        []string{scenario.TagTimeout, scenario.TagCause},
+		Duration:    1500 * time.Millisecond,
+	}, runErrVsCause))
+}
+
+// runErrVsCause runs two Hogwarts, one under context.WithCancelCause and
+// one under clock.WithTimeout, ends both at Env.CancelAfter and tabulates
+// what each context reports.
+func runErrVsCause(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration of ctx.Err() versus context.Cause()...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	cancelled, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+	timedOut, stop := clock.WithTimeout(parent, env.CancelAfter)
+	defer stop()
+	contexts := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"hogwarts-cancelled", cancelled},
+		{"hogwarts-timed-out", timedOut},
+	}
+
+	var g scenario.Group
+	defer g.Release()
+	for _, c := range contexts {
+		g.Launch(c.ctx, c.name, &worker.Hogwarts{Interval: env.TickInterval})
+	}
+
+	env.Printf("\nBoth workers run for %v; one is then cancelled and the other's deadline passes...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on hogwarts-cancelled with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	<-timedOut.Done()
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("err-vs-cause", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("  %-20s %-28s %-10s %-18s %s\n", "WORKER", "ctx.Err()", "Canceled", "DeadlineExceeded", "context.Cause()")
+	for _, c := range contexts {
+		err := c.ctx.Err()
+		env.Printf("  %-20s %-28v %-10t %-18t %v\n", c.name, err,
+			errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), context.Cause(c.ctx))
+	}
+	env.Printf("\nctx.Err() only says how a context ended; context.Cause() says why, when the canceller gave a reason.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package builtin
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/errgroup"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("errgroup", scenario.Metadata{
+		Description:      "Run the same siblings under errgroup.WithContext and under errgroup.WithCancelCause, and let one of each fail",
+		Outcome:          "In both groups the Knight Bus's error cancels its Hogwarts siblings at once, and Wait returns that error after they have torn down. Siblings under WithContext see only context.Canceled as the cause; those under WithCancelCause see the bus's error.",
+		Tags:             []string{scenario.TagErrors, scenario.TagCause},
+		ExpectedFailures: 2,
+		Duration:         time.Second,
+		Params: []scenario.Param{
+			{Name: "fail-after", Kind: scenario.ParamInt, Default: "3", Usage: "units of work the failing worker does before it fails"},
+		},
+	}, runErrgroup))
+}
+
+// runErrgroup launches two workers, each running two Hogwarts siblings and
+// a failing Knight Bus in an errgroup.Group: one group made by
+// errgroup.WithContext, the other by errgroup.WithCancelCause. If neither
+// has failed by Env.CancelAfter, both are cancelled as usual.
+func runErrgroup(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with errgroup...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	groups := []struct {
+		name  string
+		with  func(context.Context) (*errgroup.Group, context.Context)
+		cause atomic.Pointer[error] // the cause the siblings saw
+	}{
+		{name: "with-context", with: errgroup.WithContext},
+		{name: "with-cause", with: errgroup.WithCancelCause},
+	}
+	failAfter := env.IntParam("fail-after")
+
+	var g scenario.Group
+	defer g.Release()
+	for i := range groups {
+		eg := &groups[i]
+		g.Launch(ctx, eg.name, worker.Func(func(ctx context.Context) error {
+			group, gctx := eg.with(ctx)
+			for _, house := range []string{"gryffindor", "ravenclaw"} {
+				h := &worker.Hogwarts{Interval: env.TickInterval}
+				group.Go(func() error { return h.Run(worker.WithWorkerName(gctx, eg.name+"/"+house)) })
+			}
+			bus := &worker.Flaky{Interval: env.TickInterval, FailAfter: failAfter}
+			group.Go(func() error { return bus.Run(worker.WithWorkerName(gctx, eg.name+"/knight-bus")) })
+
+			err := group.Wait()
+			cause := context.Cause(gctx)
+			eg.cause.Store(&cause)
+			worker.Notef(ctx, "%s: Wait returned '%v' once every sibling had returned; they saw the cause '%v'.", eg.name, err, cause)
+			return err
+		}))
+	}
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("errgroup", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("%-14s %s\n", "GROUP", "CAUSE SEEN BY THE SIBLINGS")
+	for i := range groups {
+		if c := groups[i].cause.Load(); c != nil {
+			env.Printf("%-14s %v\n", groups[i].name, *c)
+		}
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package builtin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/context-demo/pkg/worker"
+)
+
+func TestErrgroup(t *testing.T) {
+	r := runScenario(t, "errgroup")
+
+	for group, want := range map[string]error{
+		"with-context": context.Canceled,
+		"with-cause":   worker.ErrKnightBusCrashed,
+	} {
+		exit := r.exits()[group]
+		if !errors.Is(exit.Err, worker.ErrKnightBusCrashed) {
+			t.Errorf("%s: Wait returned %v, want %v", group, exit.Err, worker.ErrKnightBusCrashed)
+		}
+		for _, sibling := range []string{group + "/gryffindor", group + "/ravenclaw"} {
+			c, ok := r.cancellations()[sibling]
+			if !ok {
+				t.Errorf("%s was never cancelled", sibling)
+				continue
+			}
+			if !errors.Is(c.Cause, want) {
+				t.Errorf("%s saw cause %v, want %v", sibling, c.Cause, want)
+			}
+			if c.EventHeader().Time.After(exit.Time) {
+				t.Errorf("%s was cancelled after Wait returned", sibling)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("fail-fast", scenario.Metadata{
+		Description:      "Cancel every sibling as soon as one worker fails, errgroup style",
+		Outcome:          "The Knight Bus crashes after a few stops; its error cancels the shared context, and the Hogwarts workers beside it stop at once, reporting the bus's error through context.Cause.",
+		Tags:             []string{scenario.TagErrors, scenario.TagCause},
+		ExpectedFailures: 1,
+		Duration:         time.Second,
+		Params: []scenario.Param{
+			{Name: "fail-after", Kind: scenario.ParamInt, Default: "3", Usage: "units of work the failing worker does before it fails"},
+		},
+	}, runFailFast))
+}
+
+// runFailFast launches siblings under Group.CancelOnError, so the first
+// error cancels them all; if nothing fails by CancelAfter, they are
+// cancelled as usual.
+func runFailFast(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Cancel on First Error...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	outer, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	var g scenario.Group
+	defer g.Release()
+	ctx := g.CancelOnError(outer)
+	g.Spawn(ctx, "hogwarts", max(env.Workers, 2), func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+	g.Spawn(ctx, "knight-bus", 1, func() worker.Worker {
+		return &worker.Flaky{Interval: env.TickInterval, FailAfter: env.IntParam("fail-after")}
+	})
+
+	env.Printf("\nAllowing workers to run for up to %v, unless one fails first...\n", env.CancelAfter)
+	timer := env.Clock.NewTimer(env.CancelAfter)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> A worker failed; the group cancelled its siblings with cause: '%v' <<<\n", context.Cause(ctx))
+	case <-timer.C():
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("fail-fast", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Every sibling stopped with the cause '%v'.\n", context.Cause(ctx))
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
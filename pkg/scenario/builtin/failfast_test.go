@@ -0,0 +1,33 @@
+package builtin_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/context-demo/pkg/worker"
+)
+
+func TestFailFast(t *testing.T) {
+	r := runScenario(t, "fail-fast")
+
+	crash, ok := r.exits()["knight-bus"]
+	if !ok || crash.Exit != "failed" || !errors.Is(crash.Err, worker.ErrKnightBusCrashed) {
+		t.Fatalf("knight-bus exited %q with %v, want failed with %v", crash.Exit, crash.Err, worker.ErrKnightBusCrashed)
+	}
+	if r.res.CancelledAt.After(crash.Time) {
+		t.Errorf("the run was cancelled at %v, after the crash at %v", r.res.CancelledAt, crash.Time)
+	}
+	for _, name := range []string{"hogwarts-1", "hogwarts-2"} {
+		c, ok := r.cancellations()[name]
+		if !ok {
+			t.Errorf("%s was never cancelled", name)
+			continue
+		}
+		if !errors.Is(c.Cause, worker.ErrKnightBusCrashed) {
+			t.Errorf("%s saw cause %v, want the crash", name, c.Cause)
+		}
+		if !c.Time.Equal(crash.Time) {
+			t.Errorf("%s was cancelled at %v, want at once, at the crash at %v", name, r.at(c), r.at(crash))
+		}
+	}
+}
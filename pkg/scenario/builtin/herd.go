@@ -0,0 +1,131 @@
+package builtin
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/rng"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("thundering-herd", scenario.Metadata{
+		Description: "Shut down a herd of workers with fixed and with jittered timing and count how many hit the backend at once",
+		Outcome:     "The fixed herd ticks in lockstep and, once cancelled, every member flushes to the backend in the same instant. The jittered herd, using -jitter or uniform jitter if that is fixed, spreads its flushes out and keeps the peak low.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "herd", Kind: scenario.ParamInt, Default: "8", Usage: "number of workers in each herd"},
+			{Name: "flush", Kind: scenario.ParamDuration, Default: "50ms", Usage: "how long each worker's final flush keeps the backend busy"},
+		},
+	}, runThunderingHerd))
+}
+
+// backend counts how many flushes it is serving at once.
+type backend struct {
+	mu           sync.Mutex
+	active, peak int
+}
+
+// serve holds a slot of the backend for d.
+func (b *backend) serve(clk clock.Clock, d time.Duration) {
+	b.mu.Lock()
+	b.active++
+	b.peak = max(b.peak, b.active)
+	b.mu.Unlock()
+	clk.Sleep(d)
+	b.mu.Lock()
+	b.active--
+	b.mu.Unlock()
+}
+
+// Peak returns the most flushes the backend served at once.
+func (b *backend) Peak() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peak
+}
+
+// herdMember returns a worker that ticks every interval until cancelled,
+// then waits about delay, jittered as its context says, and flushes to b.
+func herdMember(b *backend, interval, delay, flush time.Duration) worker.Worker {
+	return worker.Func(func(ctx context.Context) error {
+		ticks := ctxutil.Tick(ctx, interval)
+		for n := int64(1); ; n++ {
+			select {
+			case <-ticks:
+				worker.ReportTick(ctx, n, "")
+			case <-ctx.Done():
+				// Capped, so an exponential tail still fits the grace period.
+				wait := min(rng.JitterFrom(ctx).Apply(rng.From(ctx), delay), 2*delay)
+				worker.ReportCancel(ctx, fmt.Sprintf("Cancelled; flushing to the backend in %v.", wait))
+				clk := clock.From(ctx)
+				clk.Sleep(wait)
+				b.serve(clk, flush)
+				return nil
+			}
+		}
+	})
+}
+
+// runThunderingHerd starts one herd with fixed timing and one with jitter,
+// cancels both at once and compares the backend's peak load.
+func runThunderingHerd(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Thundering Herd...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	jitter := rng.JitterFrom(ctx)
+	if jitter.Kind == rng.Fixed {
+		jitter = rng.Jitter{Kind: rng.Uniform}
+	}
+	interval := cmp.Or(env.TickInterval, worker.DefaultHogwartsInterval)
+	n, flush := env.IntParam("herd"), env.DurationParam("flush")
+	herds := []struct {
+		name   string
+		jitter rng.Jitter
+		b      *backend
+	}{
+		{"herd-fixed", rng.Jitter{Kind: rng.Fixed}, &backend{}},
+		{"herd-jittered", jitter, &backend{}},
+	}
+
+	var g scenario.Group
+	defer g.Release()
+	for _, h := range herds {
+		env.Printf("%s: %d workers ticking every %v with %v timing.\n", h.name, n, interval, h.jitter)
+		g.Spawn(rng.WithJitter(ctx, h.jitter), h.name, n, func() worker.Worker {
+			return herdMember(h.b, interval, interval, flush)
+		})
+	}
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("thundering-herd", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	for _, h := range herds {
+		env.Printf("%-14s peak load on the backend: %d of %d flushes at once (%v).\n", h.name, h.b.Peak(), n, h.jitter)
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
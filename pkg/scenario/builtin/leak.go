@@ -0,0 +1,75 @@
+package builtin
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("leak", scenario.Metadata{
+		Description:   "Hand a context to a worker that ignores it and watch it outlive cancellation",
+		Outcome:       "The Leaky Cauldron keeps ticking after cancellation and is reported as leaked.",
+		Tags:          []string{scenario.TagLeak},
+		ExpectedLeaks: 1,
+		Duration:      3500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "interval", Kind: scenario.ParamDuration, Usage: "time between the Leaky Cauldron's ticks (default: the tick interval)"},
+			{Name: "wait", Default: "grace", Usage: "how to wait for the cancelled worker: grace, for at most the grace period, or forever, like a bare sync.WaitGroup, which hangs unless -hard-kill is set"},
+		},
+	}, runLeak))
+}
+
+// runLeak gives the Leaky Cauldron a real, cancellable context. Cancelling it
+// changes nothing: the worker never selects on ctx.Done().
+func runLeak(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Leaky Goroutine...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	wait := env.Param("wait")
+	if wait != "grace" && wait != "forever" {
+		return nil, fmt.Errorf("wait %q: want grace or forever", wait)
+	}
+
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(ctx, "leaky-cauldron", env.Workers, func() worker.Worker {
+		return &worker.LeakyCauldron{Interval: cmp.Or(env.DurationParam("interval"), env.TickInterval)}
+	})
+
+	env.Printf("\nAllowing the Leaky Cauldron to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	var pending []*scenario.Instance
+	if wait == "forever" {
+		env.Printf("Waiting for the worker to finish, however long it takes...\n\n\n")
+		for _, in := range g.Instances() {
+			<-in.Done() // never closes: this is the shutdown that hangs
+		}
+	} else {
+		env.Printf("Waiting up to %v to see if the worker notices...\n\n\n", env.Grace())
+		pending = g.Wait(env.Grace())
+	}
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("leak", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("The context was cancelled, yet the Leaky Cauldron kept working (goroutine leak).\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("leak-blocked", scenario.Metadata{
+		Description:   "Leak workers that block forever: on a send nobody receives, a receive nobody closes, and a lock nobody releases",
+		Outcome:       "All three workers are reported as leaked and blocked, having gone quiet after their first unit of work; -leakcheck shows the channel send, channel receive and mutex each is stuck in.",
+		Tags:          []string{scenario.TagLeak, scenario.TagChannels},
+		ExpectedLeaks: 3,
+		Duration:      3500 * time.Millisecond,
+	}, runLeakBlocked))
+}
+
+// runLeakBlocked contrasts with leak: instead of busy-looping past
+// cancellation, each worker is parked in an operation that only another
+// goroutine could complete, and that goroutine is gone.
+func runLeakBlocked(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Blocked Goroutines...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(ctx, "owl-post", env.Workers, func() worker.Worker {
+		return &worker.StrandedOwl{Interval: env.TickInterval}
+	})
+	g.Spawn(ctx, "portrait", env.Workers, func() worker.Worker {
+		return &worker.WaitingPortrait{Interval: env.TickInterval}
+	})
+	g.Spawn(ctx, "gringotts", env.Workers, func() worker.Worker {
+		return &worker.LockedVault{Interval: env.TickInterval}
+	})
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("leak-blocked", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("None of the workers can see the cancellation: each is blocked where no ctx.Done() case can reach it.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
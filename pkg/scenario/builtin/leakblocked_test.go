@@ -0,0 +1,28 @@
+package builtin_test
+
+import (
+	"testing"
+
+	"github.com/context-demo/pkg/event"
+)
+
+func TestLeakBlocked(t *testing.T) {
+	r := runScenario(t, "leak-blocked")
+
+	if exits := r.exits(); len(exits) != 0 {
+		t.Errorf("%d worker(s) exited; all three block forever", len(exits))
+	}
+	leaked := make(map[string]event.WorkerLeaked)
+	for _, l := range eventsOf[event.WorkerLeaked](r) {
+		leaked[l.Worker] = l
+	}
+	for _, name := range []string{"gringotts", "owl-post", "portrait"} {
+		l, ok := leaked[name]
+		switch {
+		case !ok:
+			t.Errorf("%s was not reported leaked", name)
+		case !l.Blocked || l.Processed != 1:
+			t.Errorf("%s leaked after %d unit(s) of work, blocked %v; want blocked after its first", name, l.Processed, l.Blocked)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("low-budget", scenario.Metadata{
+		Description: "Run Hogwarts against a deadline and warn when a unit of work starts with less time left than units have taken",
+		Outcome:     "Hogwarts works through its budget until, near the deadline, it starts a unit with less time left than units usually take and publishes a low-budget warning before the deadline ends the run.",
+		Tags:        []string{scenario.TagTimeout},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "interval", Kind: scenario.ParamDuration, Default: "90ms", Usage: "how often Hogwarts starts a unit of work"},
+			{Name: "op-time", Kind: scenario.ParamDuration, Default: "60ms", Usage: "typical time a unit of work takes"},
+		},
+	}, runLowBudget))
+}
+
+// runLowBudget runs a Hogwarts whose units take time under a deadline of
+// Env.CancelAfter, reading the remaining budget with ctxutil.Remaining as
+// it goes.
+func runLowBudget(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Low-Budget Warnings...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := clock.WithTimeout(parent, env.CancelAfter)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	opTime := env.DurationParam("op-time")
+	h := &worker.Hogwarts{
+		Interval: env.DurationParam("interval"),
+		OpTime:   func(int64) time.Duration { return env.Rand.Jitter(opTime, 0.25) },
+	}
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "hogwarts", h)
+
+	left, _ := ctxutil.Remaining(ctx)
+	env.Printf("\nHogwarts has %v before its deadline; units take about %v.\n", left.Round(time.Millisecond), opTime)
+	if env.Sleep(env.CancelAfter / 2) {
+		left, _ = ctxutil.Remaining(ctx)
+		env.Printf("Half way: %v of the budget left.\n", left.Round(time.Millisecond))
+	}
+	env.Enter(scenario.PhaseCancel)
+	<-ctx.Done()
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("low-budget", deadline)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	left, _ = ctxutil.Remaining(ctx)
+	env.Printf("Units completed: %d. The deadline passed %v ago.\n", h.Processed(), (-left).Round(time.Millisecond))
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
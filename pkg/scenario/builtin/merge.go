@@ -0,0 +1,82 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("merge-cancel", scenario.Metadata{
+		Description: "Run workers that obey both their request's context and the server's shutdown context",
+		Outcome:     "The worker with a short request deadline stops when it passes, with context.DeadlineExceeded; the one with a long deadline is stopped first by the server shutting down, and reports that cause instead.",
+		Tags:        []string{scenario.TagCause, scenario.TagShutdown},
+		Duration:    1500 * time.Millisecond,
+	}, runMerge))
+}
+
+// errServerShutdown is the cause the server context is cancelled with.
+var errServerShutdown = errors.New("the Ministry is closing for the night")
+
+// runMerge gives each worker a context merged with ctxutil.MergeCancel from
+// a request context with a deadline of its own and one server context
+// shared by all, and lets whichever ends first stop it.
+func runMerge(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Merged Contexts...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	server, shutdown := context.WithCancelCause(parent)
+	defer shutdown(nil)
+
+	var g scenario.Group
+	defer g.Release()
+	var merged []context.Context
+	for _, req := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"request-short", env.CancelAfter / 2},
+		{"request-long", 2 * env.CancelAfter},
+	} {
+		reqCtx, cancelReq := clock.WithTimeout(parent, req.timeout)
+		defer cancelReq()
+		ctx, cancel := ctxutil.MergeCancel(reqCtx, server)
+		defer cancel()
+		merged = append(merged, ctx)
+		env.Printf("%s has a deadline of %v and stops early if the server shuts down.\n", req.name, req.timeout)
+		g.Spawn(ctx, req.name, env.Workers, func() worker.Worker {
+			return &worker.Hogwarts{Interval: env.TickInterval}
+		})
+	}
+
+	env.Printf("\nAllowing the server to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Shutting down the server with cause: '%v' <<<\n", errServerShutdown)
+		shutdown(errServerShutdown)
+		// MergeCancel passes the shutdown on from a context.AfterFunc
+		// callback, so it lands a moment later.
+		for _, ctx := range merged {
+			<-ctx.Done()
+		}
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("merge-cancel", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Each worker stopped for whichever of its two contexts ended first, and its cause says which.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("op-timeout", scenario.Metadata{
+		Description: "Run each unit of Hogwarts' work under a WithTimeout child of the worker's context",
+		Outcome:     "Every slow unit times out on its own and is abandoned while the worker carries on with the next. Cancelling the parent still stops the worker, and a unit in flight with it.",
+		Tags:        []string{scenario.TagTimeout},
+		Duration:    2 * time.Second,
+		Params: []scenario.Param{
+			{Name: "op-timeout", Kind: scenario.ParamDuration, Default: "150ms", Usage: "timeout of each unit of work"},
+			{Name: "op-time", Kind: scenario.ParamDuration, Default: "50ms", Usage: "how long an ordinary unit takes"},
+			{Name: "slow-time", Kind: scenario.ParamDuration, Default: "300ms", Usage: "how long a slow unit takes"},
+			{Name: "slow-every", Kind: scenario.ParamInt, Default: "3", Usage: "every how many-th unit is slow (0 for none)"},
+		},
+	}, runOpTimeout))
+}
+
+// runOpTimeout launches a Hogwarts whose every slow-every-th unit outlasts
+// its op-timeout, cancels it, and reports how many units were abandoned.
+func runOpTimeout(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Per-Operation Timeouts...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	opTime, slowTime := env.DurationParam("op-time"), env.DurationParam("slow-time")
+	slowEvery := int64(env.IntParam("slow-every"))
+	h := &worker.Hogwarts{
+		Interval:  env.TickInterval,
+		OpTimeout: env.DurationParam("op-timeout"),
+		OpTime: func(n int64) time.Duration {
+			if slowEvery > 0 && n%slowEvery == 0 {
+				return slowTime
+			}
+			return opTime
+		},
+	}
+	env.Printf("Each unit runs under WithTimeout(ctx, %v); one in every %d takes %v, the rest %v.\n",
+		h.OpTimeout, slowEvery, slowTime, opTime)
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "hogwarts", h)
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on the parent with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("op-timeout", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Units completed: %d; abandoned after their own timeout: %d.\n", h.Processed(), h.TimedOut())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,66 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/pause"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("pause", scenario.Metadata{
+		Description: "Petrify and revive a worker through a pause switch carried by the context, then cancel it",
+		Outcome:     "The student does no work while petrified and carries on once revived, with its context never cancelled in between; Hogwarts, which does not watch the switch, works straight through. Both stop on the final cancellation.",
+		Tags:        []string{scenario.TagCause},
+		Duration:    1500 * time.Millisecond,
+	}, runPause))
+}
+
+// runPause runs a Student and a Hogwarts under one pause.Switch, pauses the
+// switch for the middle third of Env.CancelAfter and then cancels both.
+func runPause(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Pause and Resume...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+	ctx, sw := pause.With(ctx)
+
+	student := &worker.Student{Interval: env.TickInterval}
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "student", student)
+	g.Launch(ctx, "hogwarts", &worker.Hogwarts{Interval: env.TickInterval})
+
+	third := env.CancelAfter / 3
+	elapsed := false
+	if env.Sleep(third) {
+		env.Printf("\n>>> Pausing after %d unit(s) of work; nothing is cancelled <<<\n", student.Processed())
+		sw.Pause()
+		if env.Sleep(third) {
+			env.Printf("\n>>> Resuming; the student did %d unit(s) of work in all so far <<<\n", student.Processed())
+			sw.Resume()
+			elapsed = env.Sleep(env.CancelAfter - 2*third)
+		}
+	}
+	if elapsed {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("pause", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Pausing is not cancelling: the student's context stayed live through the pause, and only cancel(cause) ended it.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
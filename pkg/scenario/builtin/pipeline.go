@@ -0,0 +1,298 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+// pipelineStages are the stages a pipeline-leak run may leave without a
+// ctx.Done() case.
+var pipelineStages = []string{"generator", "processor", "collector"}
+
+func init() {
+	params := []scenario.Param{
+		{Name: "processors", Kind: scenario.ParamInt, Default: "3", Usage: "processors the generator fans out to"},
+		{Name: "generate-every", Kind: scenario.ParamDuration, Default: "100ms", Usage: "how often the generator produces a value"},
+		{Name: "process-time", Kind: scenario.ParamDuration, Default: "250ms", Usage: "how long a processor takes over each value"},
+	}
+	scenario.Register(scenario.New("pipeline", scenario.Metadata{
+		Description: "Cancel a generator → processors → collector pipeline in which every stage selects on ctx.Done()",
+		Outcome:     "Every stage sees the cancellation wherever it is blocked, in a receive, a send or a wait, reports where that was, and exits. No goroutine is left behind.",
+		Tags:        []string{scenario.TagChannels, scenario.TagShutdown},
+		Duration:    2000 * time.Millisecond,
+		Params:      params,
+	}, func(ctx context.Context, env *scenario.Env) (*scenario.Result, error) {
+		return runPipeline(ctx, env, pipelineSpec{name: "pipeline"})
+	}))
+	scenario.Register(scenario.New("pipeline-leak", scenario.Metadata{
+		Description:   "Cancel the same pipeline with one stage that blocks on its channels without a ctx.Done() case",
+		Outcome:       "The stages that select on ctx.Done() exit as before. The careless stage is left blocked on a channel whose other end has gone, and the leaked workers name exactly that stage. With the default, each of the three processors leaks.",
+		Tags:          []string{scenario.TagChannels, scenario.TagLeak},
+		ExpectedLeaks: 3,
+		Leaks:         pipelineLeaks,
+		Duration:      2000 * time.Millisecond,
+		Params: append(slices.Clip(params), scenario.Param{
+			Name: "stage", Kind: scenario.ParamString, Default: "processor",
+			Usage: "stage without a ctx.Done() case: " + strings.Join(pipelineStages, ", "),
+		}),
+	}, func(ctx context.Context, env *scenario.Env) (*scenario.Result, error) {
+		stage := env.Param("stage")
+		if !slices.Contains(pipelineStages, stage) {
+			return nil, fmt.Errorf("pipeline-leak: unknown stage %q; want one of %s", stage, strings.Join(pipelineStages, ", "))
+		}
+		return runPipeline(ctx, env, pipelineSpec{name: "pipeline-leak", leaky: stage})
+	}))
+	scenario.Register(scenario.New("pipeline-timeout", scenario.Metadata{
+		Description:      "Give each pipeline stage a context of its own, and time out a processor that turns slow",
+		Outcome:          "The slow processor overruns its stage-local timeout and cancels the processing stage with a DeadlineBudgetExhausted cause. Its sibling processors and the collector downstream stop with that cause; the generator upstream is not cancelled, and waits to send until the scenario cancels it.",
+		Tags:             []string{scenario.TagChannels, scenario.TagTimeout, scenario.TagCause},
+		ExpectedFailures: 1,
+		Duration:         2000 * time.Millisecond,
+		Params: append(slices.Clip(params),
+			scenario.Param{Name: "stage-timeout", Kind: scenario.ParamDuration, Default: "400ms", Usage: "how long a processor may take over one value"},
+			scenario.Param{Name: "slow-processor", Kind: scenario.ParamInt, Default: "2", Usage: "processor that turns slow, counting from 1"},
+			scenario.Param{Name: "slow-from", Kind: scenario.ParamInt, Default: "3", Usage: "value, counting that processor's own, from which it is slow"},
+			scenario.Param{Name: "slow-time", Kind: scenario.ParamDuration, Default: "600ms", Usage: "how long the slow processor takes over each value"},
+		),
+	}, func(ctx context.Context, env *scenario.Env) (*scenario.Result, error) {
+		return runPipeline(ctx, env, pipelineSpec{
+			name:     "pipeline-timeout",
+			timeout:  env.DurationParam("stage-timeout"),
+			slow:     env.IntParam("slow-processor"),
+			slowFrom: int64(env.IntParam("slow-from")),
+			slowTime: env.DurationParam("slow-time"),
+		})
+	}))
+}
+
+// pipelineLeaks returns the number of stages a pipeline-leak run leaves
+// blocked: every processor if the careless stage is the processors, and
+// the one generator or collector otherwise. There is one pipeline however
+// many Env.Workers there are.
+func pipelineLeaks(env *scenario.Env) int {
+	if env.Param("stage") == "processor" {
+		return max(env.IntParam("processors"), 1)
+	}
+	return 1
+}
+
+// pipelineSpec says how runPipeline builds its pipeline.
+type pipelineSpec struct {
+	name string
+	// leaky is the stage, if any, whose channel operations and waits have
+	// no ctx.Done() case.
+	leaky string
+	// timeout, if positive, is how long a processor may take over one
+	// value before it cancels the processing stage.
+	timeout time.Duration
+	// slow is the processor, counting from 1, that takes slowTime over
+	// each of its values from its slowFrom-th on. Zero means none.
+	slow     int
+	slowFrom int64
+	slowTime time.Duration
+}
+
+// item is a value passing down the pipeline.
+type item struct {
+	N  int64
+	By string // the processor that handled it
+}
+
+// runPipeline launches a generator that fans out to the processors over
+// one channel, and a collector they fan in to over another, and cancels
+// them all at Env.CancelAfter.
+//
+// Each stage runs under a context of its own, derived from the one before
+// it: the generator's from the scenario's, the processors' from the
+// generator's and the collector's from the processors'. Cancelling a stage
+// therefore cancels every stage downstream of it, with the same cause, and
+// none upstream.
+func runPipeline(parent context.Context, env *scenario.Env, spec pipelineSpec) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Fan-Out/Fan-In Pipeline...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+	genCtx, cancelGen := context.WithCancelCause(ctx)
+	defer cancelGen(nil)
+	procCtx, cancelProc := context.WithCancelCause(genCtx)
+	defer cancelProc(nil)
+	collCtx, cancelColl := context.WithCancelCause(procCtx)
+	defer cancelColl(nil)
+
+	raw, processed := make(chan item), make(chan item)
+	n := max(env.IntParam("processors"), 1)
+	every := env.DurationParam("generate-every")
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(genCtx, "generator", &pipeStage{
+		name: "generator", out: raw, leaky: spec.leaky == "generator",
+		cost: func(int64) time.Duration { return every },
+	})
+	processTime := env.DurationParam("process-time")
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("processor-%d", i)
+		cost := func(int64) time.Duration { return processTime }
+		if i == spec.slow {
+			cost = func(n int64) time.Duration {
+				if n >= spec.slowFrom {
+					return spec.slowTime
+				}
+				return processTime
+			}
+		}
+		g.Launch(procCtx, name, &pipeStage{
+			name: name, in: raw, out: processed, leaky: spec.leaky == "processor",
+			cost: cost, timeout: spec.timeout, fail: cancelProc,
+		})
+	}
+	g.Launch(collCtx, "collector", &pipeStage{name: "collector", in: processed, leaky: spec.leaky == "collector"})
+	if spec.leaky != "" {
+		env.Printf("The %s stage has no ctx.Done() case in its channel operations.\n", spec.leaky)
+	}
+	if spec.timeout > 0 {
+		env.Printf("Each processor may take %v over a value before it cancels the processing stage.\n", spec.timeout)
+	}
+	if spec.slow > 0 {
+		env.Printf("processor-%d takes %v over each value from its value %d on.\n", spec.slow, spec.slowTime, spec.slowFrom)
+	}
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on every stage with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result(spec.name, cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak: blocked on a channel with nobody at the other end).\n", scenario.Names(pending))
+	} else {
+		env.Printf("Every stage left its receive, send or wait through ctx.Done().\n")
+	}
+	if res.Failed() > 0 {
+		env.Printf("The processing stage cancelled itself on a timeout; the cause reached the collector downstream, but not the generator upstream.\n")
+	}
+	return res, nil
+}
+
+// pipeStage is one stage of the pipeline in runPipeline. The generator has
+// no in, and the collector no out. For each value a stage receives, or
+// makes, it waits what cost says and passes the value on; processors, which
+// have both, sign it with their name.
+type pipeStage struct {
+	name string
+	in   <-chan item
+	out  chan<- item
+	// cost returns how long the stage takes over its n-th value. Nil means
+	// no time at all.
+	cost func(n int64) time.Duration
+	// timeout, if positive, is how long the stage may take over one value.
+	// A value that takes longer cancels the stage's context through fail,
+	// with a cause.DeadlineBudgetExhausted, and the stage returns it.
+	timeout time.Duration
+	fail    context.CancelCauseFunc
+	// leaky leaves out the ctx.Done() case of every receive, send and wait.
+	leaky bool
+
+	processed atomic.Int64
+}
+
+// Run implements worker.Worker.
+func (s *pipeStage) Run(ctx context.Context) error {
+	clk := clock.From(ctx)
+	for n := int64(1); ; n++ {
+		v := item{N: n}
+		if s.in != nil {
+			if s.leaky {
+				v = <-s.in
+			} else {
+				select {
+				case v = <-s.in:
+				case <-ctx.Done():
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: cancelled while waiting to receive, after %d value(s). Cause: %v", s.name, s.processed.Load(), context.Cause(ctx)))
+					return nil
+				}
+			}
+		}
+		if s.cost != nil {
+			if d := s.cost(n); s.leaky {
+				clk.Sleep(d)
+			} else if err := s.work(ctx, d, v); err != nil {
+				return err
+			} else if ctx.Err() != nil {
+				return nil
+			}
+		}
+		if s.in != nil && s.out != nil {
+			v.By = s.name
+		}
+		if s.out != nil {
+			if s.leaky {
+				s.out <- v
+			} else {
+				select {
+				case s.out <- v:
+				case <-ctx.Done():
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: cancelled while waiting to send value %d. Cause: %v", s.name, v.N, context.Cause(ctx)))
+					return nil
+				}
+			}
+		}
+		s.processed.Add(1)
+		if s.out == nil {
+			worker.ReportTick(ctx, s.processed.Load(), fmt.Sprintf("Collector received value %d from %s", v.N, v.By))
+		}
+	}
+}
+
+// work spends d on v under the stage's timeout. It returns the timeout's
+// cause, having cancelled the stage with it, if v overran the timeout. If
+// ctx was cancelled first it reports that and returns nil, and ctx.Err()
+// tells the caller to stop.
+func (s *pipeStage) work(ctx context.Context, d time.Duration, v item) error {
+	wctx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		wctx, cancel = clock.WithTimeoutCause(ctx, s.timeout, &cause.DeadlineBudgetExhausted{
+			Who:    s.name,
+			Why:    fmt.Sprintf("value %d took too long", v.N),
+			At:     clock.From(ctx).Now().Add(s.timeout),
+			Budget: s.timeout,
+		})
+		defer cancel()
+	}
+	if ctxutil.Sleep(wctx, d) == nil {
+		return nil
+	}
+	if ctx.Err() == nil {
+		err := context.Cause(wctx)
+		worker.Notef(ctx, "%s: value %d overran the %v stage timeout; cancelling the stage and everything downstream.", s.name, v.N, s.timeout)
+		s.fail(err)
+		return err
+	}
+	doing := "working on"
+	if s.in == nil {
+		doing = "about to produce"
+	}
+	worker.ReportCancel(ctx, fmt.Sprintf("%s: cancelled while %s value %d. Cause: %v", s.name, doing, v.N, context.Cause(ctx)))
+	return nil
+}
+
+// Processed implements worker.Counter.
+func (s *pipeStage) Processed() int64 { return s.processed.Load() }
@@ -0,0 +1,66 @@
+package builtin_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+)
+
+func TestPipeline(t *testing.T) {
+	errCause := errors.New("the pipeline is shut")
+	r := runScenario(t, "pipeline", contextdemo.WithCause(errCause))
+
+	stages := []string{"generator", "processor-1", "processor-2", "processor-3", "collector"}
+	for _, name := range stages {
+		e, ok := r.exits()[name]
+		if !ok {
+			t.Errorf("%s never exited", name)
+			continue
+		}
+		if !errors.Is(e.Cause, errCause) || !e.Time.Equal(r.res.CancelledAt) {
+			t.Errorf("%s exited at %v with cause %v, want at once, at %v, with %v",
+				name, r.at(e), e.Cause, r.res.CancelledAt.Sub(r.res.StartedAt), errCause)
+		}
+	}
+	exits := r.exits()
+	if got, sent := exits["collector"].Processed, exits["generator"].Processed; got > sent {
+		t.Errorf("the collector received %d values, more than the %d generated", got, sent)
+	}
+}
+
+func TestPipelineLeak(t *testing.T) {
+	for _, stage := range []string{"generator", "processor", "collector"} {
+		t.Run(stage, func(t *testing.T) {
+			r := runScenario(t, "pipeline-leak", contextdemo.WithParam("stage", stage))
+			r.check(t)
+
+			leaks := eventsOf[event.WorkerLeaked](r)
+			if len(leaks) == 0 {
+				t.Fatal("no stage leaked")
+			}
+			for _, l := range leaks {
+				if name := l.Worker; name != stage && !(stage == "processor" && slices.Contains([]string{"processor-1", "processor-2", "processor-3"}, name)) {
+					t.Errorf("%s leaked, but only the %s stage blocks without a ctx.Done() case", name, stage)
+				}
+			}
+		})
+	}
+}
+
+// TestPipelineLeakCountsProcessors checks that the expected leak count
+// follows -processors, and that -workers, which the pipeline does not use,
+// leaves it alone.
+func TestPipelineLeakCountsProcessors(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		r := runScenario(t, "pipeline-leak",
+			contextdemo.WithParam("processors", "5"),
+			contextdemo.WithWorkers(workers))
+		r.check(t)
+		if got := len(eventsOf[event.WorkerLeaked](r)); got != 5 || r.res.ExpectedLeaks != 5 {
+			t.Errorf("with %d worker(s), %d processor(s) leaked and %d were expected, want 5 and 5", workers, got, r.res.ExpectedLeaks)
+		}
+	}
+}
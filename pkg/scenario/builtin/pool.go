@@ -0,0 +1,112 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/pool"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("pool-policy", scenario.Metadata{
+		Description: "Submit potions to two saturated worker pools, then cancel them: one drains its accepted tasks, the other aborts them",
+		Outcome:     "Submissions wait while each pool is saturated and fail once it is cancelled. The draining pool brews every potion it accepted before it returns; the aborting pool interrupts those brewing and drops those queued.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    2500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "size", Kind: scenario.ParamInt, Default: "2", Usage: "goroutines in each pool"},
+			{Name: "queue", Kind: scenario.ParamInt, Default: "2", Usage: "tasks each pool queues while its goroutines are busy"},
+			{Name: "brew-time", Kind: scenario.ParamDuration, Default: "200ms", Usage: "how long each potion takes"},
+			{Name: "submit-every", Kind: scenario.ParamDuration, Default: "50ms", Usage: "how often a potion is submitted"},
+		},
+	}, runPool))
+}
+
+// runPool launches two apothecaries, each submitting potions to a
+// pool.Pool of its own, one with the Drain policy and one with Abort, and
+// cancels both at Env.CancelAfter.
+func runPool(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Bounded Worker Pools...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	shops := []struct {
+		name   string
+		policy pool.Policy
+		stats  atomic.Pointer[pool.Stats]
+	}{
+		{name: "apothecary-drain", policy: pool.Drain},
+		{name: "apothecary-abort", policy: pool.Abort},
+	}
+	size, queue := env.IntParam("size"), env.IntParam("queue")
+	brew, every := env.DurationParam("brew-time"), env.DurationParam("submit-every")
+
+	var g scenario.Group
+	defer g.Release()
+	for i := range shops {
+		shop := &shops[i]
+		g.Launch(ctx, shop.name, worker.Func(func(ctx context.Context) error {
+			p := pool.New(ctx, size, queue, shop.policy)
+			clk := clock.From(ctx)
+			var brewed atomic.Int64
+			for n := 1; ; n++ {
+				start := clk.Now()
+				err := p.Submit(ctx, func(ctx context.Context) error {
+					if err := ctxutil.Sleep(ctx, brew); err != nil {
+						return fmt.Errorf("potion %d: %w", n, err)
+					}
+					worker.ReportTick(ctx, brewed.Add(1), fmt.Sprintf("%s brewed potion %d", shop.name, n))
+					return nil
+				})
+				if err != nil {
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: potion %d refused (%v); the pool will %s what it accepted.", shop.name, n, err, shop.policy))
+					break
+				}
+				if waited := clock.Since(clk, start); waited > 0 {
+					worker.Notef(ctx, "%s: the pool was saturated; potion %d waited %v to be accepted.", shop.name, n, waited)
+				}
+				if ctxutil.Sleep(ctx, every) != nil {
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: no more potions; the pool will %s what it accepted.", shop.name, shop.policy))
+					break
+				}
+			}
+			p.Wait()
+			stats := p.Stats()
+			shop.stats.Store(&stats)
+			worker.Notef(ctx, "%s: pool stopped with %d brewed, %d interrupted and %d dropped.", shop.name, stats.Completed, stats.Failed, stats.Dropped)
+			return nil
+		}))
+	}
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on both apothecaries with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("pool-policy", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("%-18s %-8s %-8s %-12s %s\n", "POOL", "POLICY", "BREWED", "INTERRUPTED", "DROPPED")
+	for i := range shops {
+		if s := shops[i].stats.Load(); s != nil {
+			env.Printf("%-18s %-8s %-8d %-12d %d\n", shops[i].name, shops[i].policy, s.Completed, s.Failed, s.Dropped)
+		}
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("queue-drain", scenario.Metadata{
+		Description: "Cancel two queue workers side by side: one drains the letters it accepted, the other drops them",
+		Outcome:     "Both Owleries stop accepting letters at cancellation. The graceful one goes on sending its queue until it is empty or the drain deadline passes; the abrupt one exits at once, and its accepted letters are lost.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    2500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "drain", Kind: scenario.ParamDuration, Default: "1s", Usage: "how long the graceful worker may send accepted letters after cancellation"},
+			{Name: "capacity", Kind: scenario.ParamInt, Default: "4", Usage: "how many accepted letters each worker's queue holds"},
+		},
+	}, runQueueDrain))
+}
+
+// runQueueDrain runs the same queue worker twice, once with a drain
+// deadline and once without, and cancels both together.
+func runQueueDrain(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with Draining Queues...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	drain, capacity := env.DurationParam("drain"), env.IntParam("capacity")
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(ctx, "owlery-graceful", env.Workers, func() worker.Worker {
+		return &worker.Owlery{Interval: env.TickInterval, Capacity: capacity, Drain: drain}
+	})
+	g.Spawn(ctx, "owlery-abrupt", env.Workers, func() worker.Worker {
+		return &worker.Owlery{Interval: env.TickInterval, Capacity: capacity}
+	})
+
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("queue-drain", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Both workers stopped pulling letters at once; only the graceful one finished those it had already accepted.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s (goroutine leak).\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("quorum", scenario.Metadata{
+		Description:      "Cancel replicated work only once K of its N workers have failed",
+		Outcome:          "The first Knight Bus crash is tolerated and the replicas carry on; the second makes a quorum, and everyone left is cancelled with both crashes joined into one cause. The third bus is cancelled before it gets to crash.",
+		Tags:             []string{scenario.TagErrors, scenario.TagCause},
+		ExpectedFailures: 2,
+		Duration:         time.Second,
+		Params: []scenario.Param{
+			{Name: "quorum", Kind: scenario.ParamInt, Default: "2", Usage: "number of failed workers that cancels the rest"},
+		},
+	}, runQuorum))
+}
+
+// runQuorum runs two healthy replicas and three that crash after 2, 4 and
+// 6 units of work under Group.CancelOnQuorum.
+func runQuorum(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Failure Quorum...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	outer, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	k := env.IntParam("quorum")
+	if k < 1 {
+		return nil, fmt.Errorf("quorum %d must be at least 1", k)
+	}
+	var g scenario.Group
+	defer g.Release()
+	ctx := g.CancelOnQuorum(outer, k)
+	g.Spawn(ctx, "hogwarts", 2, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+	for i, stops := range []int{2, 4, 6} {
+		g.Launch(ctx, fmt.Sprintf("knight-bus-%d", i+1), &worker.Flaky{Interval: env.TickInterval, FailAfter: stops})
+	}
+
+	env.Printf("\nAllowing replicas to run for up to %v, unless %d fail first...\n", env.CancelAfter, k)
+	timer := env.Clock.NewTimer(env.CancelAfter)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> %d replicas failed; the group cancelled the rest with cause: '%v' <<<\n", k, context.Cause(ctx))
+	case <-timer.C():
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	env.Printf("Waiting up to %v for workers to respond to cancellation...\n\n\n", env.Grace())
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("quorum", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("%d replica(s) failed; the cancellation waited for %d of them.\n", res.Failed(), k)
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
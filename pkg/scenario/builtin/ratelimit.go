@@ -0,0 +1,91 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/ratelimit"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("rate-limit", scenario.Metadata{
+		Description: "Throttle Gringotts clerks to a shared number of withdrawals a second with a token-bucket limiter, then cancel them while they wait",
+		Outcome:     "After the initial burst the clerks together make no more withdrawals a second than the limit, each waiting its turn in Wait. On cancellation every clerk waiting for a token is released at once, and Wait returns the cancellation's cause, not a generic error.",
+		Tags:        []string{scenario.TagCause, scenario.TagShutdown},
+		Duration:    2000 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "rate", Kind: scenario.ParamInt, Default: "5", Usage: "withdrawals a second the clerks may make between them"},
+			{Name: "burst", Kind: scenario.ParamInt, Default: "2", Usage: "withdrawals that may be made at once"},
+			{Name: "clerks", Kind: scenario.ParamInt, Default: "3", Usage: "clerks sharing the limiter"},
+		},
+	}, runRateLimit))
+}
+
+// runRateLimit launches clerks that take a token from one shared
+// ratelimit.Limiter before each withdrawal, and cancels them at
+// Env.CancelAfter, when most are waiting for one.
+func runRateLimit(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Rate Limiter...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	rate, burst := env.IntParam("rate"), env.IntParam("burst")
+	lim := ratelimit.New(ratelimit.Limit(rate), burst)
+	env.Printf("The clerks may make %d withdrawal(s) a second between them, in bursts of up to %d.\n", rate, lim.Burst())
+
+	clerks := make([]struct {
+		name     string
+		released atomic.Pointer[error] // what Wait returned on cancellation
+	}, max(env.IntParam("clerks"), 1))
+	var total atomic.Int64
+
+	var g scenario.Group
+	defer g.Release()
+	for i := range clerks {
+		c := &clerks[i]
+		c.name = fmt.Sprintf("clerk-%d", i+1)
+		g.Launch(ctx, c.name, worker.Func(func(ctx context.Context) error {
+			for n := int64(1); ; n++ {
+				if err := lim.Wait(ctx); err != nil {
+					c.released.Store(&err)
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: released from Wait before withdrawal %d. Cause: %v", c.name, n, err))
+					return nil
+				}
+				worker.ReportTick(ctx, n, fmt.Sprintf("%s made withdrawal %d, %d in all", c.name, n, total.Add(1)))
+			}
+		}))
+	}
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with %d clerk(s) waiting for a token, with cause: '%v' <<<\n", lim.Waiting(), env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("rate-limit", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	made := total.Load()
+	env.Printf("Withdrawals: %d in %v: a burst of %d, then %.1f a second against a limit of %d.\n",
+		made, env.CancelAfter, min(made, int64(lim.Burst())), float64(max(made-int64(lim.Burst()), 0))/env.CancelAfter.Seconds(), rate)
+	env.Printf("%-10s %s\n", "CLERK", "WAIT RETURNED")
+	for i := range clerks {
+		if err := clerks[i].released.Load(); err != nil {
+			env.Printf("%-10s %v\n", clerks[i].name, *err)
+		}
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
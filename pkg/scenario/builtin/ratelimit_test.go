@@ -0,0 +1,35 @@
+package builtin_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+)
+
+func TestRateLimit(t *testing.T) {
+	errCause := errors.New("Gringotts is closing")
+	r := runScenario(t, "rate-limit",
+		contextdemo.WithCancelAfter(2*time.Second), contextdemo.WithCause(errCause),
+		contextdemo.WithParam("rate", "5"), contextdemo.WithParam("burst", "2"), contextdemo.WithParam("clerks", "3"))
+
+	// The clerks share the limit: a burst of 2, then 5 a second.
+	if n, most := len(eventsOf[event.TickCompleted](r)), 2+5*2; n > most || n < most-1 {
+		t.Errorf("the clerks made %d withdrawals in 2s, want %d or just under", n, most)
+	}
+	for _, name := range []string{"clerk-1", "clerk-2", "clerk-3"} {
+		c, ok := r.cancellations()[name]
+		if !ok {
+			t.Errorf("%s was never released from Wait", name)
+			continue
+		}
+		if !errors.Is(c.Cause, errCause) {
+			t.Errorf("%s: Wait returned %v, want the cause %v", name, c.Cause, errCause)
+		}
+		if !c.Time.Equal(r.res.CancelledAt) {
+			t.Errorf("%s was released at %v, want at once, at 2s", name, r.at(c))
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/rungroup"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("rungroup", scenario.Metadata{
+		Description: "Start dependent services in order and stop them in reverse with per-stop timeouts",
+		Outcome:     "The services stop in reverse start order, and Gringotts overruns its stop timeout, which the run group reports as an error.",
+		Tags:        []string{scenario.TagShutdown, scenario.TagTimeout},
+		Duration:    2 * time.Second,
+		Params: []scenario.Param{
+			{Name: "stop-timeout", Kind: scenario.ParamDuration, Default: "300ms", Usage: "time each service is given to stop"},
+		},
+	}, runRunGroup))
+}
+
+// service is a simulated component: a Hogwarts worker with its own context,
+// and a shutdown that takes stopDelay before it begins.
+type service struct {
+	name      string
+	stopDelay time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *service) start(ctx context.Context) error {
+	// The service's own context is detached from ctx: it must keep running
+	// until the group stops it, not as soon as the group is cancelled.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		worker.Execute(runCtx, s.name, &worker.Hogwarts{Interval: time.Second})
+	}()
+	return nil
+}
+
+func (s *service) stop(ctx context.Context) error {
+	if err := ctxutil.Sleep(ctx, s.stopDelay); err != nil {
+		return err
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// runRunGroup brings up three services that depend on one another and
+// cancels the group. They stop in reverse order; Gringotts takes longer than
+// its stop timeout allows.
+func runRunGroup(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Run Group...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	services := []*service{
+		{name: "gringotts", stopDelay: time.Second},
+		{name: "owlery", stopDelay: 50 * time.Millisecond},
+		{name: "great-hall", stopDelay: 100 * time.Millisecond},
+	}
+	rg := &rungroup.Group{StopTimeout: env.DurationParam("stop-timeout")}
+	for _, s := range services {
+		rg.Add(s.name, s.start, s.stop)
+	}
+	// Reap any service whose stop timed out, and wait for it, so it does not
+	// outlive the scenario.
+	defer func() {
+		for _, s := range services {
+			if s.cancel != nil {
+				s.cancel()
+				<-s.done
+			}
+		}
+	}()
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "rungroup", worker.Func(func(ctx context.Context) error {
+		if err := rg.Run(ctx); err != nil {
+			env.Printf("Run group: shutdown finished with errors: %v\n", err)
+		}
+		return nil
+	}))
+
+	env.Printf("\nAllowing the services to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("rungroup", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("The services stopped in reverse start order; Gringotts overran its stop timeout.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,23 @@
+package builtin_test
+
+import "testing"
+
+func TestRungroup(t *testing.T) {
+	r := runScenario(t, "rungroup")
+
+	exits := r.exits()
+	// Gringotts starts first, then the owlery, then the great hall, and
+	// they stop in the reverse order.
+	if !exits["great-hall"].Time.Before(exits["owlery"].Time) {
+		t.Errorf("owlery stopped at %v, before great-hall at %v", r.at(exits["owlery"]), r.at(exits["great-hall"]))
+	}
+	// Gringotts outlives its stop timeout, and the group returns without
+	// waiting for it.
+	group, gringotts := exits["rungroup"], exits["gringotts"]
+	if !exits["owlery"].Time.Before(group.Time) {
+		t.Errorf("the group returned at %v, before owlery stopped", r.at(group))
+	}
+	if gringotts.Time.Before(group.Time) {
+		t.Errorf("gringotts stopped at %v, within its stop timeout", r.at(gringotts))
+	}
+}
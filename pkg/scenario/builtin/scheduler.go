@@ -0,0 +1,100 @@
+package builtin
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/schedule"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("scheduler", scenario.Metadata{
+		Description: "Run interval and cron jobs from a scheduler bound to a context, then cancel it with a job in flight",
+		Outcome:     "The jobs run on their schedules until the scheduler is cancelled. The backup in flight at that moment stops part way through, no job starts afterwards, and the scheduler returns only once it has.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "backup-every", Kind: scenario.ParamDuration, Default: "600ms", Usage: "how often the backup job runs"},
+			{Name: "backup-time", Kind: scenario.ParamDuration, Default: "500ms", Usage: "how long a backup takes"},
+			{Name: "cron", Kind: scenario.ParamString, Default: "*/1 * * * * *", Usage: "cron expression, with seconds, for the report job"},
+		},
+	}, runScheduler))
+}
+
+// runScheduler launches a schedule.Scheduler with a heartbeat, a backup
+// and a cron-driven report job, cancels it, and tabulates what each job
+// did.
+func runScheduler(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Scheduler...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	report, err := schedule.ParseCron(env.Param("cron"))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	backupTime := env.DurationParam("backup-time")
+	s := &schedule.Scheduler{Jobs: []schedule.Job{
+		{Name: "heartbeat", Schedule: schedule.Every(cmp.Or(env.TickInterval, worker.DefaultHogwartsInterval)), Run: func(ctx context.Context) error {
+			worker.Notef(ctx, "heartbeat: all is well.")
+			return nil
+		}},
+		{Name: "backup", Schedule: schedule.Every(env.DurationParam("backup-every")), Run: func(ctx context.Context) error {
+			worker.Notef(ctx, "backup: copying the restricted section (%v)...", backupTime)
+			if err := ctxutil.Sleep(ctx, backupTime); err != nil {
+				worker.Notef(ctx, "backup: abandoned part way through: %v", err)
+				return err
+			}
+			worker.Notef(ctx, "backup: done.")
+			return nil
+		}},
+		{Name: "report", Schedule: report, Run: func(ctx context.Context) error {
+			worker.Notef(ctx, "report: owls counted at %s.", env.Clock.Now().Format("15:04:05"))
+			return nil
+		}},
+	}}
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "scheduler", s)
+
+	var inFlight []string
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		for _, j := range s.Jobs {
+			if s.Stats(j.Name).Running {
+				inFlight = append(inFlight, j.Name)
+			}
+		}
+		env.Printf("\n>>> Calling cancel(cause) on the scheduler with %d job(s) in flight, cause: '%v' <<<\n", len(inFlight), env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("scheduler", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("  %-10s %-16s %-5s %-7s %-8s %s\n", "JOB", "SCHEDULE", "RUNS", "FAILED", "SKIPPED", "AT CANCEL")
+	for _, j := range s.Jobs {
+		st := s.Stats(j.Name)
+		at := "idle"
+		if slices.Contains(inFlight, j.Name) {
+			at = "in flight"
+		}
+		env.Printf("  %-10s %-16v %-5d %-7d %-8d %s\n", j.Name, j.Schedule, st.Runs, st.Failed, st.Skipped, at)
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
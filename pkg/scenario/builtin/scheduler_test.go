@@ -0,0 +1,33 @@
+package builtin_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+)
+
+func TestScheduler(t *testing.T) {
+	errCause := errors.New("the castle is closing")
+	r := runScenario(t, "scheduler", contextdemo.WithCause(errCause))
+
+	e, ok := r.exits()["scheduler"]
+	if !ok {
+		t.Fatal("the scheduler never exited")
+	}
+	if !errors.Is(e.Cause, errCause) {
+		t.Errorf("the scheduler exited with cause %v, want %v", e.Cause, errCause)
+	}
+	// The backup in flight stops the moment the scheduler is cancelled,
+	// and no job runs afterwards.
+	for _, n := range eventsOf[event.Note](r) {
+		if n.Worker == "" || n.Worker == "scheduler" || !n.Time.After(r.res.CancelledAt) {
+			continue
+		}
+		t.Errorf("%s narrated at %v, after the cancellation: %q", n.Worker, r.at(n), n.Message)
+	}
+	if e.Time.Before(r.res.CancelledAt) {
+		t.Errorf("the scheduler exited at %v, before it was cancelled", r.at(e))
+	}
+}
@@ -0,0 +1,108 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/scope"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("scope", scenario.Metadata{
+		Description: "Run Hogwarts houses as the children of a structured-concurrency scope that cannot return before they do",
+		Outcome:     "Cancelling the castle cancels every house through the scope. The castle returns only once the slowest house has finished its cleanup, and starting a house after that panics instead of leaking it.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "linger", Kind: scenario.ParamDuration, Default: "300ms", Usage: "how long slytherin keeps cleaning up after it is cancelled"},
+		},
+	}, runScope))
+}
+
+// houses are the Hogwarts workers run as children of the castle's scope.
+var houses = []string{"gryffindor", "hufflepuff", "ravenclaw"}
+
+// runScope launches a single castle worker that runs the houses in a
+// scope.Scope, cancels the castle, and then tries to start one more house
+// in the scope it has left.
+func runScope(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Structured-Concurrency Scope...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	linger := env.DurationParam("linger")
+	var held atomic.Pointer[scope.Scope]
+	castle := worker.Func(func(ctx context.Context) error {
+		return scope.Run(ctx, func(s *scope.Scope) error {
+			held.Store(s)
+			for _, name := range houses {
+				h := &worker.Hogwarts{Interval: env.TickInterval}
+				s.Go(func(ctx context.Context) error {
+					return h.Run(worker.WithWorkerName(ctx, name))
+				})
+			}
+			s.Go(func(ctx context.Context) error {
+				return slytherin(worker.WithWorkerName(ctx, "slytherin"), env.TickInterval, linger)
+			})
+			worker.Notef(ctx, "Castle: %d houses started in the scope.", len(houses)+1)
+			return nil
+		})
+	})
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "castle", castle)
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) on the castle with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("scope", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	if s := held.Load(); s != nil && len(pending) == 0 {
+		env.Printf("The castle returned only after every house had. Starting one more house now: %v.\n", goAfterReturn(s))
+	}
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
+
+// slytherin works like Hogwarts, ticking every interval, until ctx is
+// cancelled, then spends linger on cleanup it does not interrupt, which
+// keeps the scope from returning.
+func slytherin(ctx context.Context, interval, linger time.Duration) error {
+	clk := clock.From(ctx)
+	h := &worker.Hogwarts{Interval: interval}
+	h.Run(ctx)
+	worker.Notef(ctx, "Slytherin: cleaning up for %v before it returns.", linger)
+	clk.Sleep(linger)
+	worker.Notef(ctx, "Slytherin: cleanup done.")
+	return nil
+}
+
+// goAfterReturn calls s.Go once the scope's Run has returned and reports
+// how it panicked.
+func goAfterReturn(s *scope.Scope) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+	s.Go(func(context.Context) error { return nil })
+	return "no panic"
+}
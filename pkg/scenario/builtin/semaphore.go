@@ -0,0 +1,95 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/semaphore"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("semaphore", scenario.Metadata{
+		Description: "Share the library's desks through a weighted semaphore, and cancel while students are queued for them",
+		Outcome:     "Students queue for desks in the order they asked. On cancellation those studying give their desks back, and those still queued are released from Acquire at once with the cause, without ever being handed a desk.",
+		Tags:        []string{scenario.TagShutdown},
+		Duration:    2000 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "desks", Kind: scenario.ParamInt, Default: "4", Usage: "desks in the library, the semaphore's weight"},
+			{Name: "study-time", Kind: scenario.ParamDuration, Default: "300ms", Usage: "how long a student keeps their desks"},
+		},
+	}, runSemaphore))
+}
+
+// students are the semaphore scenario's workers and the desks each needs.
+var students = []struct {
+	name  string
+	desks int64
+}{
+	{"hermione", 3},
+	{"ron", 1},
+	{"harry", 2},
+	{"neville", 2},
+	{"luna", 1},
+}
+
+// runSemaphore launches students who take turns at the library's desks
+// through a semaphore.Weighted, and cancels them at Env.CancelAfter.
+func runSemaphore(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Weighted Semaphore...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	desks := int64(env.IntParam("desks"))
+	study := env.DurationParam("study-time")
+	sem := semaphore.NewWeighted(desks)
+	env.Printf("The library has %d desks.\n", desks)
+
+	var g scenario.Group
+	defer g.Release()
+	for _, s := range students {
+		g.Launch(ctx, s.name, worker.Func(func(ctx context.Context) error {
+			clk := clock.From(ctx)
+			for n := int64(1); ; n++ {
+				start := clk.Now()
+				if err := sem.Acquire(ctx, s.desks); err != nil {
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: released from the queue for %d desk(s) after %v. Cause: %v", s.name, s.desks, clock.Since(clk, start), err))
+					return nil
+				}
+				waited := clock.Since(clk, start)
+				if ctxutil.Sleep(ctx, study) != nil {
+					sem.Release(s.desks)
+					worker.ReportCancel(ctx, fmt.Sprintf("%s: cancelled while studying; gave back %d desk(s). Cause: %v", s.name, s.desks, context.Cause(ctx)))
+					return nil
+				}
+				sem.Release(s.desks)
+				worker.ReportTick(ctx, n, fmt.Sprintf("%s studied at %d desk(s) after queueing %v", s.name, s.desks, waited))
+			}
+		}))
+	}
+
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with %d desk(s) in use and %d student(s) queued, with cause: '%v' <<<\n", sem.Held(), sem.Waiting(), env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("semaphore", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Desks in use: %d. Students still queued: %d.\n", sem.Held(), sem.Waiting())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,33 @@
+package builtin_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/context-demo/pkg/contextdemo"
+	"github.com/context-demo/pkg/event"
+)
+
+func TestSemaphore(t *testing.T) {
+	errCause := errors.New("the library is closing")
+	r := runScenario(t, "semaphore", contextdemo.WithCause(errCause))
+
+	// Nobody does any work once cancelled, and everyone, queued or
+	// studying, is released at the moment of cancellation with its cause.
+	for _, e := range eventsOf[event.TickCompleted](r) {
+		if e.Time.After(r.res.CancelledAt) {
+			t.Errorf("%s finished studying at %v, after the cancellation", e.Worker, r.at(e))
+		}
+	}
+	cancellations := r.cancellations()
+	for _, name := range []string{"harry", "hermione", "ron", "neville", "luna"} {
+		c, ok := cancellations[name]
+		if !ok {
+			t.Errorf("%s was never released", name)
+			continue
+		}
+		if !errors.Is(c.Cause, errCause) || !c.Time.Equal(r.res.CancelledAt) {
+			t.Errorf("%s was released at %v with cause %v, want at once with %v", name, r.at(c), c.Cause, errCause)
+		}
+	}
+}
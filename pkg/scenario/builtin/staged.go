@@ -0,0 +1,79 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/shutdown"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("staged-teardown", scenario.Metadata{
+		Description: "Tear workers down in priority order: background jobs, then request handlers, then critical flushers",
+		Outcome:     "Each stage is cancelled with its own cause only once the stage before it has exited: the background job first, then the handler, and last the flusher, which drains the letters it accepted before it exits.",
+		Tags:        []string{scenario.TagShutdown, scenario.TagCause},
+		Duration:    2500 * time.Millisecond,
+	}, runStaged))
+}
+
+// runStaged starts one group of workers per shutdown.Stage and lets
+// shutdown.Staged cancel them in order, waiting on each group in turn.
+func runStaged(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Staged Teardown...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	var stages shutdown.Staged
+	defer stages.CancelAll()
+	type stageGroup struct {
+		stage *shutdown.Stage
+		group scenario.Group
+	}
+	var all []*stageGroup
+	add := func(name string, priority int, newWorker func() worker.Worker) {
+		sg := &stageGroup{stage: stages.Add(parent, name, priority, fmt.Errorf("staged teardown: %s stopped (priority %d)", name, priority))}
+		sg.group.Spawn(sg.stage.Context(), name, env.Workers, newWorker)
+		all = append(all, sg)
+	}
+	// Added out of order on purpose: Teardown goes by priority.
+	add("flusher", 2, func() worker.Worker { return &worker.Owlery{Interval: env.TickInterval, Drain: env.Grace() / 2} })
+	add("handler", 1, func() worker.Worker { return &worker.Hogwarts{Interval: env.TickInterval} })
+	add("background-job", 0, func() worker.Worker { return &worker.Hogwarts{Interval: env.TickInterval} })
+	for _, sg := range all {
+		defer sg.group.Release()
+	}
+
+	var pending []*scenario.Instance
+	env.Printf("\nAllowing workers to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		stages.Teardown(func(st *shutdown.Stage) {
+			env.Printf("\n>>> Stage %q (priority %d): cancelling with cause: '%v' <<<\n", st.Name, st.Priority, st.Cause)
+			for _, sg := range all {
+				if sg.stage == st {
+					pending = append(pending, sg.group.Wait(env.Grace())...)
+				}
+			}
+		})
+	}
+
+	env.Enter(scenario.PhaseGraceEnd)
+	res := &scenario.Result{Scenario: "staged-teardown"}
+	for _, sg := range all {
+		r := sg.group.Result("staged-teardown", sg.stage.CancelledAt)
+		res.Workers = append(res.Workers, r.Workers...)
+		if at := sg.stage.CancelledAt; !at.IsZero() && (res.CancelledAt.IsZero() || at.Before(res.CancelledAt)) {
+			res.CancelledAt = at
+		}
+	}
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Each stage was cancelled only after the one before it had exited; the flusher went last and drained.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,30 @@
+package builtin_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStagedTeardown(t *testing.T) {
+	r := runScenario(t, "staged-teardown")
+
+	exits := r.exits()
+	order := []string{"background-job", "handler", "flusher"}
+	for i, name := range order {
+		e, ok := exits[name]
+		if !ok {
+			t.Fatalf("%s never exited", name)
+		}
+		if e.Cause == nil || !strings.Contains(e.Cause.Error(), name) {
+			t.Errorf("%s exited with cause %v, want its own stage's", name, e.Cause)
+		}
+		c := r.cancellations()[name]
+		if i > 0 && c.Time.Before(exits[order[i-1]].Time) {
+			t.Errorf("%s was cancelled at %v, before %s exited at %v", name, r.at(c), order[i-1], r.at(exits[order[i-1]]))
+		}
+	}
+	// The flusher drains the letters it accepted before it exits.
+	if f := exits["flusher"]; !f.Time.After(r.cancellations()["flusher"].Time) {
+		t.Errorf("the flusher exited as soon as it was cancelled, at %v, without draining", r.at(f))
+	}
+}
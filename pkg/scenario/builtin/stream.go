@@ -0,0 +1,71 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker/stream"
+)
+
+func init() {
+	scenario.Register(scenario.New("stream", scenario.Metadata{
+		Description: "Cancel a typed producer and watch it close its result channel",
+		Outcome:     "The producer sees ctx.Done() and closes its channel, which ends the consumer's range loop.",
+		Tags:        []string{scenario.TagChannels, scenario.TagCause},
+		Duration:    1500 * time.Millisecond,
+	}, runStream))
+}
+
+// spells are handed out, in order, by the producer in runStream.
+var spells = []string{"Lumos", "Alohomora", "Expelliarmus", "Wingardium Leviosa", "Expecto Patronum"}
+
+// spell is the value type streamed by the producer.
+type spell struct {
+	N    int64
+	Name string
+}
+
+// runStream drains a stream.Generator of spells. On cancellation the
+// producer stops, closes its channel, and the consumer's range loop ends.
+func runStream(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Typed Producer...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	producer := &stream.Generator[spell]{
+		Interval: env.TickInterval,
+		Next: func(n int64) spell {
+			return spell{N: n, Name: spells[(n-1)%int64(len(spells))]}
+		},
+	}
+	consumer := stream.Drain(producer, func(ctx context.Context, s spell) {
+		env.Printf("Received spell #%d: %s\n", s.N, s.Name)
+	})
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "spell-stream", consumer)
+
+	env.Printf("\nAllowing the producer to run for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("stream", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("The producer observed ctx.Done(), closed its channel, and the consumer's range loop ended.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,66 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/supervisor"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("supervisor", scenario.Metadata{
+		Description: "Restart crashing workers with backoff until the parent context is cancelled",
+		Outcome:     "The supervisor restarts its crashing children a few times and stops restarting once it is cancelled.",
+		Tags:        []string{scenario.TagErrors, scenario.TagShutdown},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "backoff", Kind: scenario.ParamDuration, Default: "50ms", Usage: "delay before the first restart"},
+			{Name: "max-backoff", Kind: scenario.ParamDuration, Default: "400ms", Usage: "upper bound on the restart delay"},
+		},
+	}, runSupervisor))
+}
+
+// runSupervisor runs two Knight Buses under a supervisor: one that returns
+// an error and one that panics. Both are restarted over and over until the
+// scenario cancels the supervisor's context.
+func runSupervisor(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Supervisor...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	sup := &supervisor.Supervisor{
+		Children: []supervisor.Child{
+			{Name: "knight-bus-error", Worker: &worker.Flaky{Interval: env.TickInterval, FailAfter: 2}},
+			{Name: "knight-bus-panic", Worker: &worker.Flaky{Interval: env.TickInterval, FailAfter: 3, Panic: true}},
+		},
+		Backoff: supervisor.Exponential(env.DurationParam("backoff"), env.DurationParam("max-backoff")),
+	}
+
+	var g scenario.Group
+	defer g.Release()
+	g.Launch(ctx, "supervisor", sup)
+
+	env.Printf("\nAllowing the supervisor to restart crashing workers for %v...\n", env.CancelAfter)
+	if env.Sleep(env.CancelAfter) {
+		env.Enter(scenario.PhaseCancel)
+		env.Printf("\n>>> Calling cancel(cause) with cause: '%v' <<<\n", env.Cause)
+		cancel(env.Cause)
+	}
+	cancelledAt := env.Clock.Now()
+
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("supervisor", cancelledAt)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("The supervisor restarted its children %d times, then stopped restarting once cancelled.\n", sup.Restarts())
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
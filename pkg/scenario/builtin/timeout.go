@@ -0,0 +1,59 @@
+package builtin
+
+import (
+	"context"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("timeout", scenario.Metadata{
+		Description: "Let a deadline cancel a well-behaved worker with context.DeadlineExceeded",
+		Outcome:     "Hogwarts stops when its deadline passes, reporting context.DeadlineExceeded as both error and cause.",
+		Tags:        []string{scenario.TagTimeout},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "deadline", Kind: scenario.ParamDuration, Usage: "how long the worker has before its deadline (default: the cancel-after delay)"},
+		},
+	}, runTimeout))
+}
+
+// runTimeout cancels Hogwarts with a deadline rather than an explicit call to
+// cancel, so both ctx.Err() and context.Cause() report DeadlineExceeded.
+// The deadline parameter sets the timeout, falling back to Env.CancelAfter.
+func runTimeout(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Timeout...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	timeout := env.CancelAfter
+	if d := env.DurationParam("deadline"); d > 0 {
+		timeout = d
+	}
+	ctx, cancel := clock.WithTimeout(parent, timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(ctx, "hogwarts", env.Workers, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+
+	env.Printf("\nHogwarts has %v before its deadline...\n", timeout)
+	env.Enter(scenario.PhaseCancel)
+	<-ctx.Done()
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("timeout", deadline)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("Hogwarts shut down when the deadline passed, reporting context.DeadlineExceeded.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
@@ -0,0 +1,28 @@
+package builtin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/contextdemo"
+)
+
+func TestTimeout(t *testing.T) {
+	r := runScenario(t, "timeout", contextdemo.WithCancelAfter(time.Second))
+
+	c, ok := r.cancellations()["hogwarts"]
+	if !ok {
+		t.Fatal("hogwarts never received the cancellation")
+	}
+	if !errors.Is(c.Err, context.DeadlineExceeded) || !errors.Is(c.Cause, context.DeadlineExceeded) {
+		t.Errorf("hogwarts saw error %v and cause %v, want context.DeadlineExceeded for both", c.Err, c.Cause)
+	}
+	if got := r.at(c); got != time.Second {
+		t.Errorf("hogwarts received the cancellation at %v, want the 1s deadline", got)
+	}
+	if e := r.exits()["hogwarts"]; !errors.Is(e.CtxErr, context.DeadlineExceeded) {
+		t.Errorf("hogwarts exited with ctx.Err() %v, want context.DeadlineExceeded", e.CtxErr)
+	}
+}
@@ -0,0 +1,69 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/scenario"
+	"github.com/context-demo/pkg/worker"
+)
+
+func init() {
+	scenario.Register(scenario.New("timeout-cause", scenario.Metadata{
+		Description: "Compare a plain deadline with one set by context.WithDeadlineCause, side by side",
+		Outcome:     "Both Hogwarts workers stop at the same deadline with ctx.Err() = context.DeadlineExceeded, but only the one under WithDeadlineCause says why through context.Cause(); the other's cause is DeadlineExceeded too.",
+		Tags:        []string{scenario.TagTimeout, scenario.TagCause},
+		Duration:    1500 * time.Millisecond,
+		Params: []scenario.Param{
+			{Name: "deadline", Kind: scenario.ParamDuration, Usage: "how long the workers have before their deadline (default: the cancel-after delay)"},
+			{Name: "deadline-cause", Default: "the Hogwarts Express has left platform nine and three-quarters", Usage: "the cause the deadline reports through context.Cause"},
+		},
+	}, runTimeoutCause))
+}
+
+// runTimeoutCause gives two Hogwarts workers the same deadline, one from
+// clock.WithDeadline and one from clock.WithDeadlineCause, and prints what
+// each context's Err and Cause report once it has passed.
+func runTimeoutCause(parent context.Context, env *scenario.Env) (*scenario.Result, error) {
+	env.Printf("\n\nStarting Context Demonstration with a Deadline Cause...\n\n")
+	env.Printf("---------------------------------------------------\n")
+
+	timeout := env.CancelAfter
+	if d := env.DurationParam("deadline"); d > 0 {
+		timeout = d
+	}
+	deadline := env.Clock.Now().Add(timeout)
+	plain, cancelPlain := clock.WithDeadline(parent, deadline)
+	defer cancelPlain()
+	caused, cancelCaused := clock.WithDeadlineCause(parent, deadline, errors.New(env.Param("deadline-cause")))
+	defer cancelCaused()
+
+	var g scenario.Group
+	defer g.Release()
+	g.Spawn(plain, "hogwarts-plain", env.Workers, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+	g.Spawn(caused, "hogwarts-cause", env.Workers, func() worker.Worker {
+		return &worker.Hogwarts{Interval: env.TickInterval}
+	})
+
+	env.Printf("\nBoth workers have %v before their deadline...\n", timeout)
+	env.Enter(scenario.PhaseCancel)
+	<-plain.Done()
+	<-caused.Done()
+	pending := g.Wait(env.Grace())
+	env.Enter(scenario.PhaseGraceEnd)
+	res := g.Result("timeout-cause", deadline)
+
+	env.Printf("\n\n---------------------------------------------------\n")
+	env.Printf("Demonstration complete. \n\n")
+	env.Printf("WithDeadline:      ctx.Err() = %v; context.Cause(ctx) = %v\n", plain.Err(), context.Cause(plain))
+	env.Printf("WithDeadlineCause: ctx.Err() = %v; context.Cause(ctx) = %v\n", caused.Err(), context.Cause(caused))
+	env.Printf("Err is the same either way; only the cause tells the two deadlines apart.\n")
+	if len(pending) > 0 {
+		env.Printf("Never signalled completion: %s.\n", scenario.Names(pending))
+	}
+	return res, nil
+}
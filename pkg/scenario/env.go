@@ -0,0 +1,152 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/rng"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Defaults used when the corresponding Env field is left at its zero value.
+const (
+	DefaultCancelAfter = 1500 * time.Millisecond
+	DefaultRunFor      = 3500 * time.Millisecond
+)
+
+// DefaultCause is the cancellation cause used when Env.Cause is nil.
+var DefaultCause = errors.New("Voldemort is here: all tasks stopped")
+
+// Env carries the parameters shared by every scenario.
+type Env struct {
+	// Logger narrates the demonstration's events. Defaults to worker.Stdout;
+	// use worker.Discard to silence the narration.
+	Logger worker.Logger
+	// Sinks receive every event alongside the Logger.
+	Sinks []event.Sink
+	// CancelAfter is how long workers run before the scenario cancels them.
+	CancelAfter time.Duration
+	// RunFor bounds the total duration of the scenario, measured from its
+	// start. Whatever is left after CancelAfter is the longest the scenario
+	// waits for cancelled workers to signal completion.
+	RunFor time.Duration
+	// GracePeriod, if positive, overrides how long the scenario waits for
+	// cancelled workers after cancellation; see Grace.
+	GracePeriod time.Duration
+	// TickInterval is how often workers started by scenarios do a unit of
+	// work. Zero leaves each worker at its own default.
+	TickInterval time.Duration
+	// Cause is passed to cancel functions that accept one.
+	Cause error
+	// Clock keeps time for the scenario and, through the context, for its
+	// workers. Defaults to clock.Real.
+	Clock clock.Clock
+	// Rand is the source of every random choice made during the run, and
+	// reaches workers through the context. Defaults to a Rand with a fresh
+	// seed, which the result reports.
+	Rand *rng.Rand
+	// Jitter varies the intervals of workers that tick with ctxutil.Tick,
+	// and reaches them through the context. The zero value leaves every
+	// interval fixed.
+	Jitter rng.Jitter
+	// Middleware decorates the context of every worker launched through a
+	// Group, in order.
+	Middleware []ctxmw.Middleware
+	// Workers is the number of instances scenarios start of each worker
+	// type. Defaults to 1.
+	Workers int
+	// Watch, if positive, is how often the number of goroutines in the
+	// process is sampled and published while the scenario runs.
+	Watch time.Duration
+	// HardKill, if positive, is how much longer than CancelAfter plus Grace
+	// the scenario may run before Kill is called, to stop a shutdown that
+	// hangs. It is measured on the wall clock from the start of the run.
+	HardKill time.Duration
+	// Kill is called, in its own goroutine, if the scenario outlives
+	// HardKill. It is meant not to return, as with watchdog.Kill.
+	Kill func()
+	// Step, if set, is called each time the scenario reaches a Phase, and
+	// the scenario waits for it to return. It is how step mode pauses a run.
+	Step func(p Phase)
+	// Params holds values for the scenario's own parameters, keyed by
+	// name. The Runner fills in defaults; see Metadata.Params.
+	Params map[string]string
+
+	// ctx is the scenario's context as set up by the Runner. Printf uses
+	// it to stamp and route its narration.
+	ctx context.Context
+}
+
+// withDefaults returns a copy of e with zero fields replaced by defaults.
+func (e *Env) withDefaults() *Env {
+	var out Env
+	if e != nil {
+		out = *e
+	}
+	if out.Logger == nil {
+		out.Logger = worker.Stdout
+	}
+	if out.CancelAfter <= 0 {
+		out.CancelAfter = DefaultCancelAfter
+	}
+	if out.RunFor <= 0 {
+		out.RunFor = DefaultRunFor
+	}
+	if out.Clock == nil {
+		out.Clock = clock.Real
+	}
+	if out.Rand == nil {
+		out.Rand = rng.New(rng.NewSeed())
+	}
+	if out.Workers <= 0 {
+		out.Workers = 1
+	}
+	if out.Cause == nil {
+		out.Cause = DefaultCause
+	}
+	return &out
+}
+
+// Grace is the longest the scenario waits after cancellation for workers
+// to signal completion: GracePeriod if set, otherwise what is left of RunFor
+// after CancelAfter.
+func (e *Env) Grace() time.Duration {
+	if e.GracePeriod > 0 {
+		return e.GracePeriod
+	}
+	if g := e.RunFor - e.CancelAfter; g > 0 {
+		return g
+	}
+	return 0
+}
+
+// Sleep pauses the scenario for d on its clock, or until the scenario's
+// context is done, as when the whole run times out. It reports whether the
+// full duration elapsed; if not, the scenario's workers have already been
+// cancelled and there is no point in cancelling them again.
+func (e *Env) Sleep(d time.Duration) bool {
+	if e.ctx == nil {
+		e.Clock.Sleep(d)
+		return true
+	}
+	if err := ctxutil.Sleep(e.ctx, d); err != nil {
+		e.Printf("\n>>> The run ended early with cause: '%v' <<<\n", err)
+		return false
+	}
+	return true
+}
+
+// Printf publishes formatted narration as a Note event on the scenario's
+// bus.
+func (e *Env) Printf(format string, args ...any) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	worker.Notef(ctx, format, args...)
+}
@@ -0,0 +1,316 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxaudit"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/heartbeat"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Group tracks the workers a scenario launches. Every worker signals
+// completion by closing its done channel, so the scenario can wait for
+// exactly as long as the well-behaved workers need instead of sleeping.
+//
+// The zero value is ready to use. Scenarios should defer Release. A Group
+// keeps time with the clock carried by its instances' contexts.
+type Group struct {
+	instances []*Instance
+	onError   func(err error)   // see CancelOnError and CancelOnQuorum
+	release   func(cause error) // cancels the context either made
+}
+
+// Instance is a worker running in its own goroutine under a Group.
+type Instance struct {
+	name   string
+	w      worker.Worker
+	parent context.Context
+	ctx    context.Context // derived from parent, owned by this instance
+	cancel context.CancelCauseFunc
+	done   chan struct{} // closed once res is set
+	res    worker.Result
+	acked  chan struct{} // closed once the worker acknowledges its exit
+	ack    sync.Once
+
+	mu          sync.Mutex
+	startedAt   time.Time // when the worker started; zero until then
+	cancelledAt time.Time // when ctx was observed done; zero until then
+	observed    bool      // the worker reported cancellation; see worker.Hooks
+	observedAt  time.Time // when it did
+}
+
+// Name returns the name the instance was launched under.
+func (in *Instance) Name() string { return in.name }
+
+// Done returns a channel that is closed when the worker returns.
+func (in *Instance) Done() <-chan struct{} { return in.done }
+
+// Context returns the instance's own context.
+func (in *Instance) Context() context.Context { return in.ctx }
+
+// Cancel cancels this instance alone with cause, leaving its siblings and
+// its parent context untouched.
+func (in *Instance) Cancel(cause error) { in.cancel(cause) }
+
+// Launch starts w in a new goroutine under its own context derived from
+// parent and decorated by the middleware installed in it; see ctxmw.Apply.
+func (g *Group) Launch(parent context.Context, name string, w worker.Worker) *Instance {
+	ctx, cancel := context.WithCancelCause(parent)
+	cancel = ctxaudit.Track(ctx, "instance "+name, cancel, 1)
+	ctx = ctxmw.Apply(ctx)
+	in := &Instance{name: name, w: w, parent: parent, ctx: ctx, cancel: cancel, done: make(chan struct{}), acked: make(chan struct{})}
+	g.instances = append(g.instances, in)
+	context.AfterFunc(ctx, func() {
+		in.mu.Lock()
+		defer in.mu.Unlock()
+		in.cancelledAt = clock.From(ctx).Now()
+	})
+	ctx = worker.WithHooks(ctx, &worker.Hooks{
+		OnStart: func(context.Context) {
+			now := clock.From(ctx).Now()
+			in.mu.Lock()
+			defer in.mu.Unlock()
+			in.startedAt = now
+		},
+		OnCancel: func(context.Context, error) {
+			now := clock.From(ctx).Now()
+			in.mu.Lock()
+			defer in.mu.Unlock()
+			in.observed, in.observedAt = true, now
+		},
+		OnAck: func(context.Context) {
+			in.ack.Do(func() { close(in.acked) })
+		},
+	})
+	onError := g.onError
+	go func() {
+		in.res = worker.Execute(ctx, name, w)
+		close(in.done)
+		if in.res.Err != nil && onError != nil {
+			onError(fmt.Errorf("%s: %w", name, in.res.Err))
+		}
+	}()
+	return in
+}
+
+// CancelOnError gives g the behaviour of errgroup.WithContext: it returns a
+// copy of parent that is cancelled as soon as a worker launched afterwards
+// returns an error, with that error, prefixed by the worker's name, as the
+// cause. Launch the workers under the returned context and each one learns
+// through context.Cause which sibling failed. Release cancels it too.
+func (g *Group) CancelOnError(parent context.Context) context.Context {
+	ctx, cancel := context.WithCancelCause(parent)
+	g.release = ctxaudit.Track(ctx, "cancel-on-error", cancel, 1)
+	g.onError = g.release
+	return ctx
+}
+
+// CancelOnQuorum is CancelOnError for replicated work, where one failure is
+// to be expected: the returned context is cancelled only once k workers
+// launched afterwards have returned an error, with those k errors joined by
+// errors.Join as the cause. Later errors are not added.
+func (g *Group) CancelOnQuorum(parent context.Context, k int) context.Context {
+	ctx, cancel := context.WithCancelCause(parent)
+	release := ctxaudit.Track(ctx, "cancel-on-quorum", cancel, 1)
+	g.release = release
+	var mu sync.Mutex
+	var errs []error
+	g.onError = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if errs = append(errs, err); len(errs) == max(k, 1) {
+			release(errors.Join(errs...))
+		}
+	}
+	return ctx
+}
+
+// Spawn launches n instances of a worker built by newWorker. With a single
+// instance the worker is named base; otherwise the instances are named
+// base-1 through base-n.
+func (g *Group) Spawn(ctx context.Context, base string, n int, newWorker func() worker.Worker) []*Instance {
+	if n <= 1 {
+		return []*Instance{g.Launch(ctx, base, newWorker())}
+	}
+	ins := make([]*Instance, n)
+	for i := range ins {
+		ins[i] = g.Launch(ctx, fmt.Sprintf("%s-%d", base, i+1), newWorker())
+	}
+	return ins
+}
+
+// Instances returns every instance launched so far, in launch order.
+func (g *Group) Instances() []*Instance {
+	return append([]*Instance(nil), g.instances...)
+}
+
+// Lookup returns the instance launched under name.
+func (g *Group) Lookup(name string) (*Instance, bool) {
+	for _, in := range g.instances {
+		if in.name == name {
+			return in, true
+		}
+	}
+	return nil, false
+}
+
+// Release cancels every instance's own context, and the one made by
+// CancelOnError or CancelOnQuorum, so that no derived context outlives the
+// scenario.
+func (g *Group) Release() {
+	for _, in := range g.instances {
+		in.cancel(nil)
+	}
+	if g.release != nil {
+		g.release(nil)
+	}
+}
+
+// Wait blocks until every instance whose context has been cancelled has
+// signalled completion, or until timeout elapses. Instances whose context is
+// still live are not expected to stop and are not waited for. Wait returns
+// the instances that have not signalled.
+func (g *Group) Wait(timeout time.Duration) []*Instance {
+	if len(g.instances) == 0 {
+		return nil
+	}
+	timer := clock.From(g.instances[0].ctx).NewTimer(timeout)
+	defer timer.Stop()
+	expired := timer.C()
+	for _, in := range g.instances {
+		// Check the parent too: cancellation of a parent that is not a
+		// standard library context reaches in.ctx asynchronously.
+		if in.parent.Err() == nil && in.ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-in.done:
+		case <-expired:
+			return g.Pending()
+		}
+	}
+	return g.Pending()
+}
+
+// AwaitAcks blocks until every instance whose context has been cancelled
+// has acknowledged its exit, by returning or through worker.Ack, or until
+// timeout elapses. Unlike Wait it gives a definitive answer: confirmed are
+// the cancelled instances that acknowledged, and missing those that did
+// not, which are leaked or stuck. Instances whose context is still live are
+// in neither.
+func (g *Group) AwaitAcks(timeout time.Duration) (confirmed, missing []*Instance) {
+	if len(g.instances) == 0 {
+		return nil, nil
+	}
+	timer := clock.From(g.instances[0].ctx).NewTimer(timeout)
+	defer timer.Stop()
+	timedOut := false
+	for _, in := range g.instances {
+		if in.parent.Err() == nil && in.ctx.Err() == nil {
+			continue
+		}
+		if !timedOut {
+			select {
+			case <-in.acked:
+			case <-timer.C():
+				timedOut = true
+			}
+		}
+		select {
+		case <-in.acked:
+			confirmed = append(confirmed, in)
+		default:
+			missing = append(missing, in)
+		}
+	}
+	return confirmed, missing
+}
+
+// Pending returns the instances that have not signalled completion.
+func (g *Group) Pending() []*Instance {
+	var ins []*Instance
+	for _, in := range g.instances {
+		select {
+		case <-in.done:
+		default:
+			ins = append(ins, in)
+		}
+	}
+	return ins
+}
+
+// Result reports how every instance ended without waiting for any of them,
+// as the result of scenario name. An instance that is still running is
+// reported as leaked, and a WorkerLeaked event is published for it. If
+// cancelledAt is non-zero, latencies are measured from it, unless the
+// instance's own context was seen to be cancelled at some other time before
+// the worker exited, as when an instance times out on its own. Propagation
+// latencies, up to the moment a worker reported seeing the cancellation,
+// are measured from the same point.
+func (g *Group) Result(name string, cancelledAt time.Time) *Result {
+	res := &Result{Scenario: name, CancelledAt: cancelledAt}
+	for _, in := range g.instances {
+		res.Workers = append(res.Workers, in.result(cancelledAt))
+	}
+	return res
+}
+
+func (in *Instance) result(cancelledAt time.Time) worker.Result {
+	in.mu.Lock()
+	started, own, observed, observedAt := in.startedAt, in.cancelledAt, in.observed, in.observedAt
+	in.mu.Unlock()
+	if !observed {
+		observedAt = time.Time{}
+	}
+	select {
+	case <-in.done:
+		r := in.res
+		r.Observed, r.ObservedAt, r.Acked = observed, observedAt, true
+		from := cancelledAt
+		if !own.IsZero() && !own.After(r.ExitedAt) {
+			from = own
+		}
+		if !from.IsZero() && r.Exit == worker.ExitCancelled {
+			r.Latency = max(r.ExitedAt.Sub(from), 0)
+		}
+		if !from.IsZero() && observed {
+			r.Propagation = max(observedAt.Sub(from), 0)
+		}
+		return r
+	default:
+		r := worker.Leaked(in.name, in.w)
+		r.StartedAt = started
+		r.Observed, r.ObservedAt = observed, observedAt
+		select {
+		case <-in.acked:
+			r.Acked = true
+		default:
+		}
+		from := cancelledAt
+		if !own.IsZero() {
+			from = own
+		}
+		if !from.IsZero() && observed {
+			r.Propagation = max(observedAt.Sub(from), 0)
+		}
+		r.Blocked = heartbeat.From(in.ctx).Stale(in.name, clock.From(in.ctx).Now())
+		worker.ReportLeaked(in.ctx, r)
+		return r
+	}
+}
+
+// Names returns the names of ins, comma separated.
+func Names(ins []*Instance) string {
+	ns := make([]string, len(ins))
+	for i, in := range ins {
+		ns[i] = in.name
+	}
+	return strings.Join(ns, ", ")
+}
@@ -0,0 +1,81 @@
+package scenario
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Tags shared by the built-in scenarios. Scenario packages may use others.
+const (
+	TagCause    = "cause"
+	TagLeak     = "leak"
+	TagTimeout  = "timeout"
+	TagValues   = "values"
+	TagShutdown = "shutdown"
+	TagErrors   = "errors"
+	TagChannels = "channels"
+)
+
+// Metadata describes a scenario without running it.
+type Metadata struct {
+	// Description is a one-line summary of what the scenario demonstrates.
+	Description string
+	// Outcome is a sentence or two on how a run with default parameters
+	// ends, so users know what to look for before they run it.
+	Outcome string
+	// Tags classify the scenario, for listing and filtering.
+	Tags []string
+	// ExpectedLeaks is the number of workers the scenario leaks on purpose
+	// when each worker type is started once. Scenarios that honour
+	// Env.Workers leak that many times as many.
+	ExpectedLeaks int
+	// Leaks, if set, works out the number of workers a run leaks on purpose
+	// from its Env, Env.Workers included, for scenarios where that depends
+	// on their parameters. ExpectedLeaks is then the number with the
+	// default parameters.
+	Leaks func(env *Env) int
+	// ExpectedFailures is the number of workers that return an error on
+	// purpose. Unlike ExpectedLeaks it does not grow with Env.Workers.
+	ExpectedFailures int
+	// Duration is roughly how long the scenario takes with default
+	// parameters on the wall clock.
+	Duration time.Duration
+	// Params declares the scenario's own parameters, beyond those in Env.
+	Params []Param
+}
+
+// HasTag reports whether m is tagged with tag.
+func (m Metadata) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// LeaksFor returns the number of workers a run with env leaks on purpose.
+func (m Metadata) LeaksFor(env *Env) int {
+	if m.Leaks != nil {
+		return m.Leaks(env)
+	}
+	return m.ExpectedLeaks * max(env.Workers, 1)
+}
+
+// Verify checks res against the expectations in m.
+func (m Metadata) Verify(res *Result) error {
+	if got, want := res.Leaked(), res.ExpectedLeaks; got != want {
+		return fmt.Errorf("scenario %s: %d worker(s) leaked, want %d", res.Scenario, got, want)
+	}
+	if got := res.Failed(); got != m.ExpectedFailures {
+		return fmt.Errorf("scenario %s: %d worker(s) failed, want %d", res.Scenario, got, m.ExpectedFailures)
+	}
+	return nil
+}
+
+// WithTag returns the scenarios in ss tagged with tag.
+func WithTag(ss []Scenario, tag string) []Scenario {
+	var out []Scenario
+	for _, s := range ss {
+		if s.Metadata().HasTag(tag) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
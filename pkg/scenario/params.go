@@ -0,0 +1,99 @@
+package scenario
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParamKind is the type of a scenario parameter's value.
+type ParamKind int
+
+// The kinds of parameter.
+const (
+	ParamString ParamKind = iota
+	ParamInt
+	ParamDuration
+)
+
+func (k ParamKind) String() string {
+	switch k {
+	case ParamInt:
+		return "int"
+	case ParamDuration:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// Param declares a scenario-specific parameter. Values travel as strings,
+// so they can come from flags, files or code alike, and are checked against
+// Kind before the scenario runs.
+type Param struct {
+	Name    string
+	Kind    ParamKind
+	Default string
+	Usage   string
+}
+
+// Check reports whether value is valid for p. The empty string is always
+// valid and means the parameter is unset.
+func (p Param) Check(value string) error {
+	if value == "" {
+		return nil
+	}
+	var err error
+	switch p.Kind {
+	case ParamInt:
+		_, err = strconv.Atoi(value)
+	case ParamDuration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("parameter %s: invalid %s %q", p.Name, p.Kind, value)
+	}
+	return nil
+}
+
+// resolveParams returns values completed with the defaults in params, or an
+// error if a value is invalid or names no parameter.
+func resolveParams(name string, params []Param, values map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(params))
+	known := make(map[string]bool, len(params))
+	for _, p := range params {
+		known[p.Name] = true
+		v, ok := values[p.Name]
+		if !ok {
+			v = p.Default
+		}
+		if err := p.Check(v); err != nil {
+			return nil, fmt.Errorf("scenario %s: %w", name, err)
+		}
+		out[p.Name] = v
+	}
+	for k := range values {
+		if !known[k] {
+			return nil, fmt.Errorf("scenario %s: unknown parameter %q", name, k)
+		}
+	}
+	return out, nil
+}
+
+// Param returns the value of the scenario parameter name.
+func (e *Env) Param(name string) string {
+	return e.Params[name]
+}
+
+// IntParam returns the value of the int parameter name, or 0 if it is unset.
+func (e *Env) IntParam(name string) int {
+	n, _ := strconv.Atoi(e.Params[name])
+	return n
+}
+
+// DurationParam returns the value of the duration parameter name, or 0 if
+// it is unset.
+func (e *Env) DurationParam(name string) time.Duration {
+	d, _ := time.ParseDuration(e.Params[name])
+	return d
+}
@@ -0,0 +1,57 @@
+package scenario
+
+// Phase is one of the key moments of a scenario run, at which a run in step
+// mode pauses; see Env.Step.
+type Phase int
+
+// The phases, in the order a run reaches them.
+const (
+	// PhaseCancel is just before the workers are cancelled.
+	PhaseCancel Phase = iota + 1
+	// PhaseGraceEnd is when the scenario has stopped waiting for cancelled
+	// workers, just before their results are taken.
+	PhaseGraceEnd
+	// PhaseExit is after the scenario has returned, just before the run
+	// ends.
+	PhaseExit
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseCancel:
+		return "cancel"
+	case PhaseGraceEnd:
+		return "grace-end"
+	case PhaseExit:
+		return "exit"
+	default:
+		return "unknown"
+	}
+}
+
+// Explain says what is about to happen at p and why.
+func (p Phase) Explain() string {
+	switch p {
+	case PhaseCancel:
+		return "The workers' context is about to be cancelled, by a call to cancel or by its deadline. " +
+			"Its Done channel closes, so every worker selecting on ctx.Done() wakes up, " +
+			"and ctx.Err() and context.Cause() start reporting why."
+	case PhaseGraceEnd:
+		return "The grace period is over. Well-behaved workers have returned by now; " +
+			"any worker still running ignored its context and is about to be reported as leaked."
+	case PhaseExit:
+		return "The scenario has returned and the run is about to end. " +
+			"A leaked goroutine is still running: nothing can stop it from outside, " +
+			"which is why every goroutine should watch its context."
+	default:
+		return ""
+	}
+}
+
+// Enter marks that the scenario has reached p. If Step is set, the scenario
+// waits for it to return.
+func (e *Env) Enter(p Phase) {
+	if e.Step != nil {
+		e.Step(p)
+	}
+}
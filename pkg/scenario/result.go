@@ -0,0 +1,164 @@
+package scenario
+
+import (
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxaudit"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/leakcheck"
+	"github.com/context-demo/pkg/watch"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Result describes the outcome of a scenario run.
+type Result struct {
+	// Scenario is the name of the scenario that ran.
+	Scenario string
+	// CancelledAt is when the scenario cancelled its workers, or when their
+	// deadline passed. Zero if the workers were never cancelled.
+	CancelledAt time.Time
+	// StartedAt and FinishedAt are when the Runner started the scenario
+	// and when it returned, on the run's clock; see Wall.
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Seed is the seed of the run's Rand; running again with it makes the
+	// same random choices.
+	Seed uint64
+	// Workers holds one entry per launched worker, in launch order.
+	Workers []worker.Result
+	// ExpectedLeaks is the number of Workers the scenario leaks on purpose
+	// with the run's parameters; see Metadata.LeaksFor.
+	ExpectedLeaks int
+	// Trend holds the goroutine counts sampled in watch mode, in order; see
+	// Env.Watch.
+	Trend []watch.Point
+	// LeakChecked reports that the run was measured for leaked goroutines,
+	// and Goroutines holds those it left behind. LeakSites groups them by
+	// the worker type they were running and where they were started.
+	// Timers holds the tickers and timers the run left unstopped, and
+	// Uncancelled the contexts it derived and never cancelled.
+	LeakChecked bool
+	Goroutines  []leakcheck.Goroutine
+	LeakSites   []leakcheck.Site
+	Timers      []clock.Unstopped
+	Uncancelled []ctxaudit.Uncancelled
+}
+
+// Wall returns how long the run took, from the Runner starting the
+// scenario to it returning.
+func (r *Result) Wall() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// Exited returns the number of workers that returned before the scenario
+// finished.
+func (r *Result) Exited() int {
+	return len(r.Workers) - r.Leaked()
+}
+
+// Leaked returns the number of workers that had not exited when the
+// scenario finished, including those that had timed out; see TimedOut.
+func (r *Result) Leaked() int {
+	n := 0
+	for _, w := range r.Workers {
+		if w.Exit == worker.ExitLeaked {
+			n++
+		}
+	}
+	return n
+}
+
+// TimedOut returns the number of leaked workers that had observed
+// cancellation: they were shutting down, just not within the grace period.
+func (r *Result) TimedOut() int {
+	n := 0
+	for _, w := range r.Workers {
+		if w.Exit == worker.ExitLeaked && w.Observed {
+			n++
+		}
+	}
+	return n
+}
+
+// Blocked returns the number of leaked workers that had stopped reporting
+// ticks: they are stuck rather than still working.
+func (r *Result) Blocked() int {
+	n := 0
+	for _, w := range r.Workers {
+		if w.Exit == worker.ExitLeaked && w.Blocked {
+			n++
+		}
+	}
+	return n
+}
+
+// MaxPropagation returns the longest time cancellation took to reach a
+// worker that reported seeing it, and that worker's name. It returns zero
+// and "" if none did.
+func (r *Result) MaxPropagation() (time.Duration, string) {
+	var d time.Duration
+	var name string
+	for _, w := range r.Workers {
+		if w.Observed && (name == "" || w.Propagation > d) {
+			d, name = w.Propagation, w.Worker
+		}
+	}
+	return d, name
+}
+
+// Observed returns the number of workers that reported seeing
+// cancellation, whether or not they went on to exit.
+func (r *Result) Observed() int {
+	n := 0
+	for _, w := range r.Workers {
+		if w.Observed {
+			n++
+		}
+	}
+	return n
+}
+
+// Unacked returns the names of the workers that never acknowledged their
+// exit: those that leaked without calling worker.Ack.
+func (r *Result) Unacked() []string {
+	var names []string
+	for _, w := range r.Workers {
+		if !w.Acked {
+			names = append(names, w.Worker)
+		}
+	}
+	return names
+}
+
+// Reasons counts the workers by worker.Result.Reason.
+func (r *Result) Reasons() map[string]int {
+	n := make(map[string]int)
+	for _, w := range r.Workers {
+		n[w.Reason()]++
+	}
+	return n
+}
+
+// Causes counts the workers that exited on cancellation by the
+// event.CauseKey of their cause, as event.Metrics does.
+func (r *Result) Causes() map[string]int {
+	n := make(map[string]int)
+	for _, w := range r.Workers {
+		if k := event.CauseKey(w.Cause); k != "" && w.Err == nil {
+			n[k]++
+		}
+	}
+	return n
+}
+
+// Failed returns the number of workers that returned an error.
+func (r *Result) Failed() int {
+	n := 0
+	for _, w := range r.Workers {
+		if w.Exit == worker.ExitFailed {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,194 @@
+// Package scenario holds the registry of context demonstrations and the
+// Runner that executes them.
+//
+// Each demonstration registers itself by name in an init function, so new
+// scenarios can be added without touching main. The registry starts empty:
+// the scenarios that ship with this module live in the builtin sub-package,
+// and programs choose which scenario packages to link in with blank imports,
+// the way database/sql programs choose their drivers:
+//
+//	import (
+//		_ "github.com/context-demo/pkg/scenario/builtin"
+//		_ "example.com/hogsmeade/scenarios"
+//	)
+//
+// Scenario packages build their demonstrations from Env and Group.
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/trace"
+	"sort"
+	"sync"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/heartbeat"
+	"github.com/context-demo/pkg/rng"
+	"github.com/context-demo/pkg/watch"
+	"github.com/context-demo/pkg/watchdog"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Scenario is a single, named context demonstration.
+type Scenario interface {
+	// Name is the unique name used to select the scenario.
+	Name() string
+	// Metadata describes the scenario: what it demonstrates, how it is
+	// tagged, and what a run is expected to look like.
+	Metadata() Metadata
+	// Run executes the demonstration with the parameters in env and reports
+	// how each worker ended. It should return once the demonstration is
+	// complete or ctx is cancelled.
+	Run(ctx context.Context, env *Env) (*Result, error)
+}
+
+// New returns a Scenario that calls run when executed.
+func New(name string, md Metadata, run func(ctx context.Context, env *Env) (*Result, error)) Scenario {
+	return &funcScenario{name: name, md: md, run: run}
+}
+
+type funcScenario struct {
+	name string
+	md   Metadata
+	run  func(ctx context.Context, env *Env) (*Result, error)
+}
+
+func (s *funcScenario) Name() string       { return s.name }
+func (s *funcScenario) Metadata() Metadata { return s.md }
+
+func (s *funcScenario) Run(ctx context.Context, env *Env) (*Result, error) {
+	return s.run(ctx, env)
+}
+
+// ErrUnknownScenario is returned when a scenario name is not registered.
+var ErrUnknownScenario = errors.New("unknown scenario")
+
+// Registry is a set of scenarios keyed by name. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	scenarios map[string]Scenario
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{scenarios: make(map[string]Scenario)}
+}
+
+// Register makes a scenario available by its name.
+// If Register is called twice with the same name or if s is nil, it panics.
+func (r *Registry) Register(s Scenario) {
+	if s == nil {
+		panic("scenario: Register scenario is nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.scenarios[s.Name()]; dup {
+		panic("scenario: Register called twice for scenario " + s.Name())
+	}
+	r.scenarios[s.Name()] = s
+}
+
+// Lookup returns the scenario registered under name.
+func (r *Registry) Lookup(name string) (Scenario, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.scenarios[name]
+	return s, ok
+}
+
+// All returns every registered scenario, sorted by name.
+func (r *Registry) All() []Scenario {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]Scenario, 0, len(r.scenarios))
+	for _, s := range r.scenarios {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
+
+// Default is the registry used by the package-level functions and by the
+// built-in scenarios.
+var Default = NewRegistry()
+
+// Register adds s to the Default registry.
+func Register(s Scenario) { Default.Register(s) }
+
+// Lookup finds a scenario in the Default registry.
+func Lookup(name string) (Scenario, bool) { return Default.Lookup(name) }
+
+// All returns every scenario in the Default registry, sorted by name.
+func All() []Scenario { return Default.All() }
+
+// Runner executes scenarios from a registry.
+type Runner struct {
+	// Registry to look scenarios up in. If nil, Default is used.
+	Registry *Registry
+	// Env holds the scenario parameters. Zero fields take their defaults.
+	Env *Env
+}
+
+// Run executes the scenario registered under name with ctx as its parent
+// context and returns its result.
+func (r *Runner) Run(ctx context.Context, name string) (*Result, error) {
+	reg := r.Registry
+	if reg == nil {
+		reg = Default
+	}
+	s, ok := reg.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownScenario, name)
+	}
+	env := r.Env.withDefaults()
+	params, err := resolveParams(name, s.Metadata().Params, env.Params)
+	if err != nil {
+		return nil, err
+	}
+	env.Params = params
+	ctx = worker.WithScenarioName(ctx, name)
+	ctx, task := trace.NewTask(ctx, "scenario "+name)
+	defer task.End()
+	ctx = clock.With(ctx, env.Clock)
+	ctx = rng.With(ctx, env.Rand)
+	ctx = rng.WithJitter(ctx, env.Jitter)
+	bus := event.NewBus(worker.LogSink(env.Logger))
+	for _, sink := range env.Sinks {
+		bus.Subscribe(sink)
+	}
+	monitor := heartbeat.NewMonitor(env.Grace())
+	bus.Subscribe(monitor)
+	ctx = event.WithBus(ctx, bus)
+	ctx = heartbeat.With(ctx, monitor)
+	ctx = ctxmw.Install(ctx, env.Middleware...)
+	env.ctx = ctx
+	stopWatch := func() []watch.Point { return nil }
+	if env.Watch > 0 {
+		// Keep sampling past a run timeout, to show what outlives it.
+		stopWatch = watch.Start(context.WithoutCancel(ctx), env.Watch)
+	}
+	var dog *watchdog.Watchdog
+	if env.HardKill > 0 && env.Kill != nil {
+		// Cancellation comes CancelAfter into the run at the latest.
+		dog = watchdog.Start(env.CancelAfter+env.Grace()+env.HardKill, env.Kill)
+	}
+	startedAt := env.Clock.Now()
+	res, err := s.Run(ctx, env)
+	if dog != nil {
+		dog.Stop()
+	}
+	trend := stopWatch()
+	if err != nil {
+		return nil, err
+	}
+	env.Enter(PhaseExit)
+	res.StartedAt, res.FinishedAt = startedAt, env.Clock.Now()
+	res.Seed = env.Rand.Seed()
+	res.ExpectedLeaks = s.Metadata().LeaksFor(env)
+	res.Trend = trend
+	return res, nil
+}
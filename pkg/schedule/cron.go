@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a Schedule given by a cron expression: five fields, minute, hour,
+// day of month, month and day of week, or six with a leading seconds field.
+// Each field is *, a number, a range a-b, any of those with a step /n, or a
+// comma-separated list of them. Day of week runs from 0, Sunday, to 6, with
+// 7 also meaning Sunday. As in cron, a day matches if either day field
+// does when both are restricted.
+type Cron struct {
+	expr            string
+	sec, min, hour  uint64 // bit i set if value i matches
+	dom, month, dow uint64
+	anyDOM, anyDOW  bool
+}
+
+// field is the range of one cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var (
+	secField   = field{"second", 0, 59}
+	minField   = field{"minute", 0, 59}
+	hourField  = field{"hour", 0, 23}
+	domField   = field{"day of month", 1, 31}
+	monthField = field{"month", 1, 12}
+	dowField   = field{"day of week", 0, 7}
+)
+
+// ParseCron parses a cron expression; see Cron.
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron %q: want 5 or 6 fields, got %d", expr, len(fields))
+	}
+	c := &Cron{expr: expr, anyDOM: fields[3] == "*", anyDOW: fields[5] == "*"}
+	for i, spec := range []struct {
+		f   field
+		out *uint64
+	}{
+		{secField, &c.sec}, {minField, &c.min}, {hourField, &c.hour},
+		{domField, &c.dom}, {monthField, &c.month}, {dowField, &c.dow},
+	} {
+		bits, err := parseField(fields[i], spec.f)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		*spec.out = bits
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	return c, nil
+}
+
+// parseField returns the values s allows for f, as a bit set.
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if r, st, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(st)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: bad step %q", f.name, st)
+			}
+			rng, step = r, n
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = value(a, f); err != nil {
+				return 0, err
+			}
+			if hi, err = value(b, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: empty range %q", f.name, rng)
+			}
+		default:
+			n, err := value(rng, f)
+			if err != nil {
+				return 0, err
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses one number of field f.
+func value(s string, f field) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s: %q is not in %d-%d", f.name, s, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Next returns the first time after t, to the second, that the expression
+// matches, or the zero time if there is none within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, mo, d := t.Date()
+		h, mi, _ := t.Clock()
+		switch {
+		case c.month&(1<<mo) == 0:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, loc)
+		case !c.day(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<h) == 0:
+			t = time.Date(y, mo, d, h+1, 0, 0, 0, loc)
+		case c.min&(1<<mi) == 0:
+			t = time.Date(y, mo, d, h, mi+1, 0, 0, loc)
+		case c.sec&(1<<t.Second()) == 0:
+			t = t.Add(time.Second)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// day reports whether t's day matches the day fields.
+func (c *Cron) day(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<t.Weekday()) != 0
+	switch {
+	case c.anyDOM && c.anyDOW:
+		return true
+	case c.anyDOM:
+		return dow
+	case c.anyDOW:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func (c *Cron) String() string { return c.expr }
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a time in 2024, a leap year that starts on a Monday.
+func at(month time.Month, day, hour, min, sec int) time.Time {
+	return time.Date(2024, month, day, hour, min, sec, 0, time.UTC)
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// Five fields, which run on the minute.
+		{"* * * * *", at(1, 1, 10, 0, 0), at(1, 1, 10, 1, 0)},
+		{"* * * * *", at(1, 1, 10, 0, 30), at(1, 1, 10, 1, 0)},
+		{"30 2 * * *", at(1, 1, 10, 0, 0), at(1, 2, 2, 30, 0)},
+		{"0 0 1 1 *", at(1, 1, 0, 0, 0), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+
+		// Six fields, with seconds first.
+		{"*/10 * * * * *", at(1, 1, 10, 0, 3), at(1, 1, 10, 0, 10)},
+		{"30 0 12 * * *", at(1, 1, 10, 0, 0), at(1, 1, 12, 0, 30)},
+		{"0 * * * * *", at(1, 1, 10, 0, 0), at(1, 1, 10, 1, 0)},
+
+		// Ranges, steps and lists.
+		{"*/15 * * * *", at(1, 1, 10, 7, 0), at(1, 1, 10, 15, 0)},
+		{"*/15 * * * *", at(1, 1, 10, 15, 0), at(1, 1, 10, 30, 0)},
+		{"5/20 * * * *", at(1, 1, 10, 26, 0), at(1, 1, 10, 45, 0)},
+		{"0 0-12/6 * * *", at(1, 1, 6, 0, 0), at(1, 1, 12, 0, 0)},
+		{"0 0-12/6 * * *", at(1, 1, 12, 0, 0), at(1, 2, 0, 0, 0)},
+		{"0 9-17 * * *", at(1, 1, 17, 0, 0), at(1, 2, 9, 0, 0)},
+		{"5,10 * * * *", at(1, 1, 10, 6, 0), at(1, 1, 10, 10, 0)},
+		{"5,10 * * * *", at(1, 1, 10, 11, 0), at(1, 1, 11, 5, 0)},
+		{"0,30-31 * * * *", at(1, 1, 10, 30, 0), at(1, 1, 10, 31, 0)},
+		{"0 0 1 */3 *", at(2, 10, 0, 0, 0), at(4, 1, 0, 0, 0)},
+
+		// Day of week alone, with 7 as Sunday too.
+		{"0 9 * * 1-5", at(1, 6, 10, 0, 0), at(1, 8, 9, 0, 0)},
+		{"0 0 * * 0", at(1, 1, 0, 0, 0), at(1, 7, 0, 0, 0)},
+		{"0 0 * * 7", at(1, 1, 0, 0, 0), at(1, 7, 0, 0, 0)},
+
+		// Both day fields restricted: either one matching will do.
+		{"0 0 13 * 5", at(1, 1, 0, 0, 0), at(1, 5, 0, 0, 0)},
+		{"0 0 13 * 5", at(1, 12, 12, 0, 0), at(1, 13, 0, 0, 0)},
+		{"0 0 13 * 5", at(1, 13, 0, 0, 0), at(1, 19, 0, 0, 0)},
+
+		// February 29th, in leap years only, and days no month has.
+		{"0 0 29 2 *", at(1, 1, 0, 0, 0), at(2, 29, 0, 0, 0)},
+		{"0 0 29 2 *", at(2, 29, 0, 0, 0), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", at(1, 1, 0, 0, 0), time.Time{}},
+		{"0 0 31 4,6,9,11 *", at(1, 1, 0, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	got := MustParseCron("0 3 * * *").Next(time.Date(2024, 1, 1, 12, 0, 0, 0, loc))
+	if want := time.Date(2024, 1, 2, 3, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"60 * * * * *",
+		"-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	} {
+		if c, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) = %v, want an error", expr, c)
+		}
+	}
+}
+
+func TestMustParseCronPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustParseCron of an invalid expression did not panic")
+		}
+	}()
+	MustParseCron("not cron")
+}
@@ -0,0 +1,172 @@
+// Package schedule runs jobs on intervals or cron expressions until a
+// context is cancelled.
+//
+// A Scheduler is itself a worker.Worker. Each of its jobs waits on the
+// clock carried by the context for its next run time and then runs with a
+// context derived from the scheduler's, so cancelling that one context
+// stops the scheduler and every job in flight: no job starts after it, and
+// Run does not return until the last running job has.
+//
+//	s := &schedule.Scheduler{Jobs: []schedule.Job{
+//		{Name: "heartbeat", Schedule: schedule.Every(time.Second), Run: ping},
+//		{Name: "nightly", Schedule: schedule.MustParseCron("0 3 * * *"), Run: backup},
+//	}}
+//	go s.Run(ctx)
+//
+// A job is never run twice at once: a run time that passes while the job
+// is still running is skipped, and the job waits for the next one.
+package schedule
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Schedule says when a job runs.
+type Schedule interface {
+	// Next returns the first run time after t, or the zero time if the job
+	// is never to run again.
+	Next(t time.Time) time.Time
+}
+
+// Every is a Schedule that runs a job at a fixed interval, measured from
+// when the scheduler starts.
+type Every time.Duration
+
+// Next implements Schedule.
+func (e Every) Next(t time.Time) time.Time {
+	if e <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(e))
+}
+
+func (e Every) String() string { return "every " + time.Duration(e).String() }
+
+// MustParseCron is ParseCron for expressions known to be valid; it panics
+// on an error.
+func MustParseCron(expr string) *Cron {
+	c, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Job is a function run by a Scheduler.
+type Job struct {
+	// Name identifies the job in its context; see worker.WithWorkerName.
+	Name     string
+	Schedule Schedule
+	// Run does the job. It should return once ctx is cancelled.
+	Run func(ctx context.Context) error
+}
+
+// Scheduler runs its jobs on their schedules until its context is
+// cancelled.
+type Scheduler struct {
+	// Jobs are scheduled when Run is called.
+	Jobs []Job
+
+	mu       sync.Mutex
+	counters map[string]*counters
+}
+
+// Stats is what has happened to one job so far.
+type Stats struct {
+	// Runs counts the runs started, and Failed those that returned an
+	// error. Skipped counts run times missed because the job was still
+	// running.
+	Runs, Failed, Skipped int64
+	// Running reports whether a run is in flight.
+	Running bool
+}
+
+// counters are a job's Stats as they are kept.
+type counters struct {
+	runs, failed, skipped atomic.Int64
+	running               atomic.Bool
+}
+
+// Run schedules every job until ctx is cancelled and the jobs running then
+// have returned. It always returns nil; a failed run is counted and
+// narrated, and the job runs again at its next time.
+func (s *Scheduler) Run(ctx context.Context) error {
+	worker.Notef(ctx, "Scheduler: scheduling %d job(s).", len(s.Jobs))
+	var wg sync.WaitGroup
+	for _, j := range s.Jobs {
+		c := s.countersFor(j.Name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, j, c)
+		}()
+	}
+	wg.Wait()
+	worker.Notef(ctx, "Scheduler: stopped, no job running (%v).", context.Cause(ctx))
+	return nil
+}
+
+// Stats returns what has happened to the job called name so far.
+func (s *Scheduler) Stats(name string) Stats {
+	c := s.countersFor(name)
+	return Stats{
+		Runs:    c.runs.Load(),
+		Failed:  c.failed.Load(),
+		Skipped: c.skipped.Load(),
+		Running: c.running.Load(),
+	}
+}
+
+// countersFor returns the counters of the job called name.
+func (s *Scheduler) countersFor(name string) *counters {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counters == nil {
+		s.counters = make(map[string]*counters)
+	}
+	c, ok := s.counters[name]
+	if !ok {
+		c = new(counters)
+		s.counters[name] = c
+	}
+	return c
+}
+
+// loop runs j at each of its run times until ctx is cancelled.
+func (s *Scheduler) loop(ctx context.Context, j Job, c *counters) {
+	clk := clock.From(ctx)
+	next := j.Schedule.Next(clk.Now())
+	for !next.IsZero() {
+		timer := clk.NewTimer(next.Sub(clk.Now()))
+		select {
+		case <-ctx.Done():
+		case <-timer.C():
+		}
+		timer.Stop()
+		if ctx.Err() != nil {
+			return
+		}
+		c.runs.Add(1)
+		c.running.Store(true)
+		err := j.Run(worker.WithWorkerName(ctx, j.Name))
+		c.running.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.failed.Add(1)
+			worker.Notef(ctx, "Scheduler: %s failed: %v", j.Name, err)
+		}
+		// Skip the run times that passed while the job ran.
+		now := clk.Now()
+		for next = j.Schedule.Next(next); !next.IsZero() && next.Before(now); next = j.Schedule.Next(next) {
+			c.skipped.Add(1)
+		}
+	}
+}
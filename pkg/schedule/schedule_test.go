@@ -0,0 +1,95 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+func TestSchedulerCancelStopsJobsInFlight(t *testing.T) {
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	errStop := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(clock.With(context.Background(), f))
+	defer cancel(nil)
+
+	started := make(chan struct{})
+	var cause atomic.Pointer[error]
+	var returned atomic.Bool
+	s := &Scheduler{Jobs: []Job{{
+		Name:     "backup",
+		Schedule: Every(time.Minute),
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			err := context.Cause(ctx)
+			cause.Store(&err)
+			returned.Store(true)
+			return err
+		},
+	}}}
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	<-started
+	if st := s.Stats("backup"); st.Runs != 1 || !st.Running {
+		t.Fatalf("Stats after the first run time = %+v, want one run in flight", st)
+	}
+
+	cancel(errStop)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+	if !returned.Load() {
+		t.Error("Run returned before the job in flight did")
+	}
+	if err := cause.Load(); err == nil || !errors.Is(*err, errStop) {
+		t.Errorf("job saw cause %v, want %v", err, errStop)
+	}
+	st := s.Stats("backup")
+	if st.Running || st.Runs != 1 || st.Failed != 0 {
+		t.Errorf("Stats after cancellation = %+v, want one run, none running or failed", st)
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after Run returned, want 0", n)
+	}
+}
+
+func TestSchedulerSkipsRunTimesMissedWhileRunning(t *testing.T) {
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(clock.With(context.Background(), f))
+	defer cancel()
+
+	ran := make(chan struct{})
+	s := &Scheduler{Jobs: []Job{{
+		Name:     "slow",
+		Schedule: Every(time.Second),
+		Run: func(ctx context.Context) error {
+			f.Advance(3500 * time.Millisecond) // outlast three run times
+			ran <- struct{}{}
+			return errors.New("slow job failed")
+		},
+	}}}
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	<-ran
+	f.BlockUntil(1) // waiting for the next run time
+	if st := s.Stats("slow"); st.Runs != 1 || st.Failed != 1 || st.Skipped != 3 {
+		t.Errorf("Stats = %+v, want 1 run, 1 failed, 3 skipped", st)
+	}
+	cancel()
+	<-done
+}
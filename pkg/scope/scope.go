@@ -0,0 +1,129 @@
+// Package scope runs goroutines in a structured-concurrency scope, or
+// nursery: every goroutine started in a scope has returned by the time the
+// scope does.
+//
+//	err := scope.Run(ctx, func(s *scope.Scope) error {
+//		s.Go(fetch)
+//		s.Go(index)
+//		return nil
+//	})
+//
+// The children share a context derived from the one given to Run. It is
+// cancelled when the scope's context is, when a child returns an error or
+// panics, or when the function given to Run returns an error, so one
+// failure stops its siblings. A child cannot outlive its scope: Run does
+// not return until the last child has, and Go panics once Run has
+// returned, so a goroutine of the scope can never be left behind.
+package scope
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError is the error recorded when a child, or the function given to
+// Run, panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// ErrClosed is the value Go panics with once the scope's Run has returned.
+var ErrClosed = errors.New("scope: Go called after Run returned")
+
+// Scope is the set of goroutines started by one call of Run. It is only
+// valid while that call lasts.
+type Scope struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu     sync.Mutex
+	idle   sync.Cond // signalled when live drops to zero
+	live   int       // children still running
+	errs   []error   // in the order they happened
+	closed bool
+}
+
+// Run calls fn with a new scope and waits for fn and every child it, or
+// any child, started with Go. It returns the errors of fn and the
+// children, joined in the order they happened, or nil if there were none.
+func Run(ctx context.Context, fn func(s *Scope) error) error {
+	s := &Scope{}
+	s.idle.L = &s.mu
+	s.ctx, s.cancel = context.WithCancelCause(ctx)
+	defer s.cancel(nil)
+
+	s.fail(call(func() error { return fn(s) }))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.live > 0 {
+		s.idle.Wait()
+	}
+	s.closed = true
+	return errors.Join(s.errs...)
+}
+
+// Go starts fn in a goroutine of its own, handing it the scope's context.
+// If fn returns an error or panics, the scope is cancelled with that error
+// as the cause. Go may be called from fn or from any child, but panics
+// with ErrClosed once Run has returned.
+func (s *Scope) Go(fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		panic(ErrClosed)
+	}
+	s.live++
+	s.mu.Unlock()
+
+	go func() {
+		err := call(func() error { return fn(s.ctx) })
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.failLocked(err)
+		if s.live--; s.live == 0 {
+			s.idle.Broadcast()
+		}
+	}()
+}
+
+// Context returns the context the scope's children are handed.
+func (s *Scope) Context() context.Context { return s.ctx }
+
+// Cancel cancels the scope's context with cause, without recording cause
+// as an error of the scope.
+func (s *Scope) Cancel(cause error) { s.cancel(cause) }
+
+// fail records err, if any, and cancels the scope with it.
+func (s *Scope) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failLocked(err)
+}
+
+// failLocked is fail with s.mu held.
+func (s *Scope) failLocked(err error) {
+	if err == nil {
+		return
+	}
+	s.errs = append(s.errs, err)
+	s.cancel(err)
+}
+
+// call runs fn, converting a panic into a *PanicError.
+func call(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
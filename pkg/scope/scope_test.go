@@ -0,0 +1,157 @@
+package scope
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+func TestRunWaitsForChildrenAndCancelsTheirContext(t *testing.T) {
+	var childCtx context.Context
+	var finished atomic.Int32
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- Run(context.Background(), func(s *Scope) error {
+			childCtx = s.Context()
+			for range 3 {
+				s.Go(func(ctx context.Context) error {
+					<-release
+					finished.Add(1)
+					return nil
+				})
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Run returned %v with its children still running", err)
+	default:
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if n := finished.Load(); n != 3 {
+		t.Errorf("%d of 3 children had finished when Run returned", n)
+	}
+	if childCtx.Err() == nil {
+		t.Error("the children's context is still live after the scope ended")
+	}
+}
+
+func TestDeadlineCancelsChildren(t *testing.T) {
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := clock.WithTimeout(clock.With(context.Background(), f), time.Second)
+	defer cancel()
+
+	done := make(chan error)
+	causes := make(chan error, 2)
+	go func() {
+		done <- Run(ctx, func(s *Scope) error {
+			for range 2 {
+				s.Go(func(ctx context.Context) error {
+					<-ctx.Done()
+					causes <- context.Cause(ctx)
+					return nil
+				})
+			}
+			return nil
+		})
+	}()
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Run = %v: children that stop on cancellation are not errors", err)
+	}
+	for range 2 {
+		if err := <-causes; !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("a child saw cause %v, want %v", err, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestChildErrorCancelsSiblings(t *testing.T) {
+	errIndex := errors.New("index failed")
+	var sibling error
+	err := Run(context.Background(), func(s *Scope) error {
+		s.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			sibling = context.Cause(ctx)
+			return nil
+		})
+		s.Go(func(context.Context) error { return errIndex })
+		return nil
+	})
+	if !errors.Is(err, errIndex) {
+		t.Errorf("Run = %v, want %v", err, errIndex)
+	}
+	if !errors.Is(sibling, errIndex) {
+		t.Errorf("the sibling saw cause %v, want %v", sibling, errIndex)
+	}
+}
+
+func TestFnErrorCancelsChildren(t *testing.T) {
+	errSetup := errors.New("setup failed")
+	var child error
+	err := Run(context.Background(), func(s *Scope) error {
+		s.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			child = context.Cause(ctx)
+			return nil
+		})
+		return errSetup
+	})
+	if !errors.Is(err, errSetup) || !errors.Is(child, errSetup) {
+		t.Errorf("Run = %v and the child saw %v, want %v for both", err, child, errSetup)
+	}
+}
+
+func TestChildPanicIsRecorded(t *testing.T) {
+	err := Run(context.Background(), func(s *Scope) error {
+		s.Go(func(context.Context) error { panic("cauldron exploded") })
+		return nil
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "cauldron exploded" {
+		t.Fatalf("Run = %v, want a *PanicError for the child's panic", err)
+	}
+	if len(pe.Stack) == 0 {
+		t.Error("the PanicError has no stack")
+	}
+}
+
+func TestCancelIsNotAnError(t *testing.T) {
+	errEnough := errors.New("enough")
+	err := Run(context.Background(), func(s *Scope) error {
+		s.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		s.Cancel(errEnough)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Run = %v, want nil: Cancel records no error", err)
+	}
+}
+
+func TestGoAfterRunPanics(t *testing.T) {
+	var leaked *Scope
+	Run(context.Background(), func(s *Scope) error {
+		leaked = s
+		return nil
+	})
+	defer func() {
+		if r := recover(); r != ErrClosed {
+			t.Errorf("Go after Run panicked with %v, want %v", r, ErrClosed)
+		}
+	}()
+	leaked.Go(func(context.Context) error { return nil })
+}
@@ -0,0 +1,161 @@
+// Package semaphore provides a weighted semaphore whose acquisitions respect
+// context cancellation, for limiting how much work runs at once.
+//
+// It has the API of golang.org/x/sync/semaphore, without the dependency:
+//
+//	sem := semaphore.NewWeighted(4)
+//	if err := sem.Acquire(ctx, 2); err != nil {
+//		return err // ctx is done; nothing was acquired
+//	}
+//	defer sem.Release(2)
+//
+// An Acquire waiting for capacity returns as soon as its context is done,
+// with the context's cause, and leaves the semaphore as if it had never
+// asked. Waiters are served in the order they arrived: a large request at
+// the head of the queue is not starved by smaller ones behind it.
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// waiter is an Acquire waiting for n units. ready is closed once they are
+// its.
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// Weighted is a semaphore of a fixed number of units, acquired and released
+// in any amounts.
+type Weighted struct {
+	size    int64
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List
+}
+
+// NewWeighted returns a semaphore of n units.
+func NewWeighted(n int64) *Weighted {
+	return &Weighted{size: n}
+}
+
+// Acquire acquires n units, waiting until they are free or ctx is done. On
+// success it returns nil; otherwise it returns the cause of ctx and leaves
+// the semaphore unchanged. A request for more units than the semaphore has
+// waits until ctx is done.
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	done := ctx.Done()
+
+	s.mu.Lock()
+	select {
+	case <-done:
+		// Don't acquire for a context that is already done, even if the
+		// units are free.
+		s.mu.Unlock()
+		return context.Cause(ctx)
+	default:
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	if n > s.size {
+		s.mu.Unlock()
+		<-done
+		return context.Cause(ctx)
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(waiter{n: n, ready: ready})
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// Acquired just as ctx was done: give the units back.
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// The waiters behind the head may fit now that it has gone.
+			if front && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return context.Cause(ctx)
+
+	case <-ready:
+		// Prefer reporting the cancellation to acquiring for a context
+		// that is done anyway.
+		select {
+		case <-done:
+			s.Release(n)
+			return context.Cause(ctx)
+		default:
+		}
+		return nil
+	}
+}
+
+// TryAcquire acquires n units without waiting. It reports whether it did.
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release releases n units. It panics if that is more than are held.
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// Held returns the number of units acquired and not yet released.
+func (s *Weighted) Held() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cur
+}
+
+// Waiting returns the number of Acquire calls waiting for units.
+func (s *Weighted) Waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
+
+// notifyWaiters hands units to the waiters at the head of the queue for as
+// long as they fit. s.mu must be held.
+func (s *Weighted) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(waiter)
+		if s.size-s.cur < w.n {
+			// Stop at the first that doesn't fit, rather than let smaller
+			// requests behind it jump the queue and starve it.
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
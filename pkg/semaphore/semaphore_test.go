@@ -0,0 +1,155 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// waitForWaiters blocks until n calls of Acquire are queued on s.
+func waitForWaiters(t *testing.T, s *Weighted, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Waiting() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Waiting() = %d, want %d", s.Waiting(), n)
+		}
+		runtime.Gosched()
+	}
+}
+
+// acquire calls s.Acquire(ctx, n) in a new goroutine and returns a channel
+// that receives its result.
+func acquire(ctx context.Context, s *Weighted, n int64) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- s.Acquire(ctx, n) }()
+	return done
+}
+
+func TestAcquireRelease(t *testing.T) {
+	ctx := context.Background()
+	s := NewWeighted(3)
+	if err := s.Acquire(ctx, 2); err != nil {
+		t.Fatalf("Acquire(2) of 3 = %v", err)
+	}
+	if s.TryAcquire(2) {
+		t.Error("TryAcquire(2) with 1 unit free succeeded")
+	}
+	if !s.TryAcquire(1) {
+		t.Error("TryAcquire(1) with 1 unit free failed")
+	}
+	s.Release(3)
+	if n := s.Held(); n != 0 {
+		t.Errorf("Held() = %d after releasing everything, want 0", n)
+	}
+}
+
+func TestWaitersAreServedInOrder(t *testing.T) {
+	ctx := context.Background()
+	s := NewWeighted(4)
+	s.Acquire(ctx, 3)
+
+	big := acquire(ctx, s, 4)
+	waitForWaiters(t, s, 1)
+	small := acquire(ctx, s, 1)
+	waitForWaiters(t, s, 2)
+	if s.TryAcquire(1) {
+		t.Error("TryAcquire jumped the queue")
+	}
+
+	s.Release(3)
+	if err := <-big; err != nil {
+		t.Fatalf("the large request at the head = %v", err)
+	}
+	select {
+	case err := <-small:
+		t.Fatalf("the small request behind it returned %v while the large one held everything", err)
+	default:
+	}
+	s.Release(4)
+	if err := <-small; err != nil {
+		t.Fatalf("the small request = %v once the large one released", err)
+	}
+}
+
+func TestCancelledHeadReleasesThoseBehind(t *testing.T) {
+	s := NewWeighted(4)
+	s.Acquire(context.Background(), 3)
+
+	errGone := errors.New("gave up")
+	hctx, cancel := context.WithCancelCause(context.Background())
+	head := acquire(hctx, s, 4)
+	waitForWaiters(t, s, 1)
+	behind := acquire(context.Background(), s, 1)
+	waitForWaiters(t, s, 2)
+
+	cancel(errGone)
+	if err := <-head; !errors.Is(err, errGone) {
+		t.Fatalf("cancelled head = %v, want its cause %v", err, errGone)
+	}
+	if err := <-behind; err != nil {
+		t.Fatalf("the waiter behind the cancelled head = %v, want it to get the free unit", err)
+	}
+	if n := s.Held(); n != 4 {
+		t.Errorf("Held() = %d, want 4: the cancelled head must hold nothing", n)
+	}
+}
+
+func TestAcquireDeadline(t *testing.T) {
+	f := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := clock.With(context.Background(), f)
+	s := NewWeighted(1)
+	s.Acquire(ctx, 1)
+
+	dctx, cancel := clock.WithTimeout(ctx, time.Second)
+	defer cancel()
+	done := acquire(dctx, s, 1)
+	waitForWaiters(t, s, 1)
+	f.Advance(time.Second)
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire past its deadline = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := s.Waiting(); n != 0 {
+		t.Errorf("Waiting() = %d after the deadline, want 0", n)
+	}
+}
+
+func TestAcquireMoreThanSizeWaitsForCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewWeighted(2)
+	done := acquire(ctx, s, 3)
+	select {
+	case err := <-done:
+		t.Fatalf("Acquire(3) of 2 returned %v before its context was done", err)
+	default:
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire(3) of 2 = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestAcquireOnDoneContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewWeighted(1)
+	if err := s.Acquire(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire with a done context = %v, want %v", err, context.Canceled)
+	}
+	if n := s.Held(); n != 0 {
+		t.Errorf("Held() = %d, want 0", n)
+	}
+}
+
+func TestReleaseTooMuchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("releasing more than held did not panic")
+		}
+	}()
+	NewWeighted(1).Release(1)
+}
@@ -0,0 +1,101 @@
+// Package shutdown stops workers in two phases: first a soft drain, then a
+// hard cancel.
+//
+// Cancelling a context tells a worker to stop now, whatever it is in the
+// middle of. Often it is better to first ask it to stop taking new work and
+// let it finish what it has already accepted, and only cancel it if that
+// takes too long:
+//
+//	ctx, sd := shutdown.New(parent)
+//	// ... start workers with ctx ...
+//	sd.Shutdown(drainPeriod, allDone, nil)
+//
+// Workers learn of the drain through Draining, carried by their context,
+// and of the hard stop through ctx.Done() as usual.
+//
+// Staged orders a shutdown the other way: across groups of workers rather
+// than within each, cancelling one group at a time by priority.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// ErrHardStop is the cause the context is cancelled with when the drain
+// period runs out, unless Shutdown is given another.
+var ErrHardStop = errors.New("hard stop: the drain period is over")
+
+// Coordinator runs a two-phase shutdown of the workers under one context.
+type Coordinator struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	draining chan struct{}
+	once     sync.Once
+}
+
+type coordinatorKey struct{}
+
+// New returns a copy of parent that carries a Coordinator and is cancelled
+// by it, and the Coordinator.
+func New(parent context.Context) (context.Context, *Coordinator) {
+	c := &Coordinator{draining: make(chan struct{})}
+	ctx, cancel := context.WithCancelCause(parent)
+	c.ctx = context.WithValue(ctx, coordinatorKey{}, c)
+	c.cancel = cancel
+	return c.ctx, c
+}
+
+// Drain starts the first phase: the Draining channel closes, and workers
+// should stop taking new work. It may be called more than once.
+func (c *Coordinator) Drain() {
+	c.once.Do(func() { close(c.draining) })
+}
+
+// Draining returns a channel that is closed once Drain has been called.
+func (c *Coordinator) Draining() <-chan struct{} {
+	return c.draining
+}
+
+// Stop starts the second phase, cancelling the context with cause, or with
+// ErrHardStop if cause is nil. It also releases the context, so call it
+// even when every worker drained in time.
+func (c *Coordinator) Stop(cause error) {
+	if cause == nil {
+		cause = ErrHardStop
+	}
+	c.Drain()
+	c.cancel(cause)
+}
+
+// Shutdown runs both phases: it calls Drain, waits until done is closed or
+// drain has passed on the context's clock, and then calls Stop with cause.
+// It reports whether done closed in time, that is whether every worker
+// drained before the hard stop. A nil done waits out the whole period.
+func (c *Coordinator) Shutdown(drain time.Duration, done <-chan struct{}, cause error) (drained bool) {
+	c.Drain()
+	timer := clock.From(c.ctx).NewTimer(drain)
+	defer timer.Stop()
+	select {
+	case <-done:
+		drained = true
+	case <-timer.C():
+	case <-c.ctx.Done():
+	}
+	c.Stop(cause)
+	return drained
+}
+
+// Draining returns the Draining channel of the Coordinator carried by ctx.
+// Without one it returns nil, which never becomes ready in a select, so
+// workers can watch it unconditionally.
+func Draining(ctx context.Context) <-chan struct{} {
+	if c, ok := ctx.Value(coordinatorKey{}).(*Coordinator); ok {
+		return c.draining
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestShutdownDrainsInTime(t *testing.T) {
+	errDone := errors.New("deploy finished")
+	f := clock.NewFake(epoch)
+	ctx, sd := New(clock.With(context.Background(), f))
+
+	done := make(chan struct{})
+	go func() {
+		<-Draining(ctx)
+		if ctx.Err() != nil {
+			t.Error("the context was cancelled before the worker could drain")
+		}
+		close(done)
+	}()
+	if !sd.Shutdown(time.Second, done, errDone) {
+		t.Error("Shutdown reported a hard stop although the worker drained")
+	}
+	if !errors.Is(context.Cause(ctx), errDone) {
+		t.Errorf("cause after Shutdown = %v, want %v", context.Cause(ctx), errDone)
+	}
+	if f.Now() != epoch {
+		t.Errorf("Shutdown waited %v of the drain period after the worker was done", f.Now().Sub(epoch))
+	}
+}
+
+func TestShutdownHardStopsAfterDrainPeriod(t *testing.T) {
+	f := clock.NewFake(epoch)
+	ctx, sd := New(clock.With(context.Background(), f))
+
+	drained := make(chan bool)
+	go func() { drained <- sd.Shutdown(time.Second, make(chan struct{}), nil) }()
+	f.BlockUntil(1)
+	select {
+	case <-Draining(ctx):
+	default:
+		t.Fatal("Draining is not closed while Shutdown waits")
+	}
+	f.Advance(999 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatal("the context was cancelled before the drain period was over")
+	}
+	f.Advance(time.Millisecond)
+	if <-drained {
+		t.Error("Shutdown reported the workers drained although done never closed")
+	}
+	if !errors.Is(context.Cause(ctx), ErrHardStop) {
+		t.Errorf("cause after the drain period = %v, want %v", context.Cause(ctx), ErrHardStop)
+	}
+}
+
+func TestShutdownEndsWithParent(t *testing.T) {
+	errGone := errors.New("parent gone")
+	f := clock.NewFake(epoch)
+	parent, cancel := context.WithCancelCause(clock.With(context.Background(), f))
+	ctx, sd := New(parent)
+
+	drained := make(chan bool)
+	go func() { drained <- sd.Shutdown(time.Hour, nil, nil) }()
+	f.BlockUntil(1)
+	cancel(errGone)
+	if <-drained {
+		t.Error("Shutdown reported a drain when its parent was cancelled")
+	}
+	if !errors.Is(context.Cause(ctx), errGone) {
+		t.Errorf("cause = %v, want the parent's %v", context.Cause(ctx), errGone)
+	}
+}
+
+func TestDrainingWithoutCoordinator(t *testing.T) {
+	if Draining(context.Background()) != nil {
+		t.Error("Draining without a coordinator is not nil")
+	}
+}
+
+func TestStagedTeardownOrder(t *testing.T) {
+	f := clock.NewFake(epoch)
+	ctx := clock.With(context.Background(), f)
+	errJobs, errHandlers, errFlush := errors.New("jobs"), errors.New("handlers"), errors.New("flushers")
+
+	var s Staged
+	flushers := s.Add(ctx, "flushers", 2, errFlush)
+	jobs := s.Add(ctx, "jobs", 1, errJobs)
+	handlers := s.Add(ctx, "handlers", 1, errHandlers) // same priority as jobs, added later
+	all := []*Stage{flushers, jobs, handlers}
+
+	var order []string
+	s.Teardown(func(st *Stage) {
+		order = append(order, st.Name)
+		if !errors.Is(context.Cause(st.Context()), st.Cause) {
+			t.Errorf("%s: cause %v, want %v", st.Name, context.Cause(st.Context()), st.Cause)
+		}
+		live := 0
+		for _, other := range all {
+			if other.Context().Err() == nil {
+				live++
+			}
+		}
+		if want := len(all) - len(order); live != want {
+			t.Errorf("while waiting for %s, %d stage(s) live, want %d", st.Name, live, want)
+		}
+		f.Advance(100 * time.Millisecond) // the stage takes 100ms to stop
+	})
+
+	if want := []string{"jobs", "handlers", "flushers"}; !slices.Equal(order, want) {
+		t.Errorf("torn down in order %v, want %v", order, want)
+	}
+	for i, st := range []*Stage{jobs, handlers, flushers} {
+		if want := epoch.Add(time.Duration(i) * 100 * time.Millisecond); !st.CancelledAt.Equal(want) {
+			t.Errorf("%s cancelled at %v, want %v", st.Name, st.CancelledAt.Sub(epoch), want.Sub(epoch))
+		}
+	}
+}
+
+func TestStagedCancelAll(t *testing.T) {
+	errStop := errors.New("stop")
+	var s Staged
+	a := s.Add(context.Background(), "a", 1, errStop)
+	b := s.Add(context.Background(), "b", 2, errStop)
+	s.CancelAll()
+	for _, st := range []*Stage{a, b} {
+		if !errors.Is(context.Cause(st.Context()), errStop) {
+			t.Errorf("%s: cause %v after CancelAll, want %v", st.Name, context.Cause(st.Context()), errStop)
+		}
+	}
+}
+
+// TestStagedTeardownGivesUpOnStuckStage bounds each stage's wait with a
+// timeout, as the staged-teardown scenario does, so a stage whose workers
+// ignore cancellation delays those after it by its timeout and no more.
+func TestStagedTeardownGivesUpOnStuckStage(t *testing.T) {
+	f := clock.NewFake(epoch)
+	ctx := clock.With(context.Background(), f)
+	var s Staged
+	stuck := s.Add(ctx, "stuck", 1, errors.New("stop"))
+	after := s.Add(ctx, "after", 2, errors.New("stop"))
+
+	exited := map[*Stage]chan struct{}{stuck: make(chan struct{}), after: make(chan struct{})}
+	go func() { <-after.Context().Done(); close(exited[after]) }() // "stuck" never exits
+
+	gaveUp := make(map[string]bool)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Teardown(func(st *Stage) {
+			timer := clock.From(st.Context()).NewTimer(time.Second)
+			defer timer.Stop()
+			select {
+			case <-exited[st]:
+			case <-timer.C():
+				gaveUp[st.Name] = true
+			}
+		})
+	}()
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	<-done
+
+	if !gaveUp["stuck"] || gaveUp["after"] {
+		t.Errorf("gave up on %v, want only stuck", gaveUp)
+	}
+	if want := epoch.Add(time.Second); !after.CancelledAt.Equal(want) {
+		t.Errorf("after cancelled at %v, want +1s: stuck's timeout", after.CancelledAt.Sub(epoch))
+	}
+}
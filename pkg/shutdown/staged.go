@@ -0,0 +1,69 @@
+package shutdown
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxaudit"
+)
+
+// Stage is one group of workers in a Staged teardown.
+type Stage struct {
+	// Name identifies the stage, such as "handlers".
+	Name string
+	// Priority orders the stages: lower priorities are cancelled first.
+	Priority int
+	// Cause is what the stage's context is cancelled with.
+	Cause error
+	// CancelledAt is when Teardown cancelled the stage; zero until then.
+	CancelledAt time.Time
+
+	ctx    context.Context
+	cancel func(error)
+}
+
+// Context returns the stage's context, to start its workers with.
+func (s *Stage) Context() context.Context { return s.ctx }
+
+// Staged tears workers down one stage at a time: background jobs first,
+// say, then request handlers, and the flushers that must see everything
+// else finish last. Each stage has a context of its own, derived from a
+// common parent, that Teardown cancels with the stage's cause.
+type Staged struct {
+	stages []*Stage
+}
+
+// Add derives a context for a new stage from parent and returns the stage.
+func (s *Staged) Add(parent context.Context, name string, priority int, cause error) *Stage {
+	ctx, cancel := context.WithCancelCause(parent)
+	st := &Stage{Name: name, Priority: priority, Cause: cause, ctx: ctx}
+	st.cancel = ctxaudit.Track(ctx, "stage "+name, cancel, 1)
+	s.stages = append(s.stages, st)
+	return st
+}
+
+// Teardown cancels the stages in priority order, lowest first; stages of
+// equal priority go in the order they were added. After cancelling each
+// stage it calls wait, if not nil, which should return once the stage's
+// workers have exited or it has given up on them.
+func (s *Staged) Teardown(wait func(st *Stage)) {
+	order := slices.Clone(s.stages)
+	slices.SortStableFunc(order, func(a, b *Stage) int { return a.Priority - b.Priority })
+	for _, st := range order {
+		st.CancelledAt = clock.From(st.ctx).Now()
+		st.cancel(st.Cause)
+		if wait != nil {
+			wait(st)
+		}
+	}
+}
+
+// CancelAll cancels every stage at once. Use it to release the stages if
+// Teardown may not have run.
+func (s *Staged) CancelAll() {
+	for _, st := range s.stages {
+		st.cancel(st.Cause)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package stackdump
+
+import "io"
+
+// OnSignal does nothing: there is no SIGUSR1 on this platform. Call Write
+// directly instead.
+func OnSignal(w io.Writer) (stop func()) {
+	return func() {}
+}
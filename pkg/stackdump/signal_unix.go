@@ -0,0 +1,32 @@
+//go:build unix
+
+package stackdump
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// OnSignal writes a dump to w each time the process receives SIGUSR1, as
+// with kill -USR1 <pid>, until stop is called.
+func OnSignal(w io.Writer) (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-c:
+				Write(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
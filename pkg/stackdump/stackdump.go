@@ -0,0 +1,34 @@
+// Package stackdump writes the stacks of every goroutine in the process,
+// each labelled with the worker it is running, so a leaked worker can be
+// found and inspected while the demonstration is still going.
+package stackdump
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/context-demo/pkg/leakcheck"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Write writes the stack of every goroutine to w. Goroutines running a
+// worker are labelled with its name.
+func Write(w io.Writer) {
+	names := worker.Goroutines()
+	snap := leakcheck.Take()
+	fmt.Fprintf(w, "\n=== %d goroutine(s), %d running a worker ===\n", len(snap), len(names))
+	for _, g := range snap {
+		label := ""
+		if r, ok := names[g.ID]; ok {
+			label = " worker=" + r.Name
+		}
+		fmt.Fprintf(w, "\ngoroutine %d [%s]%s:\n", g.ID, g.State, label)
+		for _, f := range g.Stack {
+			fmt.Fprintf(w, "    %v\n", f)
+		}
+		if g.CreatedBy.Func != "" {
+			fmt.Fprintf(w, "  created by %v\n", g.CreatedBy)
+		}
+	}
+	fmt.Fprintln(w, "\n=== end of goroutine dump ===")
+}
@@ -0,0 +1,131 @@
+// Package supervisor restarts failed workers until their parent context is
+// cancelled.
+//
+// A Supervisor is itself a worker.Worker, so it can be launched, observed
+// and cancelled like any other worker. Cancelling its context is the only
+// way to stop it: children that return errors or panic are restarted after
+// a backoff delay, while children that return nil are left stopped.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Backoff returns how long to wait before restart number attempt, counting
+// from zero, of a child that keeps failing.
+type Backoff func(attempt int) time.Duration
+
+// Constant returns a Backoff that always waits d.
+func Constant(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// Exponential returns a Backoff that starts at initial and doubles with each
+// attempt, never exceeding maxDelay.
+func Exponential(initial, maxDelay time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := initial
+		for i := 0; i < attempt && d < maxDelay; i++ {
+			d *= 2
+		}
+		return min(d, maxDelay)
+	}
+}
+
+// DefaultBackoff is used when Supervisor.Backoff is nil.
+var DefaultBackoff = Exponential(100*time.Millisecond, 2*time.Second)
+
+// Child is a worker run under a Supervisor.
+type Child struct {
+	// Name identifies the child in logs and in its context; see
+	// worker.WithWorkerName.
+	Name   string
+	Worker worker.Worker
+}
+
+// PanicError is the error recorded when a child panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Supervisor runs a set of children and restarts those that fail.
+type Supervisor struct {
+	// Children are started concurrently when Run is called.
+	Children []Child
+	// Backoff spaces out restarts. Defaults to DefaultBackoff.
+	Backoff Backoff
+
+	restarts atomic.Int64
+}
+
+// Run supervises every child until ctx is cancelled and all children have
+// returned. It always returns nil.
+func (s *Supervisor) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, c := range s.Children {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.supervise(ctx, c)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// Restarts reports how many times children have been restarted.
+func (s *Supervisor) Restarts() int64 {
+	return s.restarts.Load()
+}
+
+func (s *Supervisor) supervise(ctx context.Context, c Child) {
+	backoff := s.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := runChild(ctx, c)
+		if ctx.Err() != nil {
+			// **CRITICAL:** never restart once the parent has been cancelled.
+			worker.Notef(ctx, "Supervisor: %s stopped after cancellation (%v). Not restarting.", c.Name, context.Cause(ctx))
+			return
+		}
+		if err == nil {
+			worker.Notef(ctx, "Supervisor: %s finished. Not restarting.", c.Name)
+			return
+		}
+
+		delay := backoff(attempt)
+		worker.Notef(ctx, "Supervisor: %s failed (%v). Restarting in %v.", c.Name, err, delay)
+
+		if ctxutil.Sleep(ctx, delay) != nil {
+			worker.Notef(ctx, "Supervisor: cancelled while %s was backing off. Not restarting.", c.Name)
+			return
+		}
+		s.restarts.Add(1)
+	}
+}
+
+// runChild runs c once, converting a panic into a *PanicError.
+func runChild(ctx context.Context, c Child) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return c.Worker.Run(worker.WithWorkerName(ctx, c.Name))
+}
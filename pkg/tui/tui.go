@@ -0,0 +1,309 @@
+// Package tui shows a scenario's workers live in the terminal.
+//
+// A Model follows the run through the event bus, redraws a screen of worker
+// states, the context tree beneath the scenario and the live goroutine
+// count, and lets the user cancel workers by hand. It needs nothing beyond
+// the standard library: the screen is redrawn with ANSI escape sequences,
+// and since the standard library cannot put a terminal into raw mode, each
+// key command is followed by Enter.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/context-demo/pkg/ansi"
+	"github.com/context-demo/pkg/ctxmw"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/worker"
+)
+
+// ErrCancelledByUser is the cause given to workers cancelled from the
+// keyboard.
+var ErrCancelledByUser = errors.New("cancelled from the TUI")
+
+// State is where a worker is in its lifecycle, as far as the TUI has seen.
+type State int
+
+// The states a worker moves through.
+const (
+	Running State = iota
+	Cancelled
+	Exited
+	Leaked
+)
+
+func (s State) String() string {
+	switch s {
+	case Cancelled:
+		return "cancelled"
+	case Exited:
+		return "exited"
+	case Leaked:
+		return "leaked"
+	default:
+		return "running"
+	}
+}
+
+// row is what the TUI knows about one worker.
+type row struct {
+	name      string
+	state     State
+	processed int64
+	cause     string
+	budget    float64                 // fraction of the deadline budget used, as last warned
+	cancel    context.CancelCauseFunc // nil until the worker starts
+}
+
+// Model is the state behind the screen. Subscribe it to the run's events
+// and install its Middleware so it can cancel workers; see
+// contextdemo.WithSink and WithMiddleware.
+type Model struct {
+	mu       sync.Mutex
+	scenario string
+	rows     []*row
+	status   string
+}
+
+// New returns an empty Model.
+func New() *Model {
+	return &Model{}
+}
+
+// lookup returns the row for name, adding one if needed. Rows are kept
+// sorted by name rather than in whatever order the workers happened to
+// start. m.mu must be held.
+func (m *Model) lookup(name string) *row {
+	i, found := slices.BinarySearchFunc(m.rows, name, func(r *row, name string) int {
+		return strings.Compare(r.name, name)
+	})
+	if !found {
+		m.rows = slices.Insert(m.rows, i, &row{name: name})
+	}
+	return m.rows[i]
+}
+
+// Handle implements event.Sink.
+func (m *Model) Handle(e event.Event) {
+	h := e.EventHeader()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if h.Scenario != "" {
+		m.scenario = h.Scenario
+	}
+	if h.Worker == "" {
+		return
+	}
+	r := m.lookup(h.Worker)
+	switch e := e.(type) {
+	case event.TickCompleted:
+		r.processed = e.Processed
+	case event.DeadlineApproaching:
+		r.budget = e.Consumed
+	case event.CancellationReceived:
+		r.state = Cancelled
+		if e.Cause != nil {
+			r.cause = e.Cause.Error()
+		}
+	case event.WorkerExited:
+		r.state = Exited
+		r.processed = e.Processed
+		if err := firstErr(e.Err, e.Cause); err != nil {
+			r.cause = err.Error()
+		}
+	case event.WorkerLeaked:
+		r.state = Leaked
+		r.processed = e.Processed
+	}
+}
+
+// firstErr returns the first non-nil error.
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type cancelKey struct{}
+
+// Middleware gives every worker a context the TUI can cancel on its own.
+// The cancel handle is registered under the worker's name when it starts,
+// since only then is the name in the context.
+func (m *Model) Middleware() ctxmw.Middleware {
+	hooks := &worker.Hooks{
+		OnStart: func(ctx context.Context) {
+			name, _ := worker.WorkerName(ctx)
+			cancel, ok := ctx.Value(cancelKey{}).(context.CancelCauseFunc)
+			if !ok {
+				return
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.lookup(name).cancel = cancel
+		},
+	}
+	return func(ctx context.Context) context.Context {
+		ctx, cancel := context.WithCancelCause(ctx)
+		ctx = context.WithValue(ctx, cancelKey{}, cancel)
+		return worker.WithHooks(ctx, hooks)
+	}
+}
+
+// Cancel cancels the worker shown at 1-based position n.
+func (m *Model) Cancel(n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n < 1 || n > len(m.rows) {
+		return fmt.Errorf("no worker %d", n)
+	}
+	r := m.rows[n-1]
+	if r.cancel == nil {
+		return fmt.Errorf("%s cannot be cancelled from here", r.name)
+	}
+	r.cancel(ErrCancelledByUser)
+	m.status = "cancelled " + r.name
+	return nil
+}
+
+// CancelAll cancels every worker.
+func (m *Model) CancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.rows {
+		if r.cancel != nil {
+			r.cancel(ErrCancelledByUser)
+		}
+	}
+	m.status = "cancelled all workers"
+}
+
+// Release cancels whatever contexts Middleware created that are still live.
+// Call it once the run is over.
+func (m *Model) Release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.rows {
+		if r.cancel != nil {
+			r.cancel(nil)
+		}
+	}
+}
+
+// Render draws one frame to w.
+func (m *Model) Render(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "contextdemo: %s    goroutines: %d\n\n", m.scenario, runtime.NumGoroutine())
+
+	fmt.Fprintf(&b, "   %-18s %-10s %9s  %-6s  %s\n", "WORKER", "STATE", "PROCESSED", "BUDGET", "CAUSE")
+	for i, r := range m.rows {
+		budget := fmt.Sprintf("%-6s", "-")
+		if r.budget > 0 {
+			budget = fmt.Sprintf("%-6s", fmt.Sprintf("%.0f%%", r.budget*100))
+			budget = ansi.Paint(budgetColor(r.budget), budget)
+		}
+		fmt.Fprintf(&b, "%2d %-18s %s %9d  %s  %s\n", i+1, r.name, ansi.Paint(stateColors[r.state], fmt.Sprintf("%-10s", r.state)), r.processed, budget, r.cause)
+	}
+
+	b.WriteString("\ncontext tree:\n")
+	fmt.Fprintf(&b, "%s\n", m.scenario)
+	for i, r := range m.rows {
+		branch := "├── "
+		if i == len(m.rows)-1 {
+			branch = "└── "
+		}
+		ctxState := "live"
+		if r.state != Running {
+			ctxState = "done"
+		}
+		fmt.Fprintf(&b, "%s%s (%s)\n", branch, r.name, ctxState)
+	}
+
+	b.WriteString("\nkeys: <n> cancel worker n, a cancel all, q stop watching; then Enter\n")
+	if m.status != "" {
+		fmt.Fprintf(&b, "%s\n", m.status)
+	}
+	io.WriteString(w, b.String())
+}
+
+// stateColors is the colour of each state on screen.
+var stateColors = map[State]string{
+	Running:   ansi.Cyan,
+	Cancelled: ansi.Yellow,
+	Exited:    ansi.Green,
+	Leaked:    ansi.Red,
+}
+
+// budgetColor is the colour of a used-up fraction of the deadline budget,
+// escalating as it grows.
+func budgetColor(used float64) string {
+	switch {
+	case used >= 0.9:
+		return ansi.Red
+	case used >= 0.75:
+		return ansi.Yellow
+	default:
+		return ""
+	}
+}
+
+// Run redraws the screen on w every interval until done is closed, acting
+// on commands read from in. It draws a final frame before returning.
+func (m *Model) Run(done <-chan struct{}, in io.Reader, w io.Writer, interval time.Duration) {
+	quit := make(chan struct{})
+	go m.readKeys(in, quit)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		m.Render(w)
+		select {
+		case <-done:
+			m.Render(w)
+			return
+		case <-quit:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// readKeys applies commands read line by line from in, closing quit on q.
+func (m *Model) readKeys(in io.Reader, quit chan<- struct{}) {
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		switch key := strings.TrimSpace(sc.Text()); key {
+		case "":
+		case "q":
+			close(quit)
+			return
+		case "a":
+			m.CancelAll()
+		default:
+			n, err := strconv.Atoi(key)
+			if err == nil {
+				err = m.Cancel(n)
+			}
+			if err != nil {
+				m.mu.Lock()
+				m.status = fmt.Sprintf("%q: %v", key, err)
+				m.mu.Unlock()
+			}
+		}
+	}
+}
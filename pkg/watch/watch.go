@@ -0,0 +1,81 @@
+// Package watch samples the state of the process while a scenario runs, so
+// a leak shows up as a number that refuses to go down.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/event"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Point is one goroutine count taken by Goroutines.
+type Point struct {
+	At         time.Time
+	Goroutines int
+}
+
+// Start publishes a GoroutineSample on the bus carried by ctx straight
+// away, as a baseline, and then from a goroutine of its own every interval,
+// measured on the clock carried by ctx. Sampling ends when ctx is done or
+// stop is called, whichever comes first. stop takes a last sample and
+// returns every sample taken, for Sparkline.
+func Start(ctx context.Context, interval time.Duration) (stop func() []Point) {
+	ctx, cancel := context.WithCancel(ctx)
+	first := Sample(ctx)
+	done := make(chan []Point, 1)
+	go func() {
+		ticks := ctxutil.Tick(ctx, interval)
+		points := []Point{first}
+		for {
+			select {
+			case <-ctx.Done():
+				done <- points
+				return
+			case <-ticks:
+				points = append(points, Sample(ctx))
+			}
+		}
+	}()
+	return func() []Point {
+		cancel()
+		return append(<-done, Sample(ctx))
+	}
+}
+
+// Sample publishes a single GoroutineSample on the bus carried by ctx and
+// returns it.
+func Sample(ctx context.Context) Point {
+	n := runtime.NumGoroutine()
+	h := worker.Header(ctx, fmt.Sprintf("[watch] goroutines: %d", n))
+	event.BusFrom(ctx).Publish(event.GoroutineSample{Header: h, Goroutines: n})
+	return Point{At: h.Time, Goroutines: n}
+}
+
+// sparks are the bar heights Sparkline draws with, lowest first.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws points as a row of bars, one per sample, scaled between
+// the lowest and highest count. A flat run is a flat line.
+func Sparkline(points []Point) string {
+	if len(points) == 0 {
+		return ""
+	}
+	lo, hi := points[0].Goroutines, points[0].Goroutines
+	for _, p := range points {
+		lo, hi = min(lo, p.Goroutines), max(hi, p.Goroutines)
+	}
+	line := make([]rune, len(points))
+	for i, p := range points {
+		level := 0
+		if hi > lo {
+			level = (p.Goroutines - lo) * (len(sparks) - 1) / (hi - lo)
+		}
+		line[i] = sparks[level]
+	}
+	return string(line)
+}
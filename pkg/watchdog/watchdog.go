@@ -0,0 +1,64 @@
+// Package watchdog keeps a demonstration from hanging forever on shutdown.
+//
+// A scenario that waits for its workers without a bound, as with a bare
+// sync.WaitGroup, never returns if one of them ignores cancellation. A
+// Watchdog started alongside the run fires if the run is still going at a
+// hard deadline, well past the point where every well-behaved worker has
+// stopped. Kill, the usual thing to fire, shows what is stuck and ends the
+// process: a stuck goroutine cannot be stopped from outside, but the
+// process it lives in can.
+package watchdog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/context-demo/pkg/stackdump"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Watchdog calls a function once a deadline passes, unless it is stopped
+// first. Time is kept by the wall clock, whatever clock the run uses, since
+// a hung run hangs in real time.
+type Watchdog struct {
+	t *time.Timer
+}
+
+// Start returns a Watchdog that calls fire in its own goroutine after d.
+func Start(d time.Duration, fire func()) *Watchdog {
+	return &Watchdog{t: time.AfterFunc(d, fire)}
+}
+
+// Stop disarms w. It reports whether it did so before w fired.
+func (w *Watchdog) Stop() bool {
+	return w.t.Stop()
+}
+
+// Stuck returns the names of the workers still running, sorted.
+func Stuck() []string {
+	var names []string
+	for _, r := range worker.Goroutines() {
+		names = append(names, r.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Kill returns a function to fire that writes the workers still running
+// and the stack of every goroutine to w, then exits the process with code.
+func Kill(w io.Writer, code int) func() {
+	return func() {
+		stuck := Stuck()
+		fmt.Fprintf(w, "\nwatchdog: the run did not shut down in time; %d worker(s) still running", len(stuck))
+		if len(stuck) > 0 {
+			fmt.Fprintf(w, ": %s", strings.Join(stuck, ", "))
+		}
+		fmt.Fprintln(w)
+		stackdump.Write(w)
+		os.Exit(code)
+	}
+}
@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultCopyistInterval is how long a Copyist takes over a page when its
+// Interval is zero.
+const DefaultCopyistInterval = 200 * time.Millisecond
+
+// ChapterPages is how many pages make a chapter for a Copyist.
+const ChapterPages = 4
+
+// Copyist copies a book page by page and decides how to stop from the
+// cancellation cause alone, so one piece of code shows three shutdowns:
+//
+//   - On a cause.UpstreamFailure, cause.OperatorAbort or
+//     cause.DeadlineBudgetExhausted it aborts, dropping the page in hand.
+//   - On a cause.Preempted it checkpoints: it spends CheckpointTime writing
+//     down the page to resume from, then returns.
+//   - On anything else, such as a cause.ShutdownRequested, it drains: it
+//     finishes the chapter in hand, for up to Drain, so no chapter is left
+//     half-copied.
+type Copyist struct {
+	// Interval is how long each page takes to copy.
+	Interval time.Duration
+	// Drain is how long the worker may go on copying after an orderly
+	// shutdown.
+	Drain time.Duration
+	// CheckpointTime is how long writing a checkpoint takes.
+	CheckpointTime time.Duration
+
+	processed  atomic.Int64
+	checkpoint atomic.Int64
+}
+
+// Run copies pages until ctx is cancelled, then stops as its cause says.
+func (c *Copyist) Run(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultCopyistInterval
+	}
+	Notef(ctx, "A copyist starts on the book: %v a page, %d pages a chapter.", interval, ChapterPages)
+
+	clk := clock.From(ctx)
+	for {
+		page := clk.NewTimer(interval)
+		select {
+		case <-page.C():
+			ReportTick(ctx, c.processed.Add(1), fmt.Sprintf("Copyist copied page %d", c.processed.Load()))
+		case <-ctx.Done():
+			page.Stop()
+			return c.stop(ctx, clk, interval)
+		}
+	}
+}
+
+// stop ends the run after cancellation in the way the cause calls for.
+func (c *Copyist) stop(ctx context.Context, clk clock.Clock, interval time.Duration) error {
+	var (
+		up      *cause.UpstreamFailure
+		abort   *cause.OperatorAbort
+		budget  *cause.DeadlineBudgetExhausted
+		preempt *cause.Preempted
+	)
+	why := context.Cause(ctx)
+	next := c.processed.Load() + 1
+	switch {
+	case errors.As(why, &up), errors.As(why, &abort), errors.As(why, &budget):
+		ReportCancel(ctx, fmt.Sprintf("The copyist aborts and drops page %d half-copied. Cause: %v", next, why))
+		return nil
+	case errors.As(why, &preempt):
+		ReportCancel(ctx, fmt.Sprintf("The copyist is preempted and writes a checkpoint before leaving. Cause: %v", why))
+		clk.Sleep(c.CheckpointTime) // a checkpoint is written whole or not at all
+		c.checkpoint.Store(next)
+		Notef(ctx, "Checkpoint written: resume from page %d.", next)
+		return nil
+	default:
+		left := (ChapterPages - c.processed.Load()%ChapterPages) % ChapterPages
+		ReportCancel(ctx, fmt.Sprintf("The copyist finishes the chapter in hand, %d page(s) left, for up to %v. Cause: %v", left, c.Drain, why))
+		deadline := clk.NewTimer(c.Drain)
+		defer deadline.Stop()
+		for ; left > 0; left-- {
+			page := clk.NewTimer(interval)
+			select {
+			case <-page.C():
+				ReportTick(ctx, c.processed.Add(1), fmt.Sprintf("Copyist copied page %d while draining", c.processed.Load()))
+			case <-deadline.C():
+				page.Stop()
+				Notef(ctx, "The copyist's drain deadline passed with %d page(s) of the chapter uncopied.", left)
+				return nil
+			}
+		}
+		Notef(ctx, "The copyist closes the book at the end of a chapter, after page %d.", c.processed.Load())
+		return nil
+	}
+}
+
+// Processed reports how many pages the worker has copied.
+func (c *Copyist) Processed() int64 {
+	return c.processed.Load()
+}
+
+// Checkpoint reports the page to resume from, or 0 if the worker has not
+// written a checkpoint.
+func (c *Copyist) Checkpoint() int64 {
+	return c.checkpoint.Load()
+}
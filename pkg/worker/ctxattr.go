@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+)
+
+type ctxKey int
+
+const (
+	workerNameKey ctxKey = iota
+	scenarioNameKey
+	requestIDKey
+)
+
+// WithWorkerName returns a copy of ctx carrying the name of the worker it is
+// handed to. Execute calls it for every worker it runs.
+func WithWorkerName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, workerNameKey, name)
+}
+
+// WithScenarioName returns a copy of ctx carrying the running scenario's name.
+func WithScenarioName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, scenarioNameKey, name)
+}
+
+// WithRequestID returns a copy of ctx carrying a caller-supplied request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// WorkerName returns the worker name stored in ctx, if any.
+func WorkerName(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(workerNameKey).(string)
+	return s, ok
+}
+
+// ScenarioName returns the scenario name stored in ctx, if any.
+func ScenarioName(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(scenarioNameKey).(string)
+	return s, ok
+}
+
+// RequestID returns the request ID stored in ctx, if any.
+func RequestID(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(requestIDKey).(string)
+	return s, ok
+}
+
+// Attrs returns the slog attributes for every value above that ctx carries.
+func Attrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if s, ok := ScenarioName(ctx); ok {
+		attrs = append(attrs, slog.String("scenario", s))
+	}
+	if s, ok := WorkerName(ctx); ok {
+		attrs = append(attrs, slog.String("worker", s))
+	}
+	if s, ok := RequestID(ctx); ok {
+		attrs = append(attrs, slog.String("request_id", s))
+	}
+	return attrs
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/shutdown"
+)
+
+// DefaultOrderTime is how long a HouseElf takes over an order when its
+// OrderTime is zero.
+const DefaultOrderTime = 200 * time.Millisecond
+
+// HouseElf works through kitchen orders one at a time, and understands
+// two-phase shutdown: once its context's shutdown.Coordinator starts
+// draining, it takes no new orders and returns after serving the one in
+// hand. Only if the context is cancelled first does it abandon an order
+// half-cooked.
+type HouseElf struct {
+	// OrderTime is how long each order takes.
+	OrderTime time.Duration
+
+	processed atomic.Int64
+}
+
+// Run serves orders until the kitchen drains or ctx is cancelled.
+func (e *HouseElf) Run(ctx context.Context) error {
+	orderTime := e.OrderTime
+	if orderTime <= 0 {
+		orderTime = DefaultOrderTime
+	}
+	Notef(ctx, "A house-elf starts cooking. Each order takes %v.", orderTime)
+
+	clk := clock.From(ctx)
+	draining := shutdown.Draining(ctx)
+	for order := int64(1); ; order++ {
+		// Check for the drain before taking an order, never during one.
+		select {
+		case <-draining:
+			Notef(ctx, "The kitchen is closing: the house-elf takes no new orders and leaves after serving %d.", e.processed.Load())
+			return nil
+		case <-ctx.Done():
+			ReportCancel(ctx, fmt.Sprintf("The house-elf is sent away before order %d: %v", order, context.Cause(ctx)))
+			return nil
+		default:
+		}
+
+		timer := clk.NewTimer(orderTime)
+		select {
+		case <-timer.C():
+			ReportTick(ctx, e.processed.Add(1), fmt.Sprintf("House-elf served order %d", order))
+		case <-ctx.Done():
+			timer.Stop()
+			ReportCancel(ctx, fmt.Sprintf("The house-elf abandons order %d half-cooked: %v", order, context.Cause(ctx)))
+			return nil
+		}
+	}
+}
+
+// Processed reports how many orders the worker has served.
+func (e *HouseElf) Processed() int64 {
+	return e.processed.Load()
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultFlakyInterval is the tick interval used when Flaky.Interval is zero.
+const DefaultFlakyInterval = 200 * time.Millisecond
+
+// ErrKnightBusCrashed is returned by a Flaky worker when it fails.
+var ErrKnightBusCrashed = errors.New("the Knight Bus crashed")
+
+// Flaky is a well-behaved worker that fails on its own after a few units of
+// work, either by returning ErrKnightBusCrashed or by panicking. It is the
+// raw material for supervision and error-propagation demonstrations.
+type Flaky struct {
+	// Interval is how often the worker does a unit of work.
+	Interval time.Duration
+	// FailAfter is the number of units of work done before failing. Zero
+	// means the worker fails before doing any.
+	FailAfter int
+	// Panic makes the worker panic instead of returning an error.
+	Panic bool
+
+	processed atomic.Int64
+}
+
+// Run works until it fails or ctx is cancelled, whichever comes first.
+func (f *Flaky) Run(ctx context.Context) error {
+	name, _ := WorkerName(ctx)
+	Notef(ctx, "Boarding the Knight Bus (%s). It will crash after %d stops.", name, f.FailAfter)
+
+	interval := f.Interval
+	if interval <= 0 {
+		interval = DefaultFlakyInterval
+	}
+
+	ticker := clock.From(ctx).NewTicker(interval)
+	defer ticker.Stop()
+
+	for done := 0; ; done++ {
+		if done >= f.FailAfter {
+			if f.Panic {
+				panic(ErrKnightBusCrashed)
+			}
+			return ErrKnightBusCrashed
+		}
+		select {
+		case <-ticker.C():
+			ReportTick(ctx, f.processed.Add(1), "")
+		case <-ctx.Done():
+			ReportCancel(ctx, fmt.Sprintf("Knight Bus (%s) received cancellation signal. Parking now.", name))
+			return nil
+		}
+	}
+}
+
+// Processed reports how many units of work the worker has completed, across
+// every run.
+func (f *Flaky) Processed() int64 {
+	return f.processed.Load()
+}
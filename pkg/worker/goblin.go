@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/cleanup"
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultGoblinInterval is how long a GoblinClerk's cart ride takes when its
+// Interval is zero.
+const DefaultGoblinInterval = 600 * time.Millisecond
+
+// GoblinClerk holds a ledger and a vault key while it rides the carts, and
+// a cart ride, once started, cannot be stopped: the worker only checks its
+// context between rides. How soon it gives its resources back after
+// cancellation depends on how it cleans up: with deferred calls they wait
+// for the ride to end and Run to return; with a cleanup.Registry they are
+// released the moment the context is cancelled, and the worker acknowledges
+// its exit then, with Ack, rather than when Run returns.
+type GoblinClerk struct {
+	// Interval is how long each cart ride takes.
+	Interval time.Duration
+	// Registry releases resources through a cleanup.Registry instead of
+	// deferred calls.
+	Registry bool
+
+	processed atomic.Int64
+}
+
+// Run rides the carts until ctx is cancelled.
+func (g *GoblinClerk) Run(ctx context.Context) error {
+	interval := g.Interval
+	if interval <= 0 {
+		interval = DefaultGoblinInterval
+	}
+	clk := clock.From(ctx)
+
+	// Note when cancellation happens, to say how long each release took.
+	var cancelledAt atomic.Pointer[time.Time]
+	stop := context.AfterFunc(ctx, func() {
+		now := clk.Now()
+		cancelledAt.Store(&now)
+	})
+	defer stop()
+	release := func(what string) func() error {
+		return func() error {
+			after := "before cancellation"
+			if at := cancelledAt.Load(); at != nil {
+				after = fmt.Sprintf("%v after cancellation", clock.Since(clk, *at))
+			} else if ctx.Err() != nil {
+				after = "as soon as it is cancelled" // racing the AfterFunc above
+			}
+			Notef(ctx, "The goblin releases the %s, %s.", what, after)
+			return nil
+		}
+	}
+
+	if g.Registry {
+		Notef(ctx, "A goblin opens the ledger and takes the vault key, registering their release with a cleanup registry.")
+		reg := cleanup.OnCancel(ctx)
+		defer func() {
+			if err := reg.Close(); err != nil {
+				Notef(ctx, "Cleanup reported: %v", err)
+			}
+		}()
+		reg.Add("acknowledge exit", func() error {
+			Ack(ctx) // nothing is held any more, even mid-ride
+			return nil
+		})
+		reg.Add("close ledger", release("ledger"))
+		reg.Add("polish cart", func() error { panic("the cart is made of dragon scales") })
+		reg.Add("return vault key", release("vault key"))
+	} else {
+		Notef(ctx, "A goblin opens the ledger and takes the vault key, deferring their release.")
+		defer release("ledger")()
+		defer release("vault key")()
+	}
+
+	for {
+		clk.Sleep(interval) // a cart ride cannot be stopped halfway
+		ReportTick(ctx, g.processed.Add(1), "Goblin back from a cart ride...")
+		if ctx.Err() != nil {
+			ReportCancel(ctx, fmt.Sprintf("The goblin steps off the cart and sees the cancellation: %v", context.Cause(ctx)))
+			return nil
+		}
+	}
+}
+
+// Processed reports how many cart rides the worker has completed.
+func (g *GoblinClerk) Processed() int64 {
+	return g.processed.Load()
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Running identifies the worker a goroutine is running.
+type Running struct {
+	// Name is the name the worker was launched under.
+	Name string
+	// Type is the worker's Go type, such as *worker.LeakyCauldron.
+	Type string
+}
+
+// running maps the ID of each goroutine inside Execute to the worker it is
+// running.
+var running sync.Map
+
+// Goroutines returns the worker each goroutine inside Execute is running,
+// keyed by goroutine ID as it appears in stack dumps. A leaked worker stays
+// in it for as long as its goroutine lives.
+func Goroutines() map[int]Running {
+	out := make(map[int]Running)
+	running.Range(func(id, r any) bool {
+		out[id.(int)] = r.(Running)
+		return true
+	})
+	return out
+}
+
+// track records that the calling goroutine runs w under name, until the
+// returned function is called.
+func track(name string, w Worker) (untrack func()) {
+	id := goid()
+	running.Store(id, Running{Name: name, Type: fmt.Sprintf("%T", w)})
+	return func() { running.Delete(id) }
+}
+
+// goid returns the ID of the calling goroutine, read from the header of its
+// stack dump, "goroutine 18 [running]:".
+func goid() int {
+	var buf [64]byte
+	s := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	id, _, _ := strings.Cut(s, " ")
+	n, _ := strconv.Atoi(id)
+	return n
+}
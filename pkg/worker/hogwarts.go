@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/adaptive"
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+)
+
+// DefaultHogwartsInterval is the tick interval used when Hogwarts.Interval is zero.
+const DefaultHogwartsInterval = 200 * time.Millisecond
+
+// Hogwarts is a well-behaved worker that checks the context cancellation signal.
+// It uses context.Cause() to report the specific reason for cancellation.
+//
+// A unit of work is instant unless OpTime says otherwise. With OpTimeout
+// or Adaptive set, each unit runs under a child context with a timeout: a
+// unit that overruns is abandoned and counted, and the worker carries on
+// with the next, while cancelling the parent still stops everything.
+type Hogwarts struct {
+	// Interval is how often the worker does a unit of work.
+	Interval time.Duration
+	// OpTime, if set, returns how long the n-th unit attempted takes.
+	OpTime func(n int64) time.Duration
+	// OpTimeout, if positive, bounds each unit of work.
+	OpTimeout time.Duration
+	// Adaptive, if set, bounds each unit of work instead of OpTimeout, and
+	// is told how long each took.
+	Adaptive *adaptive.Timeout
+
+	processed atomic.Int64
+	timedOut  atomic.Int64
+	costs     adaptive.Timeout // what units have taken, unless Adaptive is set
+}
+
+// Run does periodic work until ctx is cancelled.
+func (h *Hogwarts) Run(ctx context.Context) error {
+	Notef(ctx, "Entering Hogwarts. It will check if ctx.Done().")
+
+	interval := h.Interval
+	if interval <= 0 {
+		interval = DefaultHogwartsInterval
+	}
+
+	// The ticker stops with ctx; see ctxutil.Tick.
+	ticks := ctxutil.Tick(ctx, interval)
+
+	for {
+		select {
+		case <-ticks:
+			// Simulates doing some periodic work
+			if h.OpTime != nil && !h.op(ctx) {
+				continue
+			}
+			ReportTick(ctx, h.processed.Add(1), "Hogwarts Doing work...")
+
+		case <-ctx.Done():
+			// **CRITICAL:** The context was cancelled.
+			ReportCancel(ctx, fmt.Sprintf(
+				"Hogwart's received cancellation signal from ctx.Done(). Exiting now.\n"+
+					// ctx.Err() will now contain the basic cancellation error (e.g., context canceled)
+					"Cancellation error (ctx.Err()): %v\n"+
+					// Use context.Cause() to retrieve the specific error passed during the cancel call.
+					"Cancellation cause (context.Cause()): %v\n"+
+					// cause.Explain follows the cause through every error it wraps.
+					"Cause chain: %s",
+				ctx.Err(), context.Cause(ctx), cause.Explain(ctx)))
+
+			return nil // Exit the goroutine cleanly
+		}
+	}
+}
+
+// op does the next unit of work, under a timeout of its own if OpTimeout
+// is set. It reports whether the unit was completed; if not, it either
+// timed out or ctx was cancelled, which the caller's next select sees.
+func (h *Hogwarts) op(ctx context.Context) bool {
+	n := h.processed.Load() + h.timedOut.Load() + 1
+	opCtx, timeout := ctx, h.OpTimeout
+	switch {
+	case h.Adaptive != nil:
+		var cancel context.CancelFunc
+		opCtx, cancel, timeout = h.Adaptive.WithTimeout(ctx)
+		defer cancel()
+	case h.OpTimeout > 0:
+		var cancel context.CancelFunc
+		opCtx, cancel = clock.WithTimeout(ctx, h.OpTimeout)
+		defer cancel()
+	}
+	if expected, ok := h.history().Latency(); ok {
+		CheckBudget(ctx, "unit", expected, func(left time.Duration) string {
+			return fmt.Sprintf("Hogwarts starts unit %d with %v left before its deadline; units have taken up to %v.",
+				n, left.Round(time.Millisecond), expected.Round(time.Millisecond))
+		})
+	}
+	clk := clock.From(ctx)
+	start := clk.Now()
+	timer := clk.NewTimer(h.OpTime(n))
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		h.observe(clock.Since(clk, start))
+		return true
+	case <-opCtx.Done():
+		if ctx.Err() == nil {
+			// Only the child timed out; the worker itself carries on.
+			Notef(ctx, "Hogwarts abandons unit %d after its %v timeout: %v", n, timeout, opCtx.Err())
+			h.timedOut.Add(1)
+			h.observe(clock.Since(clk, start))
+		}
+		return false
+	}
+}
+
+// observe records how long a unit of work took.
+func (h *Hogwarts) observe(d time.Duration) {
+	h.history().Observe(d)
+}
+
+// history returns where the cost of units of work is kept.
+func (h *Hogwarts) history() *adaptive.Timeout {
+	if h.Adaptive != nil {
+		return h.Adaptive
+	}
+	return &h.costs
+}
+
+// Processed reports how many units of work the worker has completed.
+func (h *Hogwarts) Processed() int64 {
+	return h.processed.Load()
+}
+
+// TimedOut reports how many units of work were abandoned for overrunning
+// OpTimeout.
+func (h *Hogwarts) TimedOut() int64 {
+	return h.timedOut.Load()
+}
@@ -0,0 +1,107 @@
+package worker
+
+import "context"
+
+// Hooks observes a worker's lifecycle. Any field may be nil.
+//
+// Hooks travel in the context, in the spirit of net/http/httptrace, so they
+// reach every worker launched beneath the point where they were installed
+// without threading them through each constructor. Start and exit are
+// reported by Execute for every worker, and so is an acknowledgement of the
+// exit, unless the worker gives one earlier; ticks and cancellation receipt
+// are reported by the workers themselves.
+type Hooks struct {
+	// OnStart is called just before the worker's Run method.
+	OnStart func(ctx context.Context)
+	// OnTick is called after each unit of work with the running total.
+	OnTick func(ctx context.Context, processed int64)
+	// OnCancel is called when the worker observes ctx.Done(), with the
+	// context's cause.
+	OnCancel func(ctx context.Context, cause error)
+	// OnAck is called when the worker acknowledges that it has shut down;
+	// see Ack. It may be called more than once for the same worker.
+	OnAck func(ctx context.Context)
+	// OnExit is called after Run returns, with the worker's result.
+	OnExit func(ctx context.Context, r Result)
+}
+
+type hooksKey struct{}
+
+// WithHooks returns a copy of ctx carrying h. If ctx already carries hooks,
+// both sets are called, the earlier ones first.
+func WithHooks(ctx context.Context, h *Hooks) context.Context {
+	if old, ok := ctx.Value(hooksKey{}).(*Hooks); ok {
+		h = compose(old, h)
+	}
+	return context.WithValue(ctx, hooksKey{}, h)
+}
+
+// HooksFrom returns the hooks carried by ctx. It never returns nil, so the
+// reporting methods can be called unconditionally.
+func HooksFrom(ctx context.Context) *Hooks {
+	if h, ok := ctx.Value(hooksKey{}).(*Hooks); ok {
+		return h
+	}
+	return &Hooks{}
+}
+
+// Start reports that the worker is starting.
+func (h *Hooks) Start(ctx context.Context) {
+	if h.OnStart != nil {
+		h.OnStart(ctx)
+	}
+}
+
+// Tick reports a completed unit of work.
+func (h *Hooks) Tick(ctx context.Context, processed int64) {
+	if h.OnTick != nil {
+		h.OnTick(ctx, processed)
+	}
+}
+
+// Cancel reports that the worker observed cancellation.
+func (h *Hooks) Cancel(ctx context.Context, cause error) {
+	if h.OnCancel != nil {
+		h.OnCancel(ctx, cause)
+	}
+}
+
+// Ack reports that the worker acknowledged its shutdown.
+func (h *Hooks) Ack(ctx context.Context) {
+	if h.OnAck != nil {
+		h.OnAck(ctx)
+	}
+}
+
+// Exit reports that the worker returned.
+func (h *Hooks) Exit(ctx context.Context, r Result) {
+	if h.OnExit != nil {
+		h.OnExit(ctx, r)
+	}
+}
+
+// compose returns hooks that call a and then b.
+func compose(a, b *Hooks) *Hooks {
+	return &Hooks{
+		OnStart: func(ctx context.Context) {
+			a.Start(ctx)
+			b.Start(ctx)
+		},
+		OnTick: func(ctx context.Context, processed int64) {
+			a.Tick(ctx, processed)
+			b.Tick(ctx, processed)
+		},
+		OnCancel: func(ctx context.Context, cause error) {
+			a.Cancel(ctx, cause)
+			b.Cancel(ctx, cause)
+		},
+		OnAck: func(ctx context.Context) {
+			a.Ack(ctx)
+			b.Ack(ctx)
+		},
+		OnExit: func(ctx context.Context, r Result) {
+			a.Exit(ctx, r)
+			b.Exit(ctx, r)
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultLeakyInterval is the work interval used when LeakyCauldron.Interval is zero.
+const DefaultLeakyInterval = 500 * time.Millisecond
+
+// LeakyCauldron simulates a task that ignores the context cancellation signal.
+// Its Run method will continue running (and reporting work) indefinitely,
+// even after the context is cancelled, leading to a goroutine leak.
+type LeakyCauldron struct {
+	// Interval is how often the worker does a unit of work.
+	Interval time.Duration
+
+	processed atomic.Int64
+}
+
+// Run loops forever. It never returns.
+func (l *LeakyCauldron) Run(ctx context.Context) error {
+	Notef(ctx, "Entering the Leaky Cauldron. It will never exit gracefully.")
+
+	interval := l.Interval
+	if interval <= 0 {
+		interval = DefaultLeakyInterval
+	}
+
+	// This worker ignores the context, leading to a leak. Nor does it stop
+	// its ticker, which would leak too even if the loop ended.
+	ticker := clock.From(ctx).NewTicker(interval)
+	for range ticker.C() {
+		ReportTick(ctx, l.processed.Add(1), "Leaky Cauldron Doing work...")
+	}
+	return nil
+}
+
+// Processed reports how many units of work the worker has completed.
+func (l *LeakyCauldron) Processed() int64 {
+	return l.processed.Load()
+}
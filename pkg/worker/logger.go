@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Logger receives narration. Workers and scenarios publish their narration
+// as events; LogSink routes those events to a Logger.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// NewLogger returns a Logger that writes to w, serialising concurrent writes.
+func NewLogger(w io.Writer) Logger {
+	return &writerLogger{w: w}
+}
+
+type writerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *writerLogger) Printf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.w, format, args...)
+}
+
+// Stdout is the Logger used when none is configured.
+var Stdout = NewLogger(os.Stdout)
+
+// Discard is a Logger that drops everything, useful for tests.
+var Discard Logger = discard{}
+
+type discard struct{}
+
+func (discard) Printf(string, ...any) {}
+
+// ContextLogger is implemented by loggers that want to see the context of
+// the code doing the logging, for example to extract attributes from it.
+type ContextLogger interface {
+	Logger
+	// WithContext returns a Logger bound to ctx.
+	WithContext(ctx context.Context) Logger
+}
+
+// LoggerFor returns l bound to ctx if l is a ContextLogger, or l unchanged
+// otherwise. A nil l is replaced by Stdout.
+func LoggerFor(l Logger, ctx context.Context) Logger {
+	if l == nil {
+		return Stdout
+	}
+	if cl, ok := l.(ContextLogger); ok {
+		return cl.WithContext(ctx)
+	}
+	return l
+}
+
+// NewSlogLogger returns a Logger that emits every message as an Info record
+// on l. Once bound to a context with LoggerFor, each record carries the
+// worker name, scenario name and request ID stored in that context.
+func NewSlogLogger(l *slog.Logger) ContextLogger {
+	return &slogLogger{l: l, ctx: context.Background()}
+}
+
+type slogLogger struct {
+	l   *slog.Logger
+	ctx context.Context
+}
+
+func (s *slogLogger) Printf(format string, args ...any) {
+	// The narration is written for a terminal; drop the blank lines and
+	// trailing newlines that make no sense in a structured record.
+	msg := strings.TrimSpace(fmt.Sprintf(format, args...))
+	if msg == "" {
+		return
+	}
+	s.l.LogAttrs(s.ctx, slog.LevelInfo, msg, Attrs(s.ctx)...)
+}
+
+func (s *slogLogger) WithContext(ctx context.Context) Logger {
+	return &slogLogger{l: s.l, ctx: ctx}
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultOwlInterval is how long a StrandedOwl works when its Interval is
+// zero.
+const DefaultOwlInterval = 200 * time.Millisecond
+
+// StrandedOwl simulates a task that delivers its result on an unbuffered
+// channel nobody reads any more, as when the caller that was meant to
+// receive it gave up and returned. The send blocks forever, and the
+// goroutine leaks with it; a select on ctx.Done() alongside the send would
+// have let it go.
+type StrandedOwl struct {
+	// Interval is how long the worker works before sending its result.
+	Interval time.Duration
+
+	processed atomic.Int64
+}
+
+// Run does one unit of work and then blocks in a send. It never returns.
+func (o *StrandedOwl) Run(ctx context.Context) error {
+	Notef(ctx, "An owl sets off with a letter. Nobody will be there to take it.")
+
+	interval := o.Interval
+	if interval <= 0 {
+		interval = DefaultOwlInterval
+	}
+	clock.From(ctx).Sleep(interval)
+	n := o.processed.Add(1)
+	ReportTick(ctx, n, "Owl Post: letter written, waiting to hand it over...")
+
+	letters := make(chan int64) // unbuffered, and nobody receives
+	letters <- n
+	return nil
+}
+
+// Processed reports how many units of work the worker has completed.
+func (o *StrandedOwl) Processed() int64 {
+	return o.processed.Load()
+}
@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/cause"
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultOwleryInterval is how long an Owlery takes to send a letter when
+// its Interval is zero. Letters arrive twice as fast, so the queue fills.
+const DefaultOwleryInterval = 200 * time.Millisecond
+
+// DefaultOwleryCapacity is how many letters an Owlery accepts when its
+// Capacity is zero.
+const DefaultOwleryCapacity = 4
+
+// Owlery accepts letters into a queue of its own and sends them one at a
+// time. When its context is cancelled it stops accepting letters, and then
+// either drops those it has accepted, if Drain is zero, or keeps sending
+// them until the queue is empty or Drain has passed, whichever comes first.
+// A cause.UpstreamFailure, cause.OperatorAbort or
+// cause.DeadlineBudgetExhausted drops them at once whatever Drain is.
+type Owlery struct {
+	// Interval is how long each letter takes to send.
+	Interval time.Duration
+	// Capacity is how many accepted letters may wait; letters arriving at a
+	// full queue are turned away.
+	Capacity int
+	// Drain is how long the worker may go on sending accepted letters after
+	// cancellation. Zero drops them at once.
+	Drain time.Duration
+
+	processed atomic.Int64
+}
+
+// Run accepts and sends letters until ctx is cancelled, then drains.
+func (o *Owlery) Run(ctx context.Context) error {
+	interval := o.Interval
+	if interval <= 0 {
+		interval = DefaultOwleryInterval
+	}
+	capacity := o.Capacity
+	if capacity <= 0 {
+		capacity = DefaultOwleryCapacity
+	}
+	Notef(ctx, "The Owlery opens: up to %d letters may wait, and each owl takes %v.", capacity, interval)
+
+	clk := clock.From(ctx)
+	arrivals := clk.NewTicker(interval / 2)
+	defer arrivals.Stop()
+
+	var queue []int64 // accepted letters, oldest first; the first is being sent
+	var letter int64
+	var sending clock.Timer
+	for {
+		var sent <-chan time.Time
+		if len(queue) > 0 {
+			if sending == nil {
+				sending = clk.NewTimer(interval)
+			}
+			sent = sending.C()
+		}
+		select {
+		case <-arrivals.C():
+			letter++
+			if len(queue) < capacity {
+				queue = append(queue, letter)
+			}
+		case <-sent:
+			sending = nil
+			ReportTick(ctx, o.processed.Add(1), fmt.Sprintf("Owlery sent letter %d; %d waiting", queue[0], len(queue)-1))
+			queue = queue[1:]
+		case <-ctx.Done():
+			arrivals.Stop() // no more letters are pulled in
+			return o.drain(ctx, clk, interval, queue, sending)
+		}
+	}
+}
+
+// drain sends the accepted letters in queue for up to o.Drain after
+// cancellation. sending, if not nil, is the timer of the letter in hand.
+func (o *Owlery) drain(ctx context.Context, clk clock.Clock, interval time.Duration, queue []int64, sending clock.Timer) error {
+	period, why := o.drainFor(context.Cause(ctx))
+	if period <= 0 {
+		if sending != nil {
+			sending.Stop()
+		}
+		if why != "" {
+			why = " (" + why + ")"
+		}
+		ReportCancel(ctx, fmt.Sprintf("The Owlery shuts at once%s and drops %d accepted letter(s). Cause: %v", why, len(queue), context.Cause(ctx)))
+		return nil
+	}
+	ReportCancel(ctx, fmt.Sprintf("The Owlery takes no more letters and sends the %d it accepted, for up to %v. Cause: %v", len(queue), period, context.Cause(ctx)))
+
+	deadline := clk.NewTimer(period)
+	defer deadline.Stop()
+	for len(queue) > 0 {
+		if sending == nil {
+			sending = clk.NewTimer(interval)
+		}
+		select {
+		case <-sending.C():
+			sending = nil
+			ReportTick(ctx, o.processed.Add(1), fmt.Sprintf("Owlery sent letter %d while draining; %d waiting", queue[0], len(queue)-1))
+			queue = queue[1:]
+		case <-deadline.C():
+			sending.Stop()
+			Notef(ctx, "The Owlery's drain deadline passed with %d accepted letter(s) unsent.", len(queue))
+			return nil
+		}
+	}
+	Notef(ctx, "The Owlery is drained: every accepted letter was sent.")
+	return nil
+}
+
+// drainFor returns how long to drain after cancellation with c: Drain,
+// unless c is a typed cause that makes draining pointless, in which case it
+// returns zero and why.
+func (o *Owlery) drainFor(c error) (time.Duration, string) {
+	var (
+		up     *cause.UpstreamFailure
+		abort  *cause.OperatorAbort
+		budget *cause.DeadlineBudgetExhausted
+	)
+	switch {
+	case errors.As(c, &up):
+		return 0, "the owls have nowhere to fly"
+	case errors.As(c, &abort):
+		return 0, "an operator said stop"
+	case errors.As(c, &budget):
+		return 0, "there is no time left to drain in"
+	}
+	return o.Drain, "" // cause.ShutdownRequested, or an untyped cause
+}
+
+// Processed reports how many letters the worker has sent.
+func (o *Owlery) Processed() int64 {
+	return o.processed.Load()
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultPortraitInterval is how long a WaitingPortrait works when its
+// Interval is zero.
+const DefaultPortraitInterval = 200 * time.Millisecond
+
+// WaitingPortrait simulates a task that waits for the next job on a channel
+// its producer forgot to close. Once the producer is gone the receive
+// blocks forever, and the goroutine leaks with it: ranging over a channel
+// only ends when someone closes it.
+type WaitingPortrait struct {
+	// Interval is how long the worker works before waiting for more.
+	Interval time.Duration
+
+	processed atomic.Int64
+}
+
+// Run does one unit of work and then blocks in a receive. It never returns.
+func (p *WaitingPortrait) Run(ctx context.Context) error {
+	Notef(ctx, "A portrait waits for visitors. The corridor has been sealed.")
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultPortraitInterval
+	}
+	clock.From(ctx).Sleep(interval)
+	ReportTick(ctx, p.processed.Add(1), "Portrait: visitor greeted, waiting for the next...")
+
+	visitors := make(chan string) // never closed, and nobody sends
+	for range visitors {
+		p.processed.Add(1)
+	}
+	return nil
+}
+
+// Processed reports how many units of work the worker has completed.
+func (p *WaitingPortrait) Processed() int64 {
+	return p.processed.Load()
+}
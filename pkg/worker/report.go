@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+	"strings"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/event"
+)
+
+// Header returns an event header for ctx: stamped with the context's clock
+// and carrying its scenario name, worker name and request ID.
+func Header(ctx context.Context, msg string) event.Header {
+	h := event.Header{Time: clock.From(ctx).Now(), Message: msg}
+	h.Scenario, _ = ScenarioName(ctx)
+	h.Worker, _ = WorkerName(ctx)
+	h.RequestID, _ = RequestID(ctx)
+	return h
+}
+
+// Notef publishes narration as a Note event on the bus carried by ctx.
+// A single trailing newline is dropped, as event messages never end in one.
+func Notef(ctx context.Context, format string, args ...any) {
+	msg := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+	event.BusFrom(ctx).Publish(event.Note{Header: Header(ctx, msg)})
+}
+
+// ReportTick reports a completed unit of work: it calls the OnTick hook and
+// publishes a TickCompleted event narrated by msg.
+func ReportTick(ctx context.Context, processed int64, msg string) {
+	HooksFrom(ctx).Tick(ctx, processed)
+	event.BusFrom(ctx).Publish(event.TickCompleted{Header: Header(ctx, msg), Processed: processed})
+}
+
+// ReportCancel reports that the worker observed ctx.Done(): it calls the
+// OnCancel hook and publishes a CancellationReceived event narrated by msg.
+func ReportCancel(ctx context.Context, msg string) {
+	cause := context.Cause(ctx)
+	HooksFrom(ctx).Cancel(ctx, cause)
+	trace.Log(ctx, "cancel", fmt.Sprint(cause))
+	event.BusFrom(ctx).Publish(event.CancellationReceived{Header: Header(ctx, msg), Err: ctx.Err(), Cause: cause})
+}
+
+// Ack confirms that the worker has shut down as far as anyone else is
+// concerned: it has released what it holds and will take on no new work,
+// even though Run may not have returned yet. Execute acknowledges for every
+// worker when Run returns, so most workers never call it. It calls the
+// OnAck hook.
+func Ack(ctx context.Context) {
+	HooksFrom(ctx).Ack(ctx)
+}
+
+// CheckBudget returns the time left before ctx's deadline, and false if it
+// has none. If that is less than expected, what op has cost before, it
+// publishes a LowBudget event narrated by msg(left): the operation is
+// likely to be cut short, and the worker may rather not start it. msg is
+// not called otherwise.
+func CheckBudget(ctx context.Context, op string, expected time.Duration, msg func(left time.Duration) string) (time.Duration, bool) {
+	left, ok := ctxutil.Remaining(ctx)
+	if ok && left < expected {
+		event.BusFrom(ctx).Publish(event.LowBudget{Header: Header(ctx, msg(left)), Op: op, Remaining: left, Expected: expected})
+	}
+	return left, ok
+}
+
+// ReportLeaked publishes a WorkerLeaked event for r, a result made by Leaked.
+// ctx should be the context the worker was started with.
+func ReportLeaked(ctx context.Context, r Result) {
+	ctx = WithWorkerName(ctx, r.Worker)
+	event.BusFrom(ctx).Publish(event.WorkerLeaked{Header: Header(ctx, ""), Processed: r.Processed, Blocked: r.Blocked})
+}
+
+// LogSink returns an event sink that narrates to l. Each message is logged
+// through l bound, as by LoggerFor, to a context carrying the event's
+// scenario name, worker name and request ID.
+func LogSink(l Logger) event.Sink {
+	return event.SinkFunc(func(e event.Event) {
+		h := e.EventHeader()
+		if h.Message == "" {
+			return
+		}
+		ctx := context.Background()
+		if h.Scenario != "" {
+			ctx = WithScenarioName(ctx, h.Scenario)
+		}
+		if h.Worker != "" {
+			ctx = WithWorkerName(ctx, h.Worker)
+		}
+		if h.RequestID != "" {
+			ctx = WithRequestID(ctx, h.RequestID)
+		}
+		LoggerFor(l, ctx).Printf("%s\n", h.Message)
+	})
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/event"
+)
+
+// ExitReason describes why a worker stopped running.
+type ExitReason int
+
+const (
+	// ExitUnknown is the zero ExitReason.
+	ExitUnknown ExitReason = iota
+	// ExitCancelled means the worker returned nil after its context was cancelled.
+	ExitCancelled
+	// ExitCompleted means the worker returned nil while its context was still live.
+	ExitCompleted
+	// ExitFailed means the worker returned a non-nil error.
+	ExitFailed
+	// ExitLeaked means the worker had not returned when the result was taken.
+	ExitLeaked
+)
+
+func (r ExitReason) String() string {
+	switch r {
+	case ExitCancelled:
+		return "cancelled"
+	case ExitCompleted:
+		return "completed"
+	case ExitFailed:
+		return "failed"
+	case ExitLeaked:
+		return "leaked"
+	default:
+		return "unknown"
+	}
+}
+
+// Result describes how a single worker run ended.
+type Result struct {
+	// Worker is the name the worker was launched under.
+	Worker string
+	// Exit is why the worker stopped.
+	Exit ExitReason
+	// Err is the error returned by Run, if any.
+	Err error
+	// Cause is context.Cause of the worker's context when it exited, and
+	// CtxErr its Err.
+	Cause  error
+	CtxErr error
+	// StartedAt is when Run was called, and ExitedAt when it returned.
+	// ExitedAt is zero for leaked workers.
+	StartedAt time.Time
+	ExitedAt  time.Time
+	// Latency is the time between cancellation and exit. It is filled in by
+	// whoever knows when cancellation happened, and is zero otherwise.
+	Latency time.Duration
+	// Propagation is the time between cancellation and the worker reporting
+	// that it saw it, through the OnCancel hook: how long the signal took to
+	// reach it. Like Latency, it is filled in by the caller, and is zero if
+	// the worker never reported cancellation.
+	Propagation time.Duration
+	// Processed is the number of units of work the worker completed.
+	Processed int64
+	// Observed reports that the worker said it saw cancellation, through
+	// the OnCancel hook. Like Latency, it is filled in by the caller. A
+	// leaked worker that observed cancellation is stuck shutting down
+	// rather than ignoring its context. ObservedAt is when it did.
+	Observed   bool
+	ObservedAt time.Time
+	// Blocked reports that a leaked worker had stopped reporting ticks when
+	// the result was taken, so it is stuck rather than spinning; see
+	// package heartbeat. Like Observed, it is filled in by the caller.
+	Blocked bool
+	// Acked reports that the worker acknowledged its exit, by returning or
+	// through Ack. Like Observed, it is filled in by the caller.
+	Acked bool
+}
+
+// Reason refines r.Exit with why the worker's context ended; see
+// event.Reason.
+func (r Result) Reason() string {
+	return event.Reason(r.Exit.String(), r.CtxErr)
+}
+
+// Counter is implemented by workers that count the units of work they process.
+type Counter interface {
+	Processed() int64
+}
+
+// Labels returns the pprof labels Execute runs w under, so CPU and
+// goroutine profiles can be broken down by worker: the scenario name, the
+// worker's type, such as LeakyCauldron, and its instance name, such as
+// leaky-cauldron-2, all read from ctx.
+func Labels(ctx context.Context, w Worker) pprof.LabelSet {
+	scenario, _ := ScenarioName(ctx)
+	instance, _ := WorkerName(ctx)
+	typ := fmt.Sprintf("%T", w)
+	typ = typ[strings.LastIndex(typ, ".")+1:]
+	return pprof.Labels("scenario", scenario, "worker", typ, "instance", instance)
+}
+
+// Execute runs w with ctx and describes how it exited. The worker's name is
+// stored in the context it receives; see WithWorkerName. Execute calls the
+// OnStart, OnAck and OnExit hooks and publishes WorkerStarted and
+// WorkerExited.
+func Execute(ctx context.Context, name string, w Worker) Result {
+	ctx = WithWorkerName(ctx, name)
+	// A worker's lifetime is a trace task, so in a runtime/trace capture a
+	// leaked worker shows up as a task that never ends.
+	ctx, task := trace.NewTask(ctx, "worker "+name)
+	defer task.End()
+	defer track(name, w)()
+	hooks := HooksFrom(ctx)
+	bus := event.BusFrom(ctx)
+	startedAt := clock.From(ctx).Now()
+	hooks.Start(ctx)
+	bus.Publish(event.WorkerStarted{Header: Header(ctx, "")})
+	var err error
+	pprof.Do(ctx, Labels(ctx, w), func(ctx context.Context) {
+		trace.WithRegion(ctx, "run", func() { err = w.Run(ctx) })
+	})
+	hooks.Ack(ctx)
+	r := Result{
+		Worker:    name,
+		Err:       err,
+		StartedAt: startedAt,
+		ExitedAt:  clock.From(ctx).Now(),
+		Processed: processed(w),
+	}
+	if ctx.Err() != nil {
+		r.Cause, r.CtxErr = context.Cause(ctx), ctx.Err()
+	}
+	switch {
+	case err != nil:
+		r.Exit = ExitFailed
+	case ctx.Err() != nil:
+		r.Exit = ExitCancelled
+	default:
+		r.Exit = ExitCompleted
+	}
+	hooks.Exit(ctx, r)
+	bus.Publish(event.WorkerExited{
+		Header:    Header(ctx, ""),
+		Exit:      r.Exit.String(),
+		Err:       r.Err,
+		Cause:     r.Cause,
+		CtxErr:    r.CtxErr,
+		Processed: r.Processed,
+	})
+	return r
+}
+
+// Leaked describes a worker that has not returned from Run.
+func Leaked(name string, w Worker) Result {
+	return Result{Worker: name, Exit: ExitLeaked, Processed: processed(w)}
+}
+
+func processed(w Worker) int64 {
+	if c, ok := w.(Counter); ok {
+		return c.Processed()
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/ctxutil"
+)
+
+// DefaultScribeInterval is how often a Scribe does a unit of work when its
+// Interval is zero.
+const DefaultScribeInterval = 200 * time.Millisecond
+
+// DefaultAuditTimeout is how long a Scribe's audit write may take when its
+// AuditTimeout is zero.
+const DefaultAuditTimeout = 300 * time.Millisecond
+
+// ErrAuditTimeout is the cause an audit write is abandoned with when it
+// takes longer than its timeout.
+var ErrAuditTimeout = errors.New("the audit write ran out of time")
+
+// Scribe does periodic work and, when cancelled, fires off a final audit
+// record of what it did in a goroutine of its own and returns. The write
+// runs under context.WithoutCancel(ctx), so the cancellation that stopped
+// the worker does not stop it too, with a timeout of its own, so it cannot
+// outlive the worker by more than AuditTimeout either.
+type Scribe struct {
+	// Interval is how often the worker does a unit of work.
+	Interval time.Duration
+	// AuditTime is how long the audit write takes.
+	AuditTime time.Duration
+	// AuditTimeout bounds the audit write.
+	AuditTimeout time.Duration
+	// Naive writes the audit record under the worker's own, cancelled,
+	// context instead, which is what goes wrong without WithoutCancel.
+	Naive bool
+
+	processed atomic.Int64
+	audits    sync.WaitGroup
+}
+
+// Run does periodic work until ctx is cancelled, then starts the audit
+// write and returns without waiting for it.
+func (s *Scribe) Run(ctx context.Context) error {
+	Notef(ctx, "A scribe dips its quill. It will write an audit record when it is stopped.")
+
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultScribeInterval
+	}
+	ticks := ctxutil.Tick(ctx, interval)
+	for {
+		select {
+		case <-ticks:
+			ReportTick(ctx, s.processed.Add(1), "Scribe copying scrolls...")
+		case <-ctx.Done():
+			ReportCancel(ctx, fmt.Sprintf("The scribe is stopped (%v) and sends off its audit record.", context.Cause(ctx)))
+			auditCtx := ctx
+			if !s.Naive {
+				auditCtx = context.WithoutCancel(ctx)
+			}
+			s.audits.Add(1)
+			go s.audit(auditCtx, s.processed.Load())
+			return nil
+		}
+	}
+}
+
+// audit writes the record that the worker processed n units, taking
+// AuditTime, unless ctx or the audit's own timeout ends first.
+func (s *Scribe) audit(ctx context.Context, n int64) {
+	defer s.audits.Done()
+	timeout := s.AuditTimeout
+	if timeout <= 0 {
+		timeout = DefaultAuditTimeout
+	}
+	ctx, cancel := clock.WithTimeoutCause(ctx, timeout, ErrAuditTimeout)
+	defer cancel()
+
+	write := clock.From(ctx).NewTimer(s.AuditTime)
+	select {
+	case <-write.C():
+		Notef(ctx, "Audit record written: %d scroll(s) copied.", n)
+	case <-ctx.Done():
+		write.Stop()
+		Notef(ctx, "Audit record lost: %v.", context.Cause(ctx))
+	}
+}
+
+// WaitAudits blocks until every audit write the worker started has finished
+// or given up, which takes at most AuditTimeout.
+func (s *Scribe) WaitAudits() {
+	s.audits.Wait()
+}
+
+// Processed reports how many units of work the worker has completed.
+func (s *Scribe) Processed() int64 {
+	return s.processed.Load()
+}
@@ -0,0 +1,93 @@
+// Package stream provides typed producer workers.
+//
+// A stream Worker does not log or return an error: it hands each value it
+// produces to the caller over a channel, and closes that channel once it
+// observes cancellation. This shows how cancellation reaches a producer that
+// may be blocked sending to a consumer, not just one that is sleeping.
+package stream
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/ctxutil"
+	"github.com/context-demo/pkg/worker"
+)
+
+// Worker produces values of type T until its context is cancelled.
+type Worker[T any] interface {
+	// Run starts the producer and returns the channel it sends on. The
+	// channel is closed once the producer has observed ctx.Done().
+	Run(ctx context.Context) <-chan T
+}
+
+// DefaultInterval is the production interval used when Generator.Interval is zero.
+const DefaultInterval = 200 * time.Millisecond
+
+// Generator is a Worker that produces Next(n) every Interval, where n counts
+// from 1.
+type Generator[T any] struct {
+	// Interval is how often a value is produced.
+	Interval time.Duration
+	// Next returns the n-th value.
+	Next func(n int64) T
+}
+
+// Run implements Worker. Both the wait for the next tick and the send to the
+// consumer select on ctx.Done(), so a consumer that stops reading cannot
+// strand the producer goroutine.
+func (g *Generator[T]) Run(ctx context.Context) <-chan T {
+	interval := g.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+
+		ticks := ctxutil.Tick(ctx, interval)
+
+		for n := int64(1); ; n++ {
+			select {
+			case <-ticks:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case ch <- g.Next(n):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Drain adapts w to a worker.Worker that hands every produced value to fn
+// and returns when w closes its channel. The adapter counts the values it
+// receives, so its results report them as processed.
+func Drain[T any](w Worker[T], fn func(ctx context.Context, v T)) worker.Worker {
+	return &drain[T]{w: w, fn: fn}
+}
+
+type drain[T any] struct {
+	w         Worker[T]
+	fn        func(ctx context.Context, v T)
+	processed atomic.Int64
+}
+
+func (d *drain[T]) Run(ctx context.Context) error {
+	for v := range d.w.Run(ctx) {
+		d.fn(ctx, v)
+		worker.ReportTick(ctx, d.processed.Add(1), "")
+	}
+	worker.ReportCancel(ctx, "")
+	return nil
+}
+
+// Processed reports how many values have been received.
+func (d *drain[T]) Processed() int64 {
+	return d.processed.Load()
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+	"github.com/context-demo/pkg/pause"
+)
+
+// DefaultStudentInterval is the tick interval used when Student.Interval
+// is zero.
+const DefaultStudentInterval = 200 * time.Millisecond
+
+// Student studies periodically, like Hogwarts, and can be petrified: while
+// its context's pause.Switch is paused it does no work at all, and once the
+// switch is resumed it picks up where it left off. Being petrified is not
+// being cancelled; cancellation still ends its run, paused or not.
+type Student struct {
+	// Interval is how often the worker does a unit of work.
+	Interval time.Duration
+
+	processed atomic.Int64
+}
+
+// Run studies until ctx is cancelled, stopping for as long as it is paused.
+func (s *Student) Run(ctx context.Context) error {
+	Notef(ctx, "A student starts studying. It checks for petrification as well as ctx.Done().")
+
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultStudentInterval
+	}
+
+	clk := clock.From(ctx)
+	ticker := clk.NewTicker(interval)
+	defer func() { ticker.Stop() }() // the ticker is replaced after each pause
+
+	for {
+		select {
+		case <-ticker.C():
+			ReportTick(ctx, s.processed.Add(1), "Student studying...")
+
+		case <-pause.Paused(ctx):
+			// No ticks pile up while petrified.
+			ticker.Stop()
+			Notef(ctx, "The student is petrified after %d unit(s) of work.", s.processed.Load())
+			if err := pause.Wait(ctx); err != nil {
+				ReportCancel(ctx, fmt.Sprintf("The student was cancelled while petrified: %v", err))
+				return nil
+			}
+			Notef(ctx, "The student is revived and carries on from %d.", s.processed.Load())
+			ticker = clk.NewTicker(interval)
+
+		case <-ctx.Done():
+			ReportCancel(ctx, fmt.Sprintf("The student received cancellation signal: %v", context.Cause(ctx)))
+			return nil
+		}
+	}
+}
+
+// Processed reports how many units of work the worker has completed.
+func (s *Student) Processed() int64 {
+	return s.processed.Load()
+}
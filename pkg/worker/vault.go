@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/context-demo/pkg/clock"
+)
+
+// DefaultVaultInterval is how long a LockedVault works when its Interval is
+// zero.
+const DefaultVaultInterval = 200 * time.Millisecond
+
+// LockedVault simulates a task that needs a mutex whose holder exited
+// without unlocking it, as when an early return skips the Unlock that a
+// defer would have guaranteed. Lock blocks forever, and no context can
+// interrupt it.
+type LockedVault struct {
+	// Interval is how long the worker works before taking the lock.
+	Interval time.Duration
+
+	processed atomic.Int64
+}
+
+// Run does one unit of work and then blocks on the lock. It never returns.
+func (v *LockedVault) Run(ctx context.Context) error {
+	Notef(ctx, "A goblin opens the vault, then leaves without locking up after.")
+
+	var mu sync.Mutex
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mu.Lock() // and returns without mu.Unlock()
+	}()
+	<-done
+
+	interval := v.Interval
+	if interval <= 0 {
+		interval = DefaultVaultInterval
+	}
+	clock.From(ctx).Sleep(interval)
+	ReportTick(ctx, v.processed.Add(1), "Gringotts: gold counted, waiting for the vault...")
+
+	mu.Lock()
+	defer mu.Unlock()
+	v.processed.Add(1)
+	return nil
+}
+
+// Processed reports how many units of work the worker has completed.
+func (v *LockedVault) Processed() int64 {
+	return v.processed.Load()
+}
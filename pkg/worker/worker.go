@@ -0,0 +1,29 @@
+// Package worker contains the goroutines used by the context demonstrations.
+//
+// Every worker satisfies the same Worker contract, so the well-behaved and
+// leaky variants can be launched side by side and other programs can plug in
+// their own workers against the same interface.
+package worker
+
+import "context"
+
+// Worker is a unit of work driven by a context.
+//
+// A well-behaved Worker returns soon after ctx is cancelled. Run returns nil
+// when the worker exited because of cancellation and a non-nil error when it
+// failed on its own.
+//
+// Returning from Run acknowledges the exit. A worker that has released
+// everything it holds but cannot return straight away may acknowledge
+// earlier with Ack, so whoever is waiting for it need not.
+type Worker interface {
+	Run(ctx context.Context) error
+}
+
+// Func adapts an ordinary function to the Worker interface.
+type Func func(ctx context.Context) error
+
+// Run calls f(ctx).
+func (f Func) Run(ctx context.Context) error {
+	return f(ctx)
+}